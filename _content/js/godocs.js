@@ -39,26 +39,35 @@
     }
 
     var toc_items = [];
-    $(nav)
-      .nextAll('h2, h3')
-      /* Headings may be nested within div.markdown. */
-      .add($(nav).nextAll('div.markdown').find('h2, h3'))
-      .each(function() {
-        var node = this;
-        if (node.id == '') node.id = 'tmp_' + toc_items.length;
-        var link = $('<a/>')
-          .attr('href', '#' + node.id)
-          .text($(node).text());
-        var item;
-        if ($(node).is('h2')) {
-          item = $('<dt/>');
-        } else {
-          // h3
-          item = $('<dd class="indent"/>');
-        }
-        item.append(link);
-        toc_items.push(item);
+    var list = $(nav).children('dl');
+    if (list.length > 0) {
+      // The server listed the headings of a Markdown page.
+      list.children('dt, dd').each(function() {
+        toc_items.push($(this));
       });
+      list.remove();
+    } else {
+      $(nav)
+        .nextAll('h2, h3')
+        /* Headings may be nested within div.markdown. */
+        .add($(nav).nextAll('div.markdown').find('h2, h3'))
+        .each(function() {
+          var node = this;
+          if (node.id == '') node.id = 'tmp_' + toc_items.length;
+          var link = $('<a/>')
+            .attr('href', '#' + node.id)
+            .text($(node).text());
+          var item;
+          if ($(node).is('h2')) {
+            item = $('<dt/>');
+          } else {
+            // h3
+            item = $('<dd class="indent"/>');
+          }
+          item.append(link);
+          toc_items.push(item);
+        });
+    }
     if (toc_items.length <= 1) {
       return;
     }
@@ -358,6 +367,62 @@
     $(this).toggleClass('collapsed');
   });
 
+  /* Binds the box for jumping to a symbol (see pkgjump in site.tmpl):
+   * pressing f focuses it, and typing in it lists matching symbols.
+   */
+  function bindSymbolJump() {
+    var form = document.querySelector('.js-pkgJump');
+    if (!form) {
+      return;
+    }
+    var input = form.querySelector('input[name=q]');
+    var list = form.querySelector('datalist');
+    var timer;
+
+    document.addEventListener('keypress', function(e) {
+      var t = e.target;
+      if (
+        e.key !== 'f' ||
+        e.ctrlKey ||
+        e.metaKey ||
+        e.altKey ||
+        t.tagName === 'INPUT' ||
+        t.tagName === 'SELECT' ||
+        t.tagName === 'TEXTAREA' ||
+        t.isContentEditable
+      ) {
+        return;
+      }
+      e.preventDefault();
+      input.focus();
+    });
+
+    input.addEventListener('input', function() {
+      clearTimeout(timer);
+      timer = setTimeout(function() {
+        var q = input.value.trim();
+        if (q === '') {
+          list.replaceChildren();
+          return;
+        }
+        fetch('/pkg/?json&m=old&q=' + encodeURIComponent(q))
+          .then(function(resp) {
+            return resp.json();
+          })
+          .then(function(syms) {
+            list.replaceChildren();
+            (syms || []).slice(0, 20).forEach(function(sym) {
+              var opt = document.createElement('option');
+              opt.value = sym.ImportPath + '.' + sym.Name;
+              opt.label = sym.Kind + ' ' + sym.Package + '.' + sym.Name;
+              list.appendChild(opt);
+            });
+          })
+          .catch(function() {});
+      }, 150);
+    });
+  }
+
   function toggleExamples(className) {
     // We need to explicitly iterate through divs starting with "example_"
     // to avoid toggling Overview and Index collapsibles.
@@ -386,6 +451,7 @@
     toggleHash();
     personalizeInstallInstructions();
     updateVersionTags();
+    bindSymbolJump();
 
     // site.js defines window.initFuncs in the global scope, and play.js and
     // codewalk.js push their on-page-ready functions to the list.
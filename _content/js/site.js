@@ -256,6 +256,44 @@ window.initFuncs = [];
     }
   }
 
+  /**
+   * Switches between the code blocks in tabbed code groups
+   * written in Markdown (see “Markdown Extensions” in the internal/web package doc).
+   */
+  function registerCodeTabs() {
+    for (const list of document.querySelectorAll('.CodeTabs-list')) {
+      const tabs = Array.from(list.querySelectorAll('[role="tab"]'));
+      const select = tab => {
+        for (const t of tabs) {
+          const selected = t === tab;
+          t.setAttribute('aria-selected', selected ? 'true' : 'false');
+          t.tabIndex = selected ? 0 : -1;
+          document.getElementById(t.getAttribute('aria-controls')).hidden = !selected;
+        }
+      };
+      tabs.forEach((tab, i) => {
+        tab.addEventListener('click', () => select(tab));
+        tab.addEventListener('keydown', e => {
+          let next;
+          if (e.key === 'ArrowRight') {
+            next = tabs[(i + 1) % tabs.length];
+          } else if (e.key === 'ArrowLeft') {
+            next = tabs[(i - 1 + tabs.length) % tabs.length];
+          } else if (e.key === 'Home') {
+            next = tabs[0];
+          } else if (e.key === 'End') {
+            next = tabs[tabs.length - 1];
+          } else {
+            return;
+          }
+          e.preventDefault();
+          select(next);
+          next.focus();
+        });
+      });
+    }
+  }
+
   /**
    * Retrieves list of Go versions & returns the latest
    */
@@ -360,6 +398,7 @@ window.initFuncs = [];
     setVersionSpans();
     registerPortToggles();
     registerCookieNotice();
+    registerCodeTabs();
   };
 
   // DOM might be already loaded when we try to setup the callback, hence the check.
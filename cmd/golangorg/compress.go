@@ -0,0 +1,406 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"container/list"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+)
+
+// minCompress is the size below which responses are sent uncompressed:
+// compressing them saves too little to be worth the time.
+const minCompress = 1024
+
+// compressHandler returns a handler that compresses the responses from h
+// with brotli or gzip, as negotiated with the client's Accept-Encoding header.
+//
+// Responses of a text-like type (see compressible) are compressed, unless
+// they are small, already encoded, partial, or have no body.
+// Compressed responses carry a weak form of the ETag set by h,
+// and every response that could be compressed carries “Vary: Accept-Encoding”.
+//
+// Once the first minCompress bytes of a response show that it is worth
+// compressing, the rest is compressed as it is written, and each flush
+// by h, as for a stream of events, passes through.
+// A complete response with an ETag is compressed at a high compression
+// level and kept in cache, since its ETag identifies its content:
+// the Site's ETags are hashes of static files and of the sources of rendered pages.
+// Later requests for the same response are sent the cached copy.
+//
+// compressHandler must be the outermost handler that changes response bodies,
+// since the others, like linkRewriter and reloadWriter, expect uncompressed HTML.
+func compressHandler(h http.Handler, cache *compressCache) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" && r.Method != "HEAD" {
+			h.ServeHTTP(w, r)
+			return
+		}
+		// HEAD responses are not compressed but do get the Vary header.
+		cw := &compressWriter{w: w, r: r, cache: cache}
+		if r.Method == "GET" {
+			cw.enc = acceptEncoding(r.Header.Get("Accept-Encoding"))
+		}
+		h.ServeHTTP(cw, r)
+		cw.finish()
+	})
+}
+
+// acceptEncoding returns the content coding to use for a response,
+// given the request's Accept-Encoding header: "br", "gzip", or ""
+// for none. When the client accepts both equally, brotli wins.
+func acceptEncoding(accept string) string {
+	q := make(map[string]float64)
+	for _, elem := range strings.Split(accept, ",") {
+		name, params, _ := strings.Cut(elem, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		v := 1.0
+		for _, p := range strings.Split(params, ";") {
+			if s, ok := strings.CutPrefix(strings.TrimSpace(p), "q="); ok {
+				f, err := strconv.ParseFloat(s, 64)
+				if err != nil {
+					f = 0
+				}
+				v = f
+			}
+		}
+		q[name] = v
+	}
+	best, bestQ := "", 0.0
+	for _, enc := range []string{"br", "gzip"} {
+		v, ok := q[enc]
+		if !ok {
+			v = q["*"]
+		}
+		if v > bestQ {
+			best, bestQ = enc, v
+		}
+	}
+	return best
+}
+
+// compressible reports whether responses with the given Content-Type
+// are worth compressing.
+func compressible(ctype string) bool {
+	mt, _, err := mime.ParseMediaType(ctype)
+	if err != nil {
+		return false
+	}
+	switch mt {
+	case "text/event-stream":
+		// Streamed to the browser event by event; see reloader.serveEvents.
+		return false
+	case "application/javascript", "application/json", "application/xml",
+		"application/atom+xml", "application/rss+xml", "application/manifest+json",
+		"application/wasm", "image/svg+xml":
+		return true
+	}
+	return strings.HasPrefix(mt, "text/")
+}
+
+// A compressWriter is an http.ResponseWriter that compresses
+// the response written to w, if appropriate. See compressHandler.
+type compressWriter struct {
+	w     http.ResponseWriter
+	r     *http.Request
+	cache *compressCache
+	enc   string // negotiated content coding; "" for none
+
+	status   int         // status passed to WriteHeader; 0 if not yet called
+	decided  bool        // whether the response will be compressed has been decided
+	compress bool        // compressing the response
+	buf      []byte      // start of the response, until there is enough to compress
+	zw       encoder     // encoder writing to w, once compressing has started
+	key      compressKey // cache key for the compressed response; zero if not cacheable
+	tee      *cacheTee   // copy of the compressed response for the cache, if cacheable
+	cached   bool        // sent the cached response; discarding the handler's output
+}
+
+func (cw *compressWriter) Header() http.Header {
+	return cw.w.Header()
+}
+
+func (cw *compressWriter) WriteHeader(code int) {
+	if cw.status != 0 {
+		return
+	}
+	cw.status = code
+	if _, ok := cw.Header()["Content-Type"]; ok || code < 200 || code == http.StatusNoContent || code == http.StatusNotModified {
+		cw.decide(nil)
+	}
+	// Otherwise wait for the first Write to sniff the Content-Type, as http.ResponseWriter does.
+}
+
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	if !cw.decided {
+		cw.decide(b)
+	}
+	switch {
+	case !cw.compress:
+		return cw.w.Write(b)
+	case cw.cached:
+		return len(b), nil
+	case cw.zw != nil:
+		return cw.zw.Write(b)
+	}
+	cw.buf = append(cw.buf, b...)
+	if len(cw.buf) >= minCompress {
+		cw.start()
+	}
+	return len(b), nil
+}
+
+// Flush starts compressing a response, if it is to be compressed,
+// and sends what has been written so far.
+func (cw *compressWriter) Flush() {
+	if cw.compress && !cw.cached && cw.zw == nil {
+		cw.start()
+	}
+	if cw.zw != nil {
+		cw.zw.Flush()
+	}
+	if f, ok := cw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// decide decides whether to compress the response,
+// given the first data written, if any.
+// If not, it sends the response header.
+func (cw *compressWriter) decide(data []byte) {
+	cw.decided = true
+	h := cw.Header()
+	ctype, haveType := h["Content-Type"]
+	if !haveType && data != nil {
+		// Sniff now, as http.ResponseWriter would on the first Write.
+		ctype = []string{http.DetectContentType(data)}
+		h["Content-Type"] = ctype
+	}
+	if len(ctype) > 0 && compressible(ctype[0]) && h.Get("Content-Encoding") == "" {
+		h.Add("Vary", "Accept-Encoding")
+		// Compress successful responses and error pages, but not partial
+		// content or redirects, whose bodies are not worth the trouble.
+		if cw.enc != "" && (cw.status == http.StatusOK || cw.status >= 400) {
+			n, err := strconv.Atoi(h.Get("Content-Length"))
+			cw.compress = err != nil || n >= minCompress
+		}
+	}
+	if cw.compress {
+		h.Del("Content-Length")
+		return
+	}
+	if cw.status != 0 {
+		cw.w.WriteHeader(cw.status)
+	}
+}
+
+// start starts sending a compressed response:
+// the cached copy, if there is one, or else the buffered start
+// of the response, compressed, followed by the rest as it is written.
+func (cw *compressWriter) start() {
+	h := cw.Header()
+	if etag := h.Get("Etag"); etag != "" && cw.status == http.StatusOK {
+		cw.key = compressKey{host: cw.r.Host, path: cw.r.URL.Path, etag: etag, enc: cw.enc}
+	}
+	if z, ok := cw.cache.get(cw.key); ok {
+		h.Set("Content-Length", strconv.Itoa(len(z)))
+		cw.writeHeader()
+		cw.w.Write(z)
+		cw.cached = true
+		cw.buf = nil
+		return
+	}
+
+	var out io.Writer = cw.w
+	if max := cw.cache.maxEntry(); cw.key.etag != "" && max > 0 {
+		cw.tee = &cacheTee{w: cw.w, max: max, ok: true}
+		out = cw.tee
+	}
+	cw.writeHeader()
+	cw.zw = newEncoder(out, cw.enc, cw.key.etag != "")
+	cw.zw.Write(cw.buf)
+	cw.buf = nil
+}
+
+// writeHeader sends the header for a compressed response.
+func (cw *compressWriter) writeHeader() {
+	h := cw.Header()
+	h.Set("Content-Encoding", cw.enc)
+	if etag := h.Get("Etag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		// The compressed body differs from the uncompressed one,
+		// so the ETag can only be a weak validator for it.
+		h.Set("Etag", "W/"+etag)
+	}
+	cw.w.WriteHeader(cw.status)
+}
+
+// finish completes the response after the handler returns.
+func (cw *compressWriter) finish() {
+	if !cw.decided {
+		if cw.status != 0 {
+			cw.decide(nil)
+		}
+		return
+	}
+	if !cw.compress || cw.cached {
+		return
+	}
+	if cw.zw == nil {
+		// The response turned out too small to compress.
+		h := cw.Header()
+		h.Set("Content-Length", strconv.Itoa(len(cw.buf)))
+		cw.w.WriteHeader(cw.status)
+		cw.w.Write(cw.buf)
+		return
+	}
+	if err := cw.zw.Close(); err == nil && cw.tee != nil && cw.tee.ok {
+		cw.cache.add(cw.key, cw.tee.buf.Bytes())
+	}
+}
+
+// A cacheTee is an io.Writer that writes to w,
+// keeping a copy of what it writes for the compressCache.
+// If the copy grows larger than max bytes, or a write to w fails,
+// it discards the copy and sets ok to false.
+type cacheTee struct {
+	w   io.Writer
+	max int
+	ok  bool
+	buf bytes.Buffer
+}
+
+func (t *cacheTee) Write(b []byte) (int, error) {
+	n, err := t.w.Write(b)
+	if t.ok {
+		if err != nil || t.buf.Len()+len(b) > t.max {
+			t.ok = false
+			t.buf = bytes.Buffer{}
+		} else {
+			t.buf.Write(b)
+		}
+	}
+	return n, err
+}
+
+// An encoder is a brotli or gzip encoder.
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// newEncoder returns an encoder for the content coding enc ("br" or "gzip")
+// writing to w.
+// If best is true, the encoder takes extra time to compress better,
+// for a response that will be cached.
+func newEncoder(w io.Writer, enc string, best bool) encoder {
+	if enc == "br" {
+		level := 4
+		if best {
+			level = 9
+		}
+		return brotli.NewWriterLevel(w, level)
+	}
+	level := gzip.DefaultCompression
+	if best {
+		level = gzip.BestCompression
+	}
+	z, _ := gzip.NewWriterLevel(w, level) // error only for invalid level
+	return z
+}
+
+// A compressCache is an LRU cache of compressed responses, limited by total size.
+// A nil *compressCache caches nothing.
+type compressCache struct {
+	mu      sync.Mutex
+	max     int64                         // maximum total size of cached responses
+	size    int64                         // total size of cached responses
+	lru     list.List                     // *compressEntry, most recently used first
+	entries map[compressKey]*list.Element // key -> element in lru
+}
+
+// A compressKey identifies a compressed response.
+// The host and path are included because linkRewriter
+// changes the content after the ETag has been computed.
+type compressKey struct {
+	host string
+	path string
+	etag string
+	enc  string // content coding
+}
+
+// A compressEntry is a compressed response in the cache.
+type compressEntry struct {
+	key  compressKey
+	data []byte
+}
+
+// newCompressCache returns a new cache holding up to max bytes,
+// or nil if max is not positive.
+func newCompressCache(max int64) *compressCache {
+	if max <= 0 {
+		return nil
+	}
+	return &compressCache{max: max, entries: make(map[compressKey]*list.Element)}
+}
+
+// maxEntry returns the size of the largest response the cache will hold,
+// or 0 if c is nil.
+func (c *compressCache) maxEntry() int {
+	if c == nil {
+		return 0
+	}
+	return int(c.max / 8)
+}
+
+// get returns the cached data for key, if any.
+func (c *compressCache) get(key compressKey) ([]byte, bool) {
+	if c == nil || key.etag == "" {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(e)
+	return e.Value.(*compressEntry).data, true
+}
+
+// add adds data to the cache as the compressed response for key,
+// evicting the least recently used responses as needed to make room.
+func (c *compressCache) add(key compressKey, data []byte) {
+	if c == nil || key.etag == "" || len(data) > c.maxEntry() {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; ok {
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&compressEntry{key, data})
+	c.size += int64(len(data))
+	for c.size > c.max {
+		e := c.lru.Back()
+		ce := e.Value.(*compressEntry)
+		c.lru.Remove(e)
+		delete(c.entries, ce.key)
+		c.size -= int64(len(ce.data))
+	}
+}
@@ -0,0 +1,224 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+func TestAcceptEncoding(t *testing.T) {
+	for _, tt := range []struct {
+		accept string
+		want   string
+	}{
+		{"", ""},
+		{"identity", ""},
+		{"gzip", "gzip"},
+		{"gzip, deflate, br", "br"},
+		{"br;q=0.5, gzip", "gzip"},
+		{"br;q=0, gzip;q=0", ""},
+		{"GZIP", "gzip"},
+		{"*", "br"},
+		{"*;q=0.5, br;q=0", "gzip"},
+		{"gzip;level=1;q=0.8, br;q=0.9", "br"},
+		{"br;q=bad, gzip", "gzip"},
+	} {
+		if got := acceptEncoding(tt.accept); got != tt.want {
+			t.Errorf("acceptEncoding(%q) = %q, want %q", tt.accept, got, tt.want)
+		}
+	}
+}
+
+// decode returns the decoded body of the response w.
+func decode(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var r io.Reader = w.Body
+	switch enc := w.Header().Get("Content-Encoding"); enc {
+	case "":
+	case "gzip":
+		z, err := gzip.NewReader(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = z
+	case "br":
+		r = brotli.NewReader(r)
+	default:
+		t.Fatalf("unexpected Content-Encoding %q", enc)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestCompress(t *testing.T) {
+	page := "<!DOCTYPE html><p>" + strings.Repeat("Hello, world. ", 200) + "</p>\n"
+	body := page
+	mux := http.NewServeMux()
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Etag", `"page1"`)
+		io.WriteString(w, body)
+	})
+	mux.HandleFunc("/sniff", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, page)
+	})
+	mux.HandleFunc("/small", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/css")
+		io.WriteString(w, "body { color: red }")
+	})
+	mux.HandleFunc("/image.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		io.WriteString(w, page)
+	})
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, strings.Repeat("not found\n", 200), http.StatusNotFound)
+	})
+	mux.HandleFunc("/stream", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		io.WriteString(w, "first\n")
+		w.(http.Flusher).Flush()
+		io.WriteString(w, "second\n")
+	})
+	h := compressHandler(mux, newCompressCache(1<<20))
+
+	get := func(path, accept string) *httptest.ResponseRecorder {
+		t.Helper()
+		r := httptest.NewRequest("GET", path, nil)
+		if accept != "" {
+			r.Header.Set("Accept-Encoding", accept)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	for _, tt := range []struct {
+		path   string
+		accept string
+		code   int
+		enc    string
+		vary   bool
+		body   string
+	}{
+		{"/page", "", 200, "", true, page},
+		{"/page", "gzip", 200, "gzip", true, page},
+		{"/page", "br, gzip", 200, "br", true, page},
+		{"/sniff", "gzip", 200, "gzip", true, page},
+		{"/small", "gzip", 200, "", true, "body { color: red }"},
+		{"/image.png", "gzip", 200, "", false, page},
+		{"/missing", "br", 404, "br", true, strings.Repeat("not found\n", 200) + "\n"},
+		{"/stream", "gzip", 200, "gzip", true, "first\nsecond\n"},
+	} {
+		w := get(tt.path, tt.accept)
+		if w.Code != tt.code {
+			t.Errorf("GET %s (Accept-Encoding: %s): status %d, want %d", tt.path, tt.accept, w.Code, tt.code)
+		}
+		if enc := w.Header().Get("Content-Encoding"); enc != tt.enc {
+			t.Errorf("GET %s (Accept-Encoding: %s): Content-Encoding %q, want %q", tt.path, tt.accept, enc, tt.enc)
+		}
+		if vary := w.Header().Get("Vary") == "Accept-Encoding"; vary != tt.vary {
+			t.Errorf("GET %s (Accept-Encoding: %s): Vary %q, want Accept-Encoding %v", tt.path, tt.accept, w.Header().Get("Vary"), tt.vary)
+		}
+		if cl := w.Header().Get("Content-Length"); cl != "" && cl != strconv.Itoa(w.Body.Len()) {
+			t.Errorf("GET %s (Accept-Encoding: %s): Content-Length %s, body has %d bytes", tt.path, tt.accept, cl, w.Body.Len())
+		}
+		if got := decode(t, w); got != tt.body {
+			t.Errorf("GET %s (Accept-Encoding: %s): body = %q, want %q", tt.path, tt.accept, got, tt.body)
+		}
+	}
+
+	// A compressed response has a weak ETag.
+	if etag := get("/page", "gzip").Header().Get("Etag"); etag != `W/"page1"` {
+		t.Errorf("compressed ETag = %s, want W/\"page1\"", etag)
+	}
+	if etag := get("/page", "").Header().Get("Etag"); etag != `"page1"` {
+		t.Errorf("uncompressed ETag = %s, want \"page1\"", etag)
+	}
+
+	// The streamed response was flushed.
+	if w := get("/stream", "gzip"); !w.Flushed {
+		t.Errorf("GET /stream: not flushed")
+	}
+
+	// Compressed responses are cached by ETag:
+	// a changed body with the same ETag gets the cached response.
+	body = strings.Replace(page, "Hello", "Howdy", -1)
+	if got := decode(t, get("/page", "br")); got != page {
+		t.Errorf("GET /page with same ETag: got uncached response")
+	}
+	if got := decode(t, get("/page", "")); got != body {
+		t.Errorf("GET /page uncompressed: got stale response")
+	}
+	// The cache holds each content coding separately.
+	if w := get("/page", "br"); w.Header().Get("Content-Encoding") != "br" || decode(t, w) != page {
+		t.Errorf("GET /page with brotli: Content-Encoding %q, got uncached or wrong response", w.Header().Get("Content-Encoding"))
+	}
+}
+
+func TestCompressStreaming(t *testing.T) {
+	// A long response is compressed as it is written,
+	// once its first minCompress bytes have been seen,
+	// even if the handler never flushes.
+	w := httptest.NewRecorder()
+	body := strings.Repeat("0123456789", 2*minCompress/10)
+	var started bool
+	h := http.HandlerFunc(func(cw http.ResponseWriter, r *http.Request) {
+		cw.Header().Set("Content-Type", "text/plain")
+		cw.Header().Set("Etag", `"long"`)
+		for i := 0; i < len(body); i += 10 {
+			io.WriteString(cw, body[i:i+10])
+		}
+		started = w.Header().Get("Content-Encoding") == "gzip"
+	})
+	cache := newCompressCache(1 << 20)
+	r := httptest.NewRequest("GET", "/long", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	compressHandler(h, cache).ServeHTTP(w, r)
+	if !started {
+		t.Errorf("compressed response not started before handler returned")
+	}
+
+	// The streamed response filled the cache.
+	z, ok := cache.get(compressKey{host: r.Host, path: "/long", etag: `"long"`, enc: "gzip"})
+	if !ok || !bytes.Equal(z, w.Body.Bytes()) {
+		t.Errorf("cache entry = %d bytes, %v, want the %d bytes sent", len(z), ok, w.Body.Len())
+	}
+	if got := decode(t, w); got != body {
+		t.Errorf("body = %q, want %q", got, body)
+	}
+}
+
+func TestCompressLinkRewriter(t *testing.T) {
+	// linkRewriter buffers and rewrites HTML, so it must see
+	// the uncompressed page: compressHandler goes outside it.
+	page := `<a href="/doc/">docs</a>` + strings.Repeat("<p>Hello.</p>\n", 200)
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, page)
+	})
+	h := compressHandler(hostPathHandler(inner), nil)
+	r := httptest.NewRequest("GET", "http://localhost:6060/go.dev/", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if enc := w.Header().Get("Content-Encoding"); enc != "gzip" {
+		t.Fatalf("Content-Encoding %q, want gzip", enc)
+	}
+	if got := decode(t, w); !strings.HasPrefix(got, `<a href="/go.dev/doc/">docs</a>`) {
+		t.Errorf("body not rewritten:\n%.100s", got)
+	}
+}
@@ -0,0 +1,526 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"io/fs"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// exportSeeds lists the URL paths that an export starts from,
+// in addition to every page and file found in the content tree.
+var exportSeeds = []string{
+	"/",
+	"/pkg/",
+	"/cmd/",
+	"/blog/feed.atom",
+	"/blog/.json",
+	"/doc/devel/release",
+	"/dl/?mode=json",
+}
+
+// dynamicPaths lists URL prefixes (including query, if any)
+// that can only be served by a running server.
+var dynamicPaths = []struct {
+	prefix string
+	reason string
+}{
+	{"/_/", "playground backend"},
+	{"/play/p/", "playground snippet"},
+	{"/s/", "short link"},
+	{"/dl/?mode=json", "download list JSON"},
+	{"/dl/mod/", "toolchain module proxy"},
+	{"/issue/", "issue tracker redirect"},
+	{"/issues/", "issue tracker redirect"},
+	{"/cl/", "code review redirect"},
+	{"/change/", "code review redirect"},
+}
+
+// An exporter renders the pages served by a handler
+// into a directory tree of static files.
+type exporter struct {
+	h    http.Handler
+	dir  string // output directory
+	host string // host name of the exported site, like "go.dev"
+
+	queue     []string          // URL paths waiting to be fetched
+	seen      map[string]bool   // URL paths already queued
+	files     map[string]string // URL path -> exported file name (slash-separated, relative to dir)
+	urls      map[string]string // exported file name -> URL path
+	html      map[string]bool   // exported file name -> whether it is HTML
+	css       map[string]bool   // exported file name -> whether it is a CSS style sheet
+	dirs      map[string]bool   // directories in the output (slash-separated, relative to dir)
+	redirects map[string]string // exported file name -> redirect target URL path
+	skipped   []exportSkip      // URLs that could not be exported
+}
+
+// An exportSkip records a URL that could not be rendered statically.
+type exportSkip struct {
+	url    string
+	reason string
+}
+
+// export renders the site served by h for host into dir,
+// starting at the given URL paths and following all links to pages on the same host.
+// It returns the list of URLs that could not be exported.
+func export(h http.Handler, dir, host string, start []string) ([]exportSkip, error) {
+	x := &exporter{
+		h:         h,
+		dir:       dir,
+		host:      host,
+		seen:      make(map[string]bool),
+		files:     make(map[string]string),
+		urls:      make(map[string]string),
+		html:      make(map[string]bool),
+		css:       make(map[string]bool),
+		dirs:      make(map[string]bool),
+		redirects: make(map[string]string),
+	}
+	for _, u := range start {
+		x.enqueue(u)
+	}
+	for len(x.queue) > 0 {
+		u := x.queue[0]
+		x.queue = x.queue[1:]
+		if err := x.fetch(u); err != nil {
+			return nil, err
+		}
+	}
+	if err := x.rewrite(); err != nil {
+		return nil, err
+	}
+	sort.Slice(x.skipped, func(i, j int) bool {
+		return x.skipped[i].url < x.skipped[j].url
+	})
+	return x.skipped, nil
+}
+
+// enqueue adds the URL path u (possibly with a query) to the fetch queue,
+// unless it has been seen before or cannot be exported.
+func (x *exporter) enqueue(u string) {
+	if x.seen[u] {
+		return
+	}
+	x.seen[u] = true
+	for _, d := range dynamicPaths {
+		if strings.HasPrefix(u, d.prefix) {
+			x.skip(u, d.reason)
+			return
+		}
+	}
+	if strings.Contains(u, "?") && !strings.HasSuffix(u, rawTextQuery) {
+		x.skip(u, "page depends on query string")
+		return
+	}
+	x.queue = append(x.queue, u)
+}
+
+func (x *exporter) skip(u, reason string) {
+	x.skipped = append(x.skipped, exportSkip{u, reason})
+}
+
+// fetch serves the URL path u and writes the result to the output directory.
+func (x *exporter) fetch(u string) error {
+	target := "https://" + x.host + u
+	if isDocPath(u) && !strings.Contains(u, "?") {
+		// Serve docs directly instead of redirecting to pkg.go.dev.
+		target += "?m=old"
+	}
+	rec := httptest.NewRecorder()
+	x.h.ServeHTTP(rec, httptest.NewRequest("GET", target, nil))
+
+	switch {
+	case rec.Code == http.StatusOK:
+		ct := rec.Header().Get("Content-Type")
+		if ct == "" {
+			ct = http.DetectContentType(rec.Body.Bytes())
+		}
+		isHTML := strings.HasPrefix(ct, "text/html")
+		isCSS := strings.HasPrefix(ct, "text/css")
+		name, err := x.place(exportName(u, isHTML))
+		if errors.Is(err, errCollision) {
+			x.skip(u, err.Error())
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		base := &url.URL{Path: u}
+		if isHTML {
+			for _, m := range linkAttrRE.FindAllStringSubmatch(rec.Body.String(), -1) {
+				for _, link := range attrLinks(m[1], html.UnescapeString(m[2])) {
+					if v, ok := x.resolve(base, link); ok {
+						x.enqueue(v.String())
+					}
+				}
+			}
+		}
+		if isCSS {
+			for _, m := range cssURLRE.FindAllStringSubmatch(rec.Body.String(), -1) {
+				if v, ok := x.resolve(base, m[2]); ok {
+					x.enqueue(v.String())
+				}
+			}
+		}
+		x.files[u] = name
+		x.urls[name] = u
+		x.html[name] = isHTML
+		x.css[name] = isCSS
+		return x.write(name, rec.Body.Bytes())
+
+	case rec.Code/100 == 3:
+		loc, err := url.Parse(rec.Header().Get("Location"))
+		if err != nil {
+			x.skip(u, fmt.Sprintf("bad redirect: %v", err))
+			return nil
+		}
+		v, ok := x.resolve(&url.URL{Path: u}, loc.String())
+		if !ok {
+			x.skip(u, "redirects off-site to "+loc.String())
+			return nil
+		}
+		x.enqueue(v.String())
+		name, err := x.place(exportName(u, true))
+		if errors.Is(err, errCollision) {
+			x.skip(u, err.Error())
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		x.files[u] = name
+		if _, ok := x.html[name]; ok {
+			// Don't replace a real page (for example /doc/x.html redirecting to /doc/x).
+			return nil
+		}
+		x.urls[name] = u
+		x.redirects[name] = v.String()
+		return nil
+
+	default:
+		x.skip(u, fmt.Sprintf("HTTP status %d", rec.Code))
+		return nil
+	}
+}
+
+// isDocPath reports whether u is served by pkgdoc.
+func isDocPath(u string) bool {
+	return strings.HasPrefix(u, "/pkg/") || strings.HasPrefix(u, "/cmd/")
+}
+
+// linkAttrRE matches the HTML attributes that refer to other URLs.
+var linkAttrRE = regexp.MustCompile(`\b(href|src|srcset)="([^"]*)"`)
+
+// cssURLRE matches the url(...) references in CSS, like url('/fonts/Go.woff').
+// The submatches are the opening quote, if any, and the URL.
+var cssURLRE = regexp.MustCompile(`\burl\(\s*(['"]?)([^'")\s]+)['"]?\s*\)`)
+
+// attrLinks returns the links in the unescaped value of the HTML attribute attr.
+// A srcset attribute lists image URLs, each followed by a size, like
+// "fig.png.w640.0123456789.png 640w, fig.png 1280w"; the others hold a single URL.
+func attrLinks(attr, value string) []string {
+	if attr != "srcset" {
+		return []string{value}
+	}
+	var links []string
+	for _, c := range strings.Split(value, ",") {
+		if f := strings.Fields(c); len(f) > 0 {
+			links = append(links, f[0])
+		}
+	}
+	return links
+}
+
+// resolve resolves link relative to the page base and reports
+// whether the result refers to a page on the exported site.
+// The result has no fragment, and its query omits parameters that
+// only affect the presentation of a page (like source highlighting)
+// or the choice of documentation server (m=old).
+func (x *exporter) resolve(base *url.URL, link string) (*url.URL, bool) {
+	if link == "" || strings.HasPrefix(link, "#") {
+		return nil, false
+	}
+	v, err := url.Parse(link)
+	if err != nil {
+		return nil, false
+	}
+	if v.Scheme != "" && v.Scheme != "https" && v.Scheme != "http" {
+		return nil, false
+	}
+	if v.Host != "" && v.Host != x.host {
+		return nil, false
+	}
+	v = base.ResolveReference(v)
+	v.Scheme = ""
+	v.Host = ""
+	v.Fragment = ""
+	v.RawFragment = ""
+	if v.RawQuery != "" {
+		q := v.Query()
+		q.Del("s")
+		q.Del("h")
+		if isDocPath(v.Path) && q.Get("m") == "old" {
+			q.Del("m")
+		}
+		v.RawQuery = q.Encode()
+	}
+	return v, true
+}
+
+// rawTextQuery is the query that selects the plain text form of a text file.
+// See the web package documentation.
+const rawTextQuery = "?m=text"
+
+// exportName returns the file name for the URL path u.
+// Paths ending in a slash are stored as index.html files,
+// and HTML pages get an .html extension if they do not already have one.
+// The plain text form of a text file (with query ?m=text) is stored
+// under the file's own name, next to its .html rendering.
+func exportName(u string, isHTML bool) string {
+	name := strings.TrimPrefix(u, "/")
+	if raw, ok := strings.CutSuffix(name, rawTextQuery); ok {
+		return raw
+	}
+	if name == "" || strings.HasSuffix(name, "/") {
+		return name + "index.html"
+	}
+	if isHTML && path.Ext(name) != ".html" {
+		return name + ".html"
+	}
+	return name
+}
+
+// errCollision reports that two exported URLs need the same output file.
+var errCollision = errors.New("output file collides with")
+
+// place returns the output file name to use for the exported file name.
+// A page cannot be stored as a file named like a directory that already
+// holds other exported files (or the reverse, for example /doc/x as a
+// plain text file next to /doc/x/y.html), so in that case the page
+// is stored as name/index.html instead: place moves an earlier file
+// whose name is needed as a directory and names a later one accordingly.
+// If that file name is in use too, place returns an error wrapping errCollision.
+func (x *exporter) place(name string) (string, error) {
+	for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+		if _, ok := x.urls[dir]; ok {
+			if err := x.move(dir, dir+"/index.html"); err != nil {
+				return "", err
+			}
+		}
+	}
+	if x.dirs[name] {
+		name += "/index.html"
+		if u, ok := x.urls[name]; ok {
+			return "", fmt.Errorf("%w %s", errCollision, u)
+		}
+	}
+	for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+		x.dirs[dir] = true
+	}
+	return name, nil
+}
+
+// move renames the exported file old to new, which must be old/index.html.
+func (x *exporter) move(old, new string) error {
+	if _, ok := x.html[old]; ok {
+		// The file has been written already; make room for the directory.
+		file := filepath.Join(x.dir, filepath.FromSlash(old))
+		tmp := file + ".export"
+		if err := os.Rename(file, tmp); err != nil {
+			return err
+		}
+		if err := os.Mkdir(file, 0o777); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp, filepath.Join(x.dir, filepath.FromSlash(new))); err != nil {
+			return err
+		}
+		x.html[new], x.css[new] = x.html[old], x.css[old]
+		delete(x.html, old)
+		delete(x.css, old)
+	}
+	if target, ok := x.redirects[old]; ok {
+		x.redirects[new] = target
+		delete(x.redirects, old)
+	}
+	x.urls[new] = x.urls[old]
+	delete(x.urls, old)
+	for u, name := range x.files {
+		if name == old {
+			x.files[u] = new
+		}
+	}
+	x.dirs[old] = true
+	return nil
+}
+
+func (x *exporter) write(name string, data []byte) error {
+	file := filepath.Join(x.dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(file), 0o777); err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0o666)
+}
+
+// rewrite rewrites the links in every exported HTML file
+// and the url(...) references in every exported style sheet
+// to refer to the exported files, using relative paths
+// so that the tree can be served from any location.
+// It also writes a small HTML page for every redirect.
+func (x *exporter) rewrite() error {
+	for name, target := range x.redirects {
+		if _, ok := x.html[name]; ok {
+			// Redirect was later replaced by a real page.
+			continue
+		}
+		if t, ok := x.files[target]; ok {
+			target = relPath(name, t)
+		}
+		target = html.EscapeString(target)
+		stub := fmt.Sprintf("<!DOCTYPE html>\n<meta http-equiv=\"refresh\" content=\"0; url=%s\">\n<a href=\"%s\">Redirecting...</a>\n", target, target)
+		if err := x.write(name, []byte(stub)); err != nil {
+			return err
+		}
+	}
+	for name, isHTML := range x.html {
+		if !isHTML && !x.css[name] {
+			continue
+		}
+		file := filepath.Join(x.dir, filepath.FromSlash(name))
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		base := &url.URL{Path: x.urls[name]}
+		relink := func(link string) (string, bool) {
+			v, ok := x.resolve(base, link)
+			if !ok {
+				return "", false
+			}
+			target, ok := x.files[v.String()]
+			if !ok {
+				return "", false
+			}
+			rel := relPath(name, target)
+			if frag := fragment(link); frag != "" {
+				rel += "#" + frag
+			}
+			return rel, true
+		}
+		if !isHTML {
+			out := cssURLRE.ReplaceAllStringFunc(string(data), func(ref string) string {
+				m := cssURLRE.FindStringSubmatch(ref)
+				rel, ok := relink(m[2])
+				if !ok {
+					return ref
+				}
+				return "url(" + m[1] + rel + m[1] + ")"
+			})
+			if err := os.WriteFile(file, []byte(out), 0o666); err != nil {
+				return err
+			}
+			continue
+		}
+		out := linkAttrRE.ReplaceAllStringFunc(string(data), func(attr string) string {
+			m := linkAttrRE.FindStringSubmatch(attr)
+			value := html.UnescapeString(m[2])
+			if m[1] == "srcset" {
+				cands := strings.Split(value, ",")
+				for i, c := range cands {
+					f := strings.Fields(c)
+					if len(f) == 0 {
+						continue
+					}
+					if rel, ok := relink(f[0]); ok {
+						f[0] = rel
+					}
+					cands[i] = strings.Join(f, " ")
+				}
+				return m[1] + `="` + html.EscapeString(strings.Join(cands, ", ")) + `"`
+			}
+			rel, ok := relink(value)
+			if !ok {
+				return attr
+			}
+			return m[1] + `="` + rel + `"`
+		})
+		if err := os.WriteFile(file, []byte(out), 0o666); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fragment returns the #fragment of link, without the #.
+func fragment(link string) string {
+	_, frag, _ := strings.Cut(link, "#")
+	return frag
+}
+
+// relPath returns the relative path from the file from to the file to.
+func relPath(from, to string) string {
+	rel, err := filepath.Rel(filepath.Dir(filepath.FromSlash(from)), filepath.FromSlash(to))
+	if err != nil {
+		return "/" + to
+	}
+	return filepath.ToSlash(rel)
+}
+
+// contentURLs returns the URL paths for the pages and files in the content tree fsys.
+func contentURLs(fsys fs.FS) ([]string, error) {
+	var urls []string
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		switch path.Ext(name) {
+		case ".tmpl", ".yaml":
+			// Templates and template data are not served directly.
+			return nil
+		case ".md", ".html":
+			name = strings.TrimSuffix(name, path.Ext(name))
+			if name == "index" {
+				name = ""
+			} else if strings.HasSuffix(name, "/index") {
+				name = strings.TrimSuffix(name, "index")
+			}
+		}
+		urls = append(urls, "/"+name)
+		return nil
+	})
+	return urls, err
+}
+
+// runExport implements the -export flag,
+// writing the go.dev site served by h into dir.
+func runExport(h http.Handler, content fs.FS, dir string) {
+	urls, err := contentURLs(content)
+	if err != nil {
+		log.Fatalf("export: %v", err)
+	}
+	skipped, err := export(h, dir, "go.dev", append(exportSeeds, urls...))
+	if err != nil {
+		log.Fatalf("export: %v", err)
+	}
+	for _, s := range skipped {
+		fmt.Fprintf(os.Stderr, "%s\t%s\n", s.url, s.reason)
+	}
+	fmt.Fprintf(os.Stderr, "exported go.dev to %s; %d URLs could not be exported\n", dir, len(skipped))
+}
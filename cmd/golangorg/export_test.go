@@ -0,0 +1,132 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExport(t *testing.T) {
+	html := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			io.WriteString(w, body)
+		}
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/{$}", html(`<a href="/doc/">doc</a> <a href="https://go.dev/blog/post#x">post</a> <a href="/s/short">short</a> <a href="https://example.com/">ext</a> <a href="/old">old</a>`))
+	mux.Handle("/doc/{$}", html(`<a href="../">home</a> <a href="a.txt">a</a> <a href="a.txt?m=text">raw</a> <img src="/images/x.png" srcset="/images/x.png.w320.png 320w, /images/x.png 640w">`))
+	mux.Handle("/blog/post", html(`<a href="/search?q=x">search</a>`))
+	mux.HandleFunc("/doc/a.txt", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("m") == "text" {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			io.WriteString(w, "hello")
+			return
+		}
+		html(`<pre>hello</pre>`)(w, r)
+	})
+	mux.HandleFunc("/images/x.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("\x89PNG"))
+	})
+	mux.HandleFunc("/images/x.png.w320.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("\x89PNG small"))
+	})
+	mux.Handle("/old", http.RedirectHandler("/doc/", http.StatusMovedPermanently))
+
+	dir := t.TempDir()
+	skipped, err := export(mux, dir, "go.dev", []string{"/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		"index.html":            `<a href="doc/index.html">doc</a> <a href="blog/post.html#x">post</a> <a href="/s/short">short</a> <a href="https://example.com/">ext</a> <a href="old.html">old</a>`,
+		"doc/index.html":        `<a href="../index.html">home</a> <a href="a.txt.html">a</a> <a href="a.txt">raw</a> <img src="../images/x.png" srcset="../images/x.png.w320.png 320w, ../images/x.png 640w">`,
+		"doc/a.txt.html":        `<pre>hello</pre>`,
+		"doc/a.txt":             `hello`,
+		"blog/post.html":        `<a href="/search?q=x">search</a>`,
+		"images/x.png":          "\x89PNG",
+		"images/x.png.w320.png": "\x89PNG small",
+		"old.html":              `url=doc/index.html`,
+	}
+	for name, want := range files {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if !strings.Contains(string(data), want) {
+			t.Errorf("%s:\nhave %s\nwant %s", name, data, want)
+		}
+	}
+
+	want := []exportSkip{
+		{"/s/short", "short link"},
+		{"/search?q=x", "page depends on query string"},
+	}
+	if len(skipped) != len(want) {
+		t.Fatalf("skipped = %v, want %v", skipped, want)
+	}
+	for i := range want {
+		if skipped[i] != want[i] {
+			t.Errorf("skipped[%d] = %v, want %v", i, skipped[i], want[i])
+		}
+	}
+}
+
+func TestExportStylesAndCollisions(t *testing.T) {
+	serve := func(ct, body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", ct)
+			io.WriteString(w, body)
+		}
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/{$}", serve("text/html", `<link href="/css/s.css"> <a href="/notes">notes</a> <a href="/notes/a">a</a> <a href="/doc/b">b</a> <a href="/doc">doc</a>`))
+	mux.Handle("/css/s.css", serve("text/css", `@font-face { src: url('/fonts/Go.woff'); } a { background: url(../images/x.svg) } b { background: url("data:image/png;base64,AAAA") }`))
+	mux.Handle("/fonts/Go.woff", serve("font/woff", "woff"))
+	mux.Handle("/images/x.svg", serve("image/svg+xml", "<svg/>"))
+	mux.Handle("/notes", serve("text/plain", "notes"))
+	mux.Handle("/notes/a", serve("text/html", `<a href="../notes">notes</a>`))
+	mux.Handle("/doc/b", serve("text/html", `<a href="/doc">doc</a>`))
+	mux.Handle("/doc", serve("text/plain", "doc"))
+
+	dir := t.TempDir()
+	skipped, err := export(mux, dir, "go.dev", []string{"/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(skipped) != 0 {
+		t.Errorf("skipped = %v, want none", skipped)
+	}
+
+	files := map[string]string{
+		"index.html":       `<link href="css/s.css"> <a href="notes/index.html">notes</a> <a href="notes/a.html">a</a> <a href="doc/b.html">b</a> <a href="doc/index.html">doc</a>`,
+		"css/s.css":        `@font-face { src: url('../fonts/Go.woff'); } a { background: url(../images/x.svg) } b { background: url("data:image/png;base64,AAAA") }`,
+		"fonts/Go.woff":    "woff",
+		"images/x.svg":     "<svg/>",
+		"notes/index.html": "notes",
+		"notes/a.html":     `<a href="index.html">notes</a>`,
+		"doc/index.html":   "doc",
+		"doc/b.html":       `<a href="index.html">doc</a>`,
+	}
+	for name, want := range files {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if string(data) != want {
+			t.Errorf("%s:\nhave %s\nwant %s", name, data, want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/website"
+	"golang.org/x/website/internal/web"
+)
+
+// runLint checks the pages in contentDir (or the embedded content, if contentDir is empty)
+// and prints the problems found to standard output in the given format.
+// It exits with status 1 if there are any problems.
+func runLint(contentDir string, goroot fs.FS, format string) {
+	if format != "text" && format != "json" {
+		log.Fatalf("-lint: unknown format %q; want text or json", format)
+	}
+	content := website.Content()
+	prefix := "_content"
+	if contentDir != "" {
+		content = os.DirFS(contentDir)
+		prefix = contentDir
+	}
+	site := web.NewSite(content)
+	site.Funcs(siteFuncs("", goroot))
+	setLanguages(site)
+	diags := site.Lint(".")
+	for i := range diags {
+		diags[i].File = filepath.Join(prefix, filepath.FromSlash(diags[i].File))
+	}
+	if err := writeLint(os.Stdout, diags, format); err != nil {
+		log.Fatal(err)
+	}
+	if len(diags) > 0 {
+		os.Exit(1)
+	}
+}
+
+// writeLint writes diags to w in the given format:
+// "text" writes one file:line: message per line,
+// and "json" writes a JSON array of web.Diagnostic objects.
+func writeLint(w io.Writer, diags []web.Diagnostic, format string) error {
+	if format == "json" {
+		if diags == nil {
+			diags = []web.Diagnostic{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "\t")
+		return enc.Encode(diags)
+	}
+	for _, d := range diags {
+		if _, err := fmt.Fprintln(w, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/website/internal/gitfs"
+	"golang.org/x/website/internal/pkgdoc"
+)
+
+// docModules returns the modules named by -moddir, -modproxy, and -modgit,
+// whose package docs the sites serve along with GOROOT's.
+// It loads them on the first call, logging and skipping any that fail to load.
+var docModules = sync.OnceValue(func() []pkgdoc.Module {
+	var mods []pkgdoc.Module
+	for _, dir := range splitList(*modDirs) {
+		m, err := pkgdoc.DirModule(os.DirFS(dir), "")
+		if err != nil {
+			log.Printf("-moddir %s: %v", dir, err)
+			continue
+		}
+		mods = append(mods, m)
+	}
+	if *modProxy != "" {
+		list, err := pkgdoc.ProxyModules(*modProxy)
+		if err != nil {
+			log.Printf("-modproxy %s: %v", *modProxy, err)
+		}
+		mods = append(mods, list...)
+	}
+	for _, arg := range splitList(*modGit) {
+		m, err := gitModule(arg)
+		if err != nil {
+			log.Printf("-modgit %s: %v", arg, err)
+			continue
+		}
+		mods = append(mods, m)
+	}
+	return mods
+})
+
+// splitList returns the non-empty elements of the comma-separated list s.
+func splitList(s string) []string {
+	var list []string
+	for _, x := range strings.Split(s, ",") {
+		if x = strings.TrimSpace(x); x != "" {
+			list = append(list, x)
+		}
+	}
+	return list
+}
+
+// gitModule returns the module at ref in the Git repo,
+// named by arg as repo or repo@ref, with ref defaulting to "master".
+// The module version is the ref: unlike -tip and -wiki content,
+// the module is cloned once at startup and not updated.
+func gitModule(arg string) (pkgdoc.Module, error) {
+	repo, ref := arg, "master"
+	if i := strings.LastIndex(arg, "@"); i > strings.LastIndex(arg, "/") {
+		repo, ref = arg[:i], arg[i+1:]
+	}
+	repo = mirrorRepo(repo)
+	r, err := gitfs.NewRepo(repo)
+	if err != nil {
+		return pkgdoc.Module{}, err
+	}
+	if *gitCache != "" {
+		dir := filepath.Join(*gitCache, filepath.FromSlash(strings.TrimPrefix(repo, "https://")))
+		if err := r.SetCache(dir); err != nil {
+			log.Printf("-modgit %s: %v", repo, err)
+		}
+	}
+	_, fsys, err := r.Clone(ref)
+	if err != nil {
+		return pkgdoc.Module{}, err
+	}
+	return pkgdoc.DirModule(fsys, ref)
+}
@@ -0,0 +1,260 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// reloadPoll is how often watchDir checks a directory for changes.
+const reloadPoll = 500 * time.Millisecond
+
+// A reloader supports editing the _content directory during local development.
+// After each change to the directory (see changed), it checks the site's
+// pages and templates for errors, logging any it finds.
+// It also pushes an event to every open page, using server-sent events:
+// the page reloads itself, or shows the errors if there were any.
+type reloader struct {
+	dir   string         // directory to watch
+	check func() []error // checks content in dir
+
+	mu      sync.Mutex
+	clients map[chan string]bool // event channels for open pages
+}
+
+// newReloader returns a reloader for dir, calling check after each change.
+// It checks the content once in a separate goroutine;
+// the caller must arrange to call rl.changed after each change to dir,
+// typically using watchDir.
+func newReloader(dir string, check func() []error) *reloader {
+	rl := &reloader{
+		dir:     dir,
+		check:   check,
+		clients: make(map[chan string]bool),
+	}
+	go rl.checkContent()
+	return rl
+}
+
+// changed checks the content after a change to rl.dir
+// and tells the open pages to reload.
+func (rl *reloader) changed() {
+	rl.broadcast(rl.checkContent())
+}
+
+// watchDir polls dir for changes forever, calling changed after each one.
+func watchDir(dir string, changed func()) {
+	last := scanDir(dir)
+	for range time.Tick(reloadPoll) {
+		sum := scanDir(dir)
+		if sum == last {
+			continue
+		}
+		last = sum
+		changed()
+	}
+}
+
+// scanDir returns a fingerprint of the names, sizes, and modification times
+// of the files in dir.
+func scanDir(dir string) string {
+	h := sha256.New()
+	filepath.WalkDir(dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		fmt.Fprintf(h, "%s %d %d %v\n", name, info.Size(), info.ModTime().UnixNano(), info.Mode())
+		return nil
+	})
+	return string(h.Sum(nil))
+}
+
+// checkContent checks the content and logs any errors.
+// It returns the errors as text, one per line,
+// or the empty string if there are none.
+func (rl *reloader) checkContent() string {
+	start := time.Now()
+	var b strings.Builder
+	errs := rl.check()
+	for _, err := range errs {
+		log.Printf("reload: %v", err)
+		fmt.Fprintf(&b, "%v\n", err)
+	}
+	log.Printf("reload: checked %s in %v: %d errors", rl.dir, time.Since(start).Round(time.Millisecond), len(errs))
+	return b.String()
+}
+
+// broadcast sends msg to all the open pages.
+func (rl *reloader) broadcast(msg string) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	for c := range rl.clients {
+		select {
+		case c <- msg:
+		default:
+			// Page has not yet received the last event; drop this one.
+		}
+	}
+}
+
+// handler returns a handler that serves the reloader's event stream
+// at /_reload and its script at /_reload.js, adding the script
+// to every HTML page served by h.
+func (rl *reloader) handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/_reload":
+			rl.serveEvents(w, r)
+			return
+		case "/_reload.js":
+			w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Write([]byte(reloadScript))
+			return
+		}
+		rw := &reloadWriter{ResponseWriter: w}
+		h.ServeHTTP(rw, r)
+		rw.finish()
+	})
+}
+
+// serveEvents serves a stream of reload events to a page.
+// The data of each event is the text of the errors found by the check,
+// or empty if there were none.
+func (rl *reloader) serveEvents(w http.ResponseWriter, r *http.Request) {
+	f, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	c := make(chan string, 1)
+	rl.mu.Lock()
+	rl.clients[c] = true
+	rl.mu.Unlock()
+	defer func() {
+		rl.mu.Lock()
+		delete(rl.clients, c)
+		rl.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	fmt.Fprintf(w, ": watching %s\n\n", rl.dir)
+	f.Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case msg := <-c:
+			fmt.Fprintf(w, "event: reload\n")
+			for _, line := range strings.Split(strings.TrimSuffix(msg, "\n"), "\n") {
+				fmt.Fprintf(w, "data: %s\n", line)
+			}
+			fmt.Fprintf(w, "\n")
+			f.Flush()
+		}
+	}
+}
+
+// reloadScript is the script added to HTML pages
+// to handle the events sent by serveEvents.
+const reloadScript = `// Reload the page after golangorg -reload sees a content change.
+new EventSource("/_reload").addEventListener("reload", e => {
+  if (!e.data) {
+    location.reload();
+    return;
+  }
+  let pre = document.getElementById("golangorg-reload-errors");
+  if (!pre) {
+    pre = document.createElement("pre");
+    pre.id = "golangorg-reload-errors";
+    pre.style.cssText = "position:fixed;top:0;left:0;right:0;z-index:10000;margin:0;padding:1em;" +
+      "max-height:50vh;overflow:auto;background:#fee;color:#900;border-bottom:2px solid #900;white-space:pre-wrap";
+    document.body.appendChild(pre);
+  }
+  pre.textContent = e.data;
+});
+`
+
+// reloadTag is the HTML added to pages to load reloadScript.
+const reloadTag = `<script src="/_reload.js"></script>` + "\n"
+
+// A reloadWriter is an http.ResponseWriter that adds reloadTag
+// to the end of the body of an HTML response.
+// Other responses pass through unchanged.
+type reloadWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+	status      int
+	html        bool         // buffering HTML response
+	buf         bytes.Buffer // buffered HTML response
+}
+
+func (w *reloadWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	h := w.Header()
+	if strings.HasPrefix(h.Get("Content-Type"), "text/html") && h.Get("Content-Encoding") == "" {
+		w.html = true
+		w.status = code
+		h.Del("Content-Length")
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *reloadWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		if _, ok := w.Header()["Content-Type"]; !ok {
+			w.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.html {
+		return w.buf.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *reloadWriter) Flush() {
+	if w.html {
+		return
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// finish writes a buffered HTML response, with reloadTag added
+// just before the closing </body> tag, if there is one.
+func (w *reloadWriter) finish() {
+	if !w.html {
+		return
+	}
+	body := w.buf.Bytes()
+	i := bytes.LastIndex(body, []byte("</body>"))
+	if i < 0 {
+		i = len(body)
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.Write(body[:i])
+	w.ResponseWriter.Write([]byte(reloadTag))
+	w.ResponseWriter.Write(body[i:])
+}
@@ -0,0 +1,97 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReload(t *testing.T) {
+	rl := &reloader{dir: "content", clients: make(map[chan string]bool)}
+	h := rl.handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/page":
+			w.Write([]byte("<!DOCTYPE html><html><body>page</body></html>"))
+		case "/style.css":
+			w.Header().Set("Content-Type", "text/css")
+			w.Write([]byte("body {}"))
+		}
+	}))
+
+	for _, tt := range []struct {
+		path, body string
+	}{
+		{"/page", "<!DOCTYPE html><html><body>page" + reloadTag + "</body></html>"},
+		{"/style.css", "body {}"},
+		{"/_reload.js", reloadScript},
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		if w.Code != 200 || w.Body.String() != tt.body {
+			t.Errorf("GET %s: %d %q, want 200 %q", tt.path, w.Code, w.Body, tt.body)
+		}
+	}
+
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+	resp, err := http.Get(srv.URL + "/_reload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q, want text/event-stream", ct)
+	}
+	br := bufio.NewReader(resp.Body)
+	readEvent := func() string {
+		t.Helper()
+		var lines []string
+		for {
+			line, err := br.ReadString('\n')
+			if err != nil {
+				t.Fatal(err)
+			}
+			if line == "\n" {
+				return strings.Join(lines, "")
+			}
+			lines = append(lines, line)
+		}
+	}
+	if ev := readEvent(); ev != ": watching content\n" {
+		t.Fatalf("first event = %q, want comment", ev)
+	}
+	rl.broadcast("")
+	if ev, want := readEvent(), "event: reload\ndata: \n"; ev != want {
+		t.Errorf("event = %q, want %q", ev, want)
+	}
+	rl.broadcast("a.md: bad\nb.md: worse\n")
+	if ev, want := readEvent(), "event: reload\ndata: a.md: bad\ndata: b.md: worse\n"; ev != want {
+		t.Errorf("event = %q, want %q", ev, want)
+	}
+}
+
+func TestScanDir(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "page.md")
+	if err := os.WriteFile(file, []byte("old"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	old := scanDir(dir)
+	if scanDir(dir) != old {
+		t.Fatalf("scanDir changed without edits")
+	}
+	if err := os.WriteFile(file, []byte("new text"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if scanDir(dir) == old {
+		t.Errorf("scanDir unchanged after edit")
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"io/fs"
+	"log"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/website/internal/pkgdoc"
+	"golang.org/x/website/internal/search"
+	"golang.org/x/website/internal/web"
+)
+
+// A siteSearch maintains the search index for a site,
+// along with the index of the symbols in its GOROOT.
+type siteSearch struct {
+	index   *search.Index
+	symbols *pkgdoc.SymbolIndex
+	content fs.FS // site content, for listing the trees to index
+	goroot  fs.FS // GOROOT, for package docs
+
+	mu         sync.Mutex   // serializes updates
+	gorootDocs []search.Doc // documents indexed by the last updateGoroot
+}
+
+// extraSearchPages lists pages served from GOROOT outside the content trees.
+var extraSearchPages = []string{
+	"ref/spec.html",
+	"ref/mem.html",
+}
+
+// gorootPrefixes returns the URL prefixes of the documents indexed from GOROOT:
+// the package documentation and the extraSearchPages.
+func gorootPrefixes() []string {
+	prefixes := []string{"/pkg/", "/cmd/"}
+	for _, name := range extraSearchPages {
+		prefixes = append(prefixes, "/"+strings.TrimSuffix(name, ".html"))
+	}
+	return prefixes
+}
+
+// newSiteSearch returns a new, empty siteSearch for the site
+// serving the given content and goroot file systems.
+// Call update(".") and updateGoroot to build the index.
+func newSiteSearch(content, goroot fs.FS) *siteSearch {
+	return &siteSearch{
+		index:   search.NewIndex(),
+		symbols: pkgdoc.NewSymbolIndex(),
+		content: content,
+		goroot:  goroot,
+	}
+}
+
+// update reindexes the pages in the content tree rooted at dir,
+// or, if dir is ".", all the content trees.
+// The documents from GOROOT are left as they are; see updateGoroot.
+func (s *siteSearch) update(dir string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	start := time.Now()
+	// Use a fresh site to load the pages, so that no cached pages
+	// from before a file system change are used.
+	site := web.NewSite(siteFS(s.content, s.goroot))
+	var roots []string
+	prefix := "/" + dir + "/"
+	if dir == "." {
+		list, err := fs.ReadDir(s.content, ".")
+		if err != nil {
+			log.Printf("search: %v", err)
+			return
+		}
+		for _, d := range list {
+			roots = append(roots, d.Name())
+		}
+		prefix = ""
+	} else {
+		roots = []string{dir}
+	}
+
+	var docs []search.Doc
+	for _, root := range roots {
+		if path.Ext(root) == ".tmpl" || path.Ext(root) == ".yaml" {
+			continue
+		}
+		d, err := search.SiteDocs(site, root)
+		if err != nil {
+			log.Printf("search: %v", err)
+		}
+		docs = append(docs, d...)
+	}
+	n := len(docs)
+	if dir == "." {
+		// Replacing every document replaces the GOROOT ones too; keep them.
+		docs = append(docs, s.gorootDocs...)
+	}
+	s.index.Update(prefix, docs)
+	log.Printf("search: indexed %d documents for /%s in %v", n, dir, time.Since(start).Round(time.Millisecond))
+}
+
+// updateGoroot reindexes the documents from GOROOT:
+// the package documentation, the extraSearchPages, and the symbol index.
+func (s *siteSearch) updateGoroot() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	start := time.Now()
+	site := web.NewSite(siteFS(s.content, s.goroot))
+	var docs []search.Doc
+	for _, name := range extraSearchPages {
+		d, err := search.SiteDocs(site, name)
+		if err != nil {
+			log.Printf("search: %v", err)
+		}
+		docs = append(docs, d...)
+	}
+	docs = append(docs, pkgdoc.IndexPackages(s.goroot, s.symbols)...)
+	for _, prefix := range gorootPrefixes() {
+		s.index.Update(prefix, docs)
+	}
+	s.gorootDocs = docs
+	log.Printf("search: indexed %d documents and %d symbols from GOROOT in %v", len(docs), s.symbols.Len(), time.Since(start).Round(time.Millisecond))
+}
+
+// updateOnSet arranges for the tree rooted at dir to be reindexed
+// after each change to the file system afs.
+// The reindex runs in its own goroutine, so that Set does not wait for it.
+func (s *siteSearch) updateOnSet(afs *atomicFS, dir string) {
+	afs.OnSet(func() { go s.update(dir) })
+}
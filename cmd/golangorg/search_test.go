@@ -0,0 +1,58 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestSiteSearchUpdate(t *testing.T) {
+	content := fstest.MapFS{
+		"doc/a.md": {Data: []byte("---\ntitle: Alpha\n---\n\nThe alpha page.\n")},
+	}
+	goroot := fstest.MapFS{
+		"src/p/p.go": {Data: []byte("// Package p is about beta.\npackage p\n")},
+	}
+	s := newSiteSearch(content, goroot)
+	find := func(q string) []string {
+		var urls []string
+		for _, r := range s.index.Search(q, 0) {
+			urls = append(urls, r.URL)
+		}
+		return urls
+	}
+	check := func(q string, want ...string) {
+		t.Helper()
+		have := find(q)
+		if len(have) != len(want) {
+			t.Fatalf("search %q = %v, want %v", q, have, want)
+		}
+		for i := range want {
+			if have[i] != want[i] {
+				t.Fatalf("search %q = %v, want %v", q, have, want)
+			}
+		}
+	}
+
+	s.update(".")
+	s.updateGoroot()
+	check("alpha", "/doc/a")
+	check("beta", "/pkg/p/")
+
+	// Reindexing the content keeps the package docs.
+	content["doc/a.md"] = &fstest.MapFile{Data: []byte("---\ntitle: Gamma\n---\n\nThe gamma page.\n")}
+	s.update(".")
+	check("alpha")
+	check("gamma", "/doc/a")
+	check("beta", "/pkg/p/")
+
+	// Reindexing GOROOT keeps the content.
+	goroot["src/p/p.go"] = &fstest.MapFile{Data: []byte("// Package p is about delta.\npackage p\n")}
+	s.updateGoroot()
+	check("beta")
+	check("delta", "/pkg/p/")
+	check("gamma", "/doc/a")
+}
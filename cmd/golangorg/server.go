@@ -25,6 +25,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"runtime/debug"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -44,7 +45,9 @@ import (
 	"golang.org/x/website/internal/pkgdoc"
 	"golang.org/x/website/internal/play"
 	"golang.org/x/website/internal/redirect"
+	"golang.org/x/website/internal/search"
 	"golang.org/x/website/internal/short"
+	"golang.org/x/website/internal/sitemap"
 	"golang.org/x/website/internal/talks"
 	"golang.org/x/website/internal/tour"
 	"golang.org/x/website/internal/web"
@@ -57,14 +60,30 @@ var (
 	verbose    = flag.Bool("v", false, "verbose mode")
 	goroot     = flag.String("goroot", runtime.GOROOT(), "Go root directory")
 	contentDir = flag.String("content", "", "path to _content directory")
+	reloadFlag = flag.Bool("reload", false, "watch the -content directory, check pages and templates after each change, and reload open pages")
+	exportDir  = flag.String("export", "", "write the go.dev site as static files to `dir` and exit")
+	lintFormat = flag.String("lint", "", "check every page in the _content directory, print the problems found in `format` text or json, and exit")
+	gitCache   = flag.String("gitcache", "", "cache objects downloaded for -tip, -wiki, -gopls, and -modgit in `dir`")
+	gitMirror  = flag.String("gitmirror", "", "load -tip, -wiki, -gopls, and -modgit content from local Git repositories or bundles in `dir`")
+	gitHistory = flag.Int("githistory", 0, "load `N` commits of history for -tip, -wiki, and -gopls content, recording the commit that last changed each file")
 
 	runningOnAppEngine = os.Getenv("PORT") != ""
 	forceGorootZip, _  = strconv.ParseBool(os.Getenv("GOLANGORG_FORCE_GOROOT_ZIP"))
+	previewToken       = os.Getenv("GOLANGORG_PREVIEW_TOKEN") // see web.Site.SetPreviewToken
 
 	tipFlag   = flag.Bool("tip", runningOnAppEngine, "load git content for tip.golang.org")
 	wikiFlag  = flag.Bool("wiki", runningOnAppEngine, "load git content for go.dev/wiki")
 	goplsFlag = flag.Bool("gopls", runningOnAppEngine, "load git content for go.dev/gopls")
 
+	pageCacheMB = flag.Int("pagecache", defaultPageCacheMB(), "keep up to `MB` megabytes of rendered pages in memory for each site")
+	languages   = flag.String("languages", "", "serve translated pages in the comma-separated `list` of languages besides English, like zh,ja")
+	compressMB  = flag.Int("compresscache", 32, "keep up to `MB` megabytes of gzip-compressed responses in memory")
+	imageCache  = flag.String("imagecache", "", "keep resized images in `dir` across restarts")
+
+	modDirs  = flag.String("moddir", "", "serve package docs for the modules in the comma-separated `list` of local directories")
+	modProxy = flag.String("modproxy", "", "serve package docs for the module zips in `dir`, laid out like a module proxy")
+	modGit   = flag.String("modgit", "", "serve package docs for the modules in the comma-separated `list` of Git repo[@ref]s")
+
 	googleAnalytics string
 )
 
@@ -116,7 +135,20 @@ func main() {
 		usage()
 	}
 
+	if *lintFormat != "" {
+		runLint(*contentDir, openGoroot(*goroot), *lintFormat)
+		return
+	}
+
 	handler := NewHandler(*contentDir, *goroot)
+	if *exportDir != "" {
+		content := website.Content()
+		if *contentDir != "" {
+			content = os.DirFS(*contentDir)
+		}
+		runExport(handler, content, *exportDir)
+		return
+	}
 	handler = webtest.HandlerWithCheck(handler, "/_readycheck",
 		testdataFS, "testdata/*.txt")
 
@@ -161,23 +193,24 @@ func NewHandler(contentDir, goroot string) http.Handler {
 	// Serve files from _content, falling back to GOROOT.
 
 	// Use explicit contentDir if specified, otherwise embedded copy.
-	var contentFS fs.FS
+	// When serving contentDir with a page cache or -reload, watch it for
+	// edits and replace the file system after each one, so that the sites
+	// discard their cached pages and change their ETags, as for the
+	// Git-backed trees. The watcher is started at the end of NewHandler,
+	// once the reloader, if any, can share it.
+	var localFS atomicFS
+	var contentChanged []func()
 	if contentDir != "" {
-		contentFS = os.DirFS(contentDir)
-	} else {
-		contentFS = website.Content()
-	}
-
-	var gorootFS fs.FS
-	if strings.HasSuffix(goroot, ".zip") {
-		z, err := zip.OpenReader(goroot)
-		if err != nil {
-			log.Fatal(err)
+		localFS.Set(os.DirFS(contentDir))
+		if *pageCacheMB > 0 || *reloadFlag {
+			contentChanged = append(contentChanged, func() { localFS.Set(os.DirFS(contentDir)) })
 		}
-		gorootFS = &seekableFS{z}
 	} else {
-		gorootFS = os.DirFS(goroot)
+		localFS.Set(website.Content())
 	}
+	var contentFS fs.FS = &localFS
+
+	gorootFS := openGoroot(goroot)
 
 	// go.dev/wiki serves content from the very latest Git commit of the wiki repo.
 	// Start with the _content/wiki directory as placeholder until Git loads.
@@ -197,16 +230,25 @@ func NewHandler(contentDir, goroot string) http.Handler {
 	//
 	// tip.golang.org/gopls serves the latest commit of golang.org/x/tools/gopls/doc.
 	var tipGoroot atomicFS
-	if _, err := newSite(mux, "tip.golang.org", addGopls(contentFS, "HEAD"), &tipGoroot); err != nil {
+	tipContent, tipTools := addGopls(contentFS, "HEAD")
+	tipSearch := newSiteSearch(tipContent, &tipGoroot)
+	tipSite, err := newSite(mux, "tip.golang.org", tipContent, &tipGoroot, tipSearch, &localFS, &wikiFS, &tipGoroot, tipTools)
+	if err != nil {
 		log.Fatalf("loading tip site: %v", err)
 	}
+	// Index tip's GOROOT once watchGit installs it, and again after each new commit.
+	go tipSearch.update(".")
+	tipGoroot.OnSet(func() { go tipSearch.updateGoroot() })
+	tipSearch.updateOnSet(&localFS, ".")
+	tipSearch.updateOnSet(&wikiFS, "wiki")
+	tipSearch.updateOnSet(tipTools, "gopls")
 	if *tipFlag {
 		go watchGit(&tipGoroot, "https://go.googlesource.com/go", "HEAD")
 	}
 
 	// go.dev/gopls serves golang.org/x/tools/gopls/doc from the
 	// tip commit on the latest release branch.
-	contentFS = addGopls(contentFS, "gopls/latest-release-branch")
+	contentFS, toolsFS := addGopls(contentFS, "gopls/latest-release-branch")
 
 	// beta.golang.org is an old name for tip.
 	mux.Handle("beta.golang.org/", redirectPrefix("https://tip.golang.org/"))
@@ -257,14 +299,34 @@ func NewHandler(contentDir, goroot string) http.Handler {
 
 	// TODO(rsc): The unionFS is a hack until we move the files in a followup CL.
 	siteMux := http.NewServeMux()
-	godevSite, err := newSite(siteMux, "", contentFS, gorootFS)
+	godevSearch := newSiteSearch(contentFS, gorootFS)
+	godevSearch.updateOnSet(&localFS, ".")
+	godevSearch.updateOnSet(&wikiFS, "wiki")
+	godevSearch.updateOnSet(toolsFS, "gopls")
+	go func() {
+		godevSearch.update(".")
+		godevSearch.updateGoroot()
+	}()
+	godevSite, err := newSite(siteMux, "", contentFS, gorootFS, godevSearch, &localFS, &wikiFS, toolsFS)
 	if err != nil {
 		log.Fatalf("newSite go.dev: %v", err)
 	}
-	chinaSite, err := newSite(siteMux, "golang.google.cn", contentFS, gorootFS)
+	chinaSite, err := newSite(siteMux, "golang.google.cn", contentFS, gorootFS, godevSearch, &localFS, &wikiFS, toolsFS)
 	if err != nil {
 		log.Fatalf("newSite golang.google.cn: %v", err)
 	}
+
+	// The style sheets and scripts all come from _content,
+	// so build them once and share them between the sites.
+	// With -reload, serve them as they are instead,
+	// so that edits to them take effect without restarting.
+	if !*reloadFlag {
+		if err := godevSite.BuildAssets(assetDirs...); err != nil {
+			log.Fatalf("building assets: %v", err)
+		}
+		chinaSite.UseAssets(godevSite)
+		tipSite.UseAssets(godevSite)
+	}
 	if runningOnAppEngine {
 		appEngineSetup(mux)
 	}
@@ -275,9 +337,11 @@ func NewHandler(contentDir, goroot string) http.Handler {
 	play.RegisterHandlers(mux, godevSite, chinaSite)
 
 	mux.Handle("/explore/", http.StripPrefix("/explore/", redirectPrefix("https://pkg.go.dev/")))
-	if err := blog.RegisterFeeds(mux, "", godevSite); err != nil {
+	blogFeeds, err := blog.RegisterFeeds(mux, "", godevSite, siteMux)
+	if err != nil {
 		log.Fatalf("blog: %v", err)
 	}
+	localFS.OnSet(blogFeeds.Invalidate)
 
 	// Note: Only golang.org/x/, no go.dev/x/.
 	mux.Handle("golang.org/x/", http.HandlerFunc(xHandler))
@@ -298,14 +362,52 @@ func NewHandler(contentDir, goroot string) http.Handler {
 	h = addCSP(mux)
 	h = hostEnforcerHandler(h)
 	h = hostPathHandler(h)
+
+	if *reloadFlag {
+		if contentDir == "" {
+			log.Printf("-reload: no content directory to watch; serving embedded content")
+		} else {
+			rl := newReloader(contentDir, func() []error {
+				site := web.NewSite(os.DirFS(contentDir))
+				site.Funcs(siteFuncs("", gorootFS))
+				setLanguages(site)
+				return site.Check(".")
+			})
+			contentChanged = append(contentChanged, rl.changed)
+			h = rl.handler(h)
+		}
+	}
+	if len(contentChanged) > 0 {
+		go watchDir(contentDir, func() {
+			for _, f := range contentChanged {
+				f()
+			}
+		})
+	}
+	h = compressHandler(h, newCompressCache(int64(*compressMB)<<20))
 	return h
 }
 
+// openGoroot returns the file system for goroot,
+// which is either a directory or a .zip file.
+func openGoroot(goroot string) fs.FS {
+	if strings.HasSuffix(goroot, ".zip") {
+		z, err := zip.OpenReader(goroot)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return &seekableFS{z}
+	}
+	return os.DirFS(goroot)
+}
+
 // addGopls registers the /gopls endpoint to serve
 // golang.org/x/tools/gopls/doc, depending on ref, at either the
 // latest Gopls release or the latest x/tools commit.
-func addGopls(contentFS fs.FS, ref string) fs.FS {
-	var toolsFS atomicFS
+// It returns the new content file system and the x/tools file system
+// mounted in it, which changes as new commits are loaded.
+func addGopls(contentFS fs.FS, ref string) (fs.FS, *atomicFS) {
+	toolsFS := new(atomicFS)
 
 	// Before the first git clone completes,
 	// serve _content/gopls/doc as placeholder.
@@ -324,25 +426,124 @@ func addGopls(contentFS fs.FS, ref string) fs.FS {
 		// don't fetch x/tools in that case.
 
 	} else if *goplsFlag {
-		go watchGit(&toolsFS, "https://go.googlesource.com/tools", ref)
+		go watchGit(toolsFS, "https://go.googlesource.com/tools", ref)
 	}
 
-	goplsDocFS, err := fs.Sub(&toolsFS, "gopls/doc")
+	goplsDocFS, err := fs.Sub(toolsFS, "gopls/doc")
 	if err != nil {
 		log.Fatalf("can't restrict to gopls/doc tree: %v", err)
 	}
-	return &mountFS{contentFS, "gopls", goplsDocFS}
+	return &mountFS{contentFS, "gopls", goplsDocFS}, toolsFS
 }
 
 var gorebuild = NewCachedURL("https://gorebuild.storage.googleapis.com/gorebuild.json", 5*time.Minute)
 
+// siteFS returns the file system for a site serving
+// the given content and goroot file system pair.
+func siteFS(content, goroot fs.FS) fs.FS {
+	return unionFS{content, &hideRootMDFS{&fixSpecsFS{goroot}}}
+}
+
 // newSite creates a new site for a given content and goroot file system pair
 // and registers it in mux to handle requests for host.
+// The site serves search results from ss.
 // If host is the empty string, the registrations are for the wildcard host.
-func newSite(mux *http.ServeMux, host string, content, goroot fs.FS) (*web.Site, error) {
-	fsys := unionFS{content, &hideRootMDFS{&fixSpecsFS{goroot}}}
+// The afss list the Git-backed file systems that the content and goroot
+// include, so that the site can discard cached data when they change.
+func newSite(mux *http.ServeMux, host string, content, goroot fs.FS, ss *siteSearch, afss ...*atomicFS) (*web.Site, error) {
+	fsys := siteFS(content, goroot)
 	site := web.NewSite(fsys)
-	site.Funcs(template.FuncMap{
+	site.Funcs(siteFuncs(host, goroot))
+	site.SetSiteInfo(siteInfo(host))
+	setLanguages(site)
+	site.SetPreviewToken(previewToken)
+	for _, prefix := range staticPrefixes {
+		site.SetCacheControl(prefix, "public, max-age=3600")
+	}
+	site.SetWebPEncoder(webpEncoder())
+	if *imageCache != "" {
+		site.SetImageCache(*imageCache)
+	}
+	docs, err := pkgdoc.NewServer(fsys, site, googleCN, docModules()...)
+	if err != nil {
+		return nil, err
+	}
+	pkgdoc.SetSymbolIndex(docs, ss.symbols)
+
+	mux.Handle(host+"/", site)
+	mux.Handle(host+"/cmd/", docs)
+	mux.Handle(host+"/pkg/", docs)
+	mux.Handle(host+"/doc/api/", docs)
+	mux.Handle(host+"/doc/codewalk/", codewalk.NewServer(fsys, site))
+	mux.Handle(host+"/search", search.NewServer(site, ss.index))
+
+	name, baseURL := host, "https://"+host
+	if host == "" {
+		name, baseURL = "go.dev", "https://go.dev"
+	}
+	sm := sitemap.NewServer(content, baseURL, func() []string {
+		return append(slices.Clone(extraSitemapPages), pkgdoc.URLs(goroot)...)
+	})
+	setLanguages(sm)
+	sm.RegisterHandlers(mux, host)
+	for _, afs := range afss {
+		afs.OnSet(sm.Invalidate)
+		afs.OnSet(site.Invalidate)
+		afs.OnSet(func() { pkgdoc.Invalidate(docs) })
+	}
+	cachePages(name, site)
+	return site, nil
+}
+
+// extraSitemapPages lists pages served from GOROOT outside the content trees,
+// to be listed in the sitemap along with the package documentation.
+var extraSitemapPages = []string{
+	"/ref/spec",
+	"/ref/mem",
+}
+
+// setLanguages configures x, a web.Site or sitemap.Server,
+// to serve translated pages in the languages listed by -languages.
+// The go.dev content is written in English.
+func setLanguages(x interface{ SetLanguages(string, ...string) }) {
+	if *languages != "" {
+		x.SetLanguages("en", strings.Split(*languages, ",")...)
+	}
+}
+
+// defaultPageCacheMB returns the default for the -pagecache flag.
+// Local servers usually serve _content from disk, where edits
+// should appear immediately, so they do not cache rendered pages.
+func defaultPageCacheMB() int {
+	if runningOnAppEngine {
+		return 64
+	}
+	return 0
+}
+
+// pageCacheStatsInterval is how often cachePages logs cache statistics.
+const pageCacheStatsInterval = 1 * time.Hour
+
+// cachePages enables the rendered-page cache for site, if -pagecache is set.
+// (newSite arranges to discard cached pages whenever one of the Git-backed
+// file systems or the -content directory changes.) It also logs the cache statistics periodically,
+// labeled with name.
+func cachePages(name string, site *web.Site) {
+	if *pageCacheMB <= 0 {
+		return
+	}
+	site.SetPageCache(int64(*pageCacheMB) << 20)
+	go func() {
+		for range time.Tick(pageCacheStatsInterval) {
+			log.Printf("pagecache %s: %v", name, site.PageCacheStats())
+		}
+	}()
+}
+
+// siteFuncs returns the template functions for a site
+// serving host with the given goroot file system.
+func siteFuncs(host string, goroot fs.FS) template.FuncMap {
+	return template.FuncMap{
 		"googleAnalytics": func() string { return googleAnalytics },
 		"googleCN":        func() bool { return host == "golang.google.cn" },
 		"gorebuild":       gorebuild.Get,
@@ -352,19 +553,40 @@ func newSite(mux *http.ServeMux, host string, content, goroot fs.FS) (*web.Site,
 		"releases":        func() []*history.Major { return history.Majors },
 		"rfc3339":         parseRFC3339,
 		"section":         section,
+		"tagURL":          blog.TagURL,
 		"version":         func() string { return runtime.Version() },
 		"docNext":         releaseNotePreview{goroot}.MergedFragments,
-	})
-	docs, err := pkgdoc.NewServer(fsys, site, googleCN)
-	if err != nil {
-		return nil, err
 	}
+}
 
-	mux.Handle(host+"/", site)
-	mux.Handle(host+"/cmd/", docs)
-	mux.Handle(host+"/pkg/", docs)
-	mux.Handle(host+"/doc/codewalk/", codewalk.NewServer(fsys, site))
-	return site, nil
+// siteInfo returns the information about the site for host used in page metadata.
+// Pages on golang.google.cn give go.dev as their canonical location,
+// since they have the same content.
+func siteInfo(host string) web.SiteInfo {
+	base := "https://go.dev"
+	if host == "tip.golang.org" {
+		base = "https://" + host
+	}
+	return web.SiteInfo{
+		BaseURL: base,
+		Name:    "The Go Programming Language",
+		Image:   "/doc/gopher/gopher5logo.jpg",
+		Twitter: "@golang",
+	}
+}
+
+// assetDirs lists the directories holding the style sheets and scripts
+// that sites bundle and serve at fingerprinted URLs (see web.Site.BuildAssets).
+var assetDirs = []string{"css", "js"}
+
+// staticPrefixes lists the URL path prefixes for style sheets,
+// scripts, fonts, and images, which browsers may cache for an hour
+// before checking with the server for a new version.
+var staticPrefixes = []string{
+	"/css/",
+	"/fonts/",
+	"/images/",
+	"/js/",
 }
 
 // releaseNotePreview implements a preview of upcoming release notes.
@@ -403,6 +625,7 @@ func parseRFC3339(s string) (time.Time, error) {
 // When the ref (e.g. "HEAD") refers to a new commit, watchGit downloads the new tree and calls
 // fsys.Set to install the new file system.
 func watchGit(fsys *atomicFS, repo, ref string) {
+	repo = mirrorRepo(repo)
 	for {
 		// watchGit1 runs until it panics (hopefully never).
 		// If that happens, sleep 5 minutes and try again.
@@ -411,6 +634,26 @@ func watchGit(fsys *atomicFS, repo, ref string) {
 	}
 }
 
+// mirrorRepo returns the location of the Git repo to use in place of repo.
+// If -gitmirror is set and the mirror directory contains a repository
+// or bundle named for the last element of repo (like "wiki", "wiki.git",
+// or "wiki.bundle" for https://go.googlesource.com/wiki), mirrorRepo
+// returns its file name. Otherwise it returns repo unchanged.
+func mirrorRepo(repo string) string {
+	if *gitMirror == "" {
+		return repo
+	}
+	name := path.Base(repo)
+	for _, file := range []string{name, name + ".git", name + ".bundle"} {
+		file = filepath.Join(*gitMirror, file)
+		if _, err := os.Stat(file); err == nil {
+			return file
+		}
+	}
+	log.Printf("watchGit %s: no mirror in %s", repo, *gitMirror)
+	return repo
+}
+
 // watchGit1 does the actual work of watchGit and recovers from panics.
 func watchGit1(afs *atomicFS, repo, ref string) {
 	defer func() {
@@ -430,6 +673,15 @@ func watchGit1(afs *atomicFS, repo, ref string) {
 		}
 		break
 	}
+	if *gitHistory > 0 {
+		r.SetHistory(*gitHistory)
+	}
+	if *gitCache != "" {
+		dir := filepath.Join(*gitCache, filepath.FromSlash(strings.TrimPrefix(repo, "https://")))
+		if err := r.SetCache(dir); err != nil {
+			log.Printf("watchGit %s: %v", repo, err)
+		}
+	}
 
 	var h gitfs.Hash
 	for {
@@ -904,11 +1156,29 @@ func (*memFile) Close() error                 { return nil }
 // as well as updating (assigning a different fs.FS to use in future read requests).
 type atomicFS struct {
 	v atomic.Value
+
+	mu    sync.Mutex
+	onSet []func() // called after each Set
 }
 
 // Set sets the file system used by future calls to Open.
+// It then calls the functions registered with OnSet.
 func (a *atomicFS) Set(fsys fs.FS) {
 	a.v.Store(&fsys)
+
+	a.mu.Lock()
+	onSet := a.onSet
+	a.mu.Unlock()
+	for _, f := range onSet {
+		f()
+	}
+}
+
+// OnSet arranges for f to be called after each future call to Set.
+func (a *atomicFS) OnSet(f func()) {
+	a.mu.Lock()
+	a.onSet = append(a.onSet, f)
+	a.mu.Unlock()
 }
 
 // A mountFS is a root FS with a second FS mounted at a specific location.
@@ -0,0 +1,55 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"golang.org/x/website/internal/web"
+)
+
+// webpEncoder returns a WebP encoder that runs the cwebp command,
+// or nil if cwebp is not installed, in which case the sites offer no WebP images.
+// There is no WebP encoder in the Go standard library or x/image.
+// (If cwebp fails, web.Site serves the image in its own format.)
+func webpEncoder() web.WebPEncoder {
+	cwebp, err := exec.LookPath("cwebp")
+	if err != nil {
+		return nil
+	}
+	return func(w io.Writer, m image.Image) error {
+		dir, err := os.MkdirTemp("", "golangorg-webp-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+
+		in, out := filepath.Join(dir, "in.png"), filepath.Join(dir, "out.webp")
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, m); err != nil {
+			return err
+		}
+		if err := os.WriteFile(in, buf.Bytes(), 0666); err != nil {
+			return err
+		}
+		cmd := exec.Command(cwebp, "-quiet", "-q", "80", "-m", "6", in, "-o", out)
+		if msg, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("cwebp: %v\n%s", err, msg)
+		}
+		data, err := os.ReadFile(out)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"image"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestWebPEncoder(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake cwebp is a shell script")
+	}
+	dir := t.TempDir()
+	t.Setenv("PATH", dir)
+
+	// Without cwebp, there is no encoder, so sites offer no WebP images.
+	if enc := webpEncoder(); enc != nil {
+		t.Fatalf("webpEncoder without cwebp = non-nil")
+	}
+
+	// With cwebp, the encoder runs it: cwebp -quiet -q 80 -m 6 in.png -o out.webp.
+	cwebp := filepath.Join(dir, "cwebp")
+	write := func(script string) {
+		t.Helper()
+		if err := os.WriteFile(cwebp, []byte("#!/bin/sh\n"+script), 0777); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(`printf 'WEBP %s' "$6" >"$8"` + "\n")
+	enc := webpEncoder()
+	if enc == nil {
+		t.Fatalf("webpEncoder with cwebp = nil")
+	}
+	m := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	var buf bytes.Buffer
+	if err := enc(&buf, m); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); !strings.HasPrefix(got, "WEBP ") || !strings.HasSuffix(got, "in.png") {
+		t.Errorf("encoded %q, want output of fake cwebp", got)
+	}
+
+	// A failing cwebp is reported with its output.
+	write("echo cannot encode >&2; exit 1\n")
+	buf.Reset()
+	if err := enc(&buf, m); err == nil || !strings.Contains(err.Error(), "cannot encode") {
+		t.Errorf("encoding with failing cwebp: err = %v, want cwebp's message", err)
+	}
+}
@@ -6,6 +6,7 @@ require (
 	cloud.google.com/go/cloudbuild v1.15.0
 	cloud.google.com/go/datastore v1.15.0
 	cloud.google.com/go/storage v1.31.0
+	github.com/andybalholm/brotli v1.2.0
 	github.com/chromedp/cdproto v0.0.0-20241022234722-4d5d5faf59fb
 	github.com/chromedp/chromedp v0.11.1
 	github.com/evanw/esbuild v0.18.19
@@ -35,13 +35,15 @@ type PkgDB struct {
 	Method map[string]map[string]string // "*Server" ->"Shutdown"->1.8
 	Func   map[string]string            // "NewServer" -> "1.7"
 	Field  map[string]map[string]string // "ClientTrace" -> "Got1xxResponse" -> "1.11"
+	Const  map[string]string            // "StatusEarlyHints" -> "1.19"
+	Var    map[string]string            // "ErrSchemeMismatch" -> "1.21"
 }
 
 // Func returns a string (such as "1.7") specifying which Go
 // version introduced a symbol, unless it was introduced in Go1, in
 // which case it returns the empty string.
 //
-// The kind is one of "type", "method", or "func".
+// The kind is one of "type", "method", "func", "const", or "var".
 //
 // The receiver is only used for "methods" and specifies the receiver type,
 // such as "*Server".
@@ -57,17 +59,29 @@ func (v DB) Func(pkg, kind, receiver, name string) string {
 		return pv.Type[name]
 	case "method":
 		return pv.Method[receiver][name]
+	case "const":
+		return pv.Const[name]
+	case "var":
+		return pv.Var[name]
 	}
 	return ""
 }
 
 // Load loads a database from fsys's api/go*.txt files.
 // Typically, fsys should be the root of a Go repository (a $GOROOT).
+//
+// If fsys also has api/next/*.txt files, as it does during a release cycle,
+// Load records the features they list as added in the next release:
+// the version after the latest api/go*.txt file.
 func Load(fsys fs.FS) (DB, error) {
 	files, err := fs.Glob(fsys, "api/go*.txt")
 	if err != nil {
 		return nil, err
 	}
+	next, err := fs.Glob(fsys, "api/next/*.txt")
+	if err != nil {
+		return nil, err
+	}
 
 	// Process files in go1.n, go1.n-1, ..., go1.2, go1.1, go1 order.
 	//
@@ -89,8 +103,16 @@ func Load(fsys fs.FS) (DB, error) {
 	}
 	sort.Slice(files, func(i, j int) bool { return ver(files[i]) > ver(files[j]) })
 	vp := new(parser)
+	if len(files) > 0 {
+		nextVer := "1." + strconv.Itoa(ver(files[0])+1)
+		for _, f := range next {
+			if err := vp.parseFile(fsys, f, nextVer); err != nil {
+				return nil, err
+			}
+		}
+	}
 	for _, f := range files {
-		if err := vp.parseFile(fsys, f); err != nil {
+		if err := vp.parseFile(fsys, f, ""); err != nil {
 			return nil, err
 		}
 	}
@@ -108,15 +130,19 @@ type parser struct {
 // vp.res to VERSION, overwriting any previous value.
 // As a special case, if goVERSION is "go1", it deletes
 // from the map instead.
-func (vp *parser) parseFile(fsys fs.FS, name string) error {
+// If ver is not empty, parseFile uses it in place of VERSION,
+// as for the files in $GOROOT/api/next.
+func (vp *parser) parseFile(fsys fs.FS, name, ver string) error {
 	f, err := fsys.Open(name)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
 
-	base := filepath.Base(name)
-	ver := strings.TrimPrefix(strings.TrimSuffix(base, ".txt"), "go")
+	if ver == "" {
+		base := filepath.Base(name)
+		ver = strings.TrimPrefix(strings.TrimSuffix(base, ".txt"), "go")
+	}
 
 	sc := bufio.NewScanner(f)
 	for sc.Scan() {
@@ -134,6 +160,8 @@ func (vp *parser) parseFile(fsys fs.FS, name string) error {
 				Method: make(map[string]map[string]string),
 				Func:   make(map[string]string),
 				Field:  make(map[string]map[string]string),
+				Const:  make(map[string]string),
+				Var:    make(map[string]string),
 			}
 			vp.res[row.pkg] = pkgi
 		}
@@ -168,6 +196,18 @@ func (vp *parser) parseFile(fsys fs.FS, name string) error {
 				pkgi.Field[row.structName] = make(map[string]string)
 			}
 			pkgi.Field[row.structName][row.name] = ver
+		case "const":
+			if ver == "1" {
+				delete(pkgi.Const, row.name)
+				break
+			}
+			pkgi.Const[row.name] = ver
+		case "var":
+			if ver == "1" {
+				delete(pkgi.Var, row.name)
+				break
+			}
+			pkgi.Var[row.name] = ver
 		}
 	}
 	return sc.Err()
@@ -177,7 +217,7 @@ func (vp *parser) parseFile(fsys fs.FS, name string) error {
 // $GOROOT/api/go.*txt file.
 type row struct {
 	pkg        string // "net/http"
-	kind       string // "type", "func", "method", "field" TODO: "const", "var"
+	kind       string // "type", "func", "method", "field", "const", "var"
 	recv       string // for methods, the receiver type ("Server", "*Server")
 	name       string // name of type, (struct) field, func, method
 	structName string // for struct fields, the outer struct name
@@ -221,6 +261,12 @@ func parseRow(s string) (vr row, ok bool) {
 			vr.name = rest[:i]
 			return vr, true
 		}
+	case strings.HasPrefix(rest, "const "), strings.HasPrefix(rest, "var "): // "const StatusEarlyHints = 103", "var ErrSchemeMismatch error"
+		vr.kind, rest, _ = strings.Cut(rest, " ")
+		if i := strings.IndexByte(rest, ' '); i != -1 {
+			vr.name = rest[:i]
+			return vr, true
+		}
 	case strings.HasPrefix(rest, "func "):
 		vr.kind = "func"
 		rest = rest[len("func "):]
@@ -7,8 +7,10 @@ package api
 import (
 	"go/build"
 	"os"
+	"reflect"
 	"runtime"
 	"testing"
+	"testing/fstest"
 )
 
 func TestParseVersionRow(t *testing.T) {
@@ -65,6 +67,22 @@ func TestParseVersionRow(t *testing.T) {
 				recv: "Encoding",
 			},
 		},
+		{
+			row: "pkg net/http, const StatusEarlyHints = 103",
+			want: row{
+				pkg:  "net/http",
+				kind: "const",
+				name: "StatusEarlyHints",
+			},
+		},
+		{
+			row: "pkg archive/zip, var ErrInsecurePath error #55356",
+			want: row{
+				pkg:  "archive/zip",
+				kind: "var",
+				name: "ErrInsecurePath",
+			},
+		},
 	}
 
 	for i, tt := range tests {
@@ -139,3 +157,59 @@ func TestAPIVersion(t *testing.T) {
 		}
 	}
 }
+
+func TestFeatures(t *testing.T) {
+	fsys := fstest.MapFS{
+		"api/go1.txt": {Data: []byte(`pkg p, func Old() int
+pkg p, type T struct
+`)},
+		"api/go1.1.txt": {Data: []byte(`pkg p, method (*T) M() error
+pkg p, type T struct, F int
+pkg p, const C = 1
+pkg p, const C ideal-int
+`)},
+		"api/go1.2.txt": {Data: []byte(`pkg p, func Old() int64
+pkg q, var V error
+`)},
+		"api/next/12345.txt": {Data: []byte(`pkg q, func New() error #12345
+`)},
+	}
+	db, err := Load(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Feature{
+		{"1.1", "p", "const", "", "C"},
+		{"1.1", "p", "field", "T", "F"},
+		{"1.1", "p", "method", "*T", "M"},
+		{"1.2", "q", "var", "", "V"},
+		{"1.3", "q", "func", "", "New"},
+	}
+	if got := db.Features(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Features() = %v, want %v", got, want)
+	}
+	if got := db.Func("p", "const", "", "C"); got != "1.1" {
+		t.Errorf(`Func("p", "const", "", "C") = %q, want "1.1"`, got)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	for _, tt := range []struct {
+		x, y string
+		want int
+	}{
+		{"1", "1.1", -1},
+		{"1.9", "1.10", -1},
+		{"1.10", "1.9", +1},
+		{"1.21", "1.21", 0},
+	} {
+		if got := CompareVersions(tt.x, tt.y); got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.x, tt.y, got, tt.want)
+		}
+	}
+	for v, want := range map[string]bool{"1": true, "1.21": true, "go1.21": false, "1.": false, "1.2.3": false, "2.0": false} {
+		if got := ValidVersion(v); got != want {
+			t.Errorf("ValidVersion(%q) = %v, want %v", v, got, want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file lists the API features in a DB by release.
+
+package api
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A Feature is an API feature added to a package after Go 1.
+type Feature struct {
+	Version string // Go version adding the feature, like "1.8"
+	Package string // import path, like "net/http"
+	Kind    string // "const", "var", "type", "func", "method", or "field"
+	Recv    string // for a method, the receiver type ("*Server"); for a field, the struct type ("ClientTrace")
+	Name    string // name of the feature, like "Shutdown"
+}
+
+// Symbol returns the name of the feature as used in doc links:
+// the name, qualified by its type for a method or field,
+// like "Server.Shutdown" or "ClientTrace.Got1xxResponse".
+func (f Feature) Symbol() string {
+	if f.Recv == "" {
+		return f.Name
+	}
+	return strings.TrimPrefix(f.Recv, "*") + "." + f.Name
+}
+
+// Features returns all the features in v, sorted by version,
+// then by package, and then by symbol, so that a type's methods
+// and fields follow the type.
+func (v DB) Features() []Feature {
+	var list []Feature
+	for pkg, pv := range v {
+		add := func(kind, recv string, m map[string]string) {
+			for name, ver := range m {
+				list = append(list, Feature{ver, pkg, kind, recv, name})
+			}
+		}
+		add("const", "", pv.Const)
+		add("var", "", pv.Var)
+		add("type", "", pv.Type)
+		add("func", "", pv.Func)
+		for recv, m := range pv.Method {
+			add("method", recv, m)
+		}
+		for typ, m := range pv.Field {
+			add("field", typ, m)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		fi, fj := list[i], list[j]
+		if c := CompareVersions(fi.Version, fj.Version); c != 0 {
+			return c < 0
+		}
+		if fi.Package != fj.Package {
+			return fi.Package < fj.Package
+		}
+		if si, sj := fi.Symbol(), fj.Symbol(); si != sj {
+			return si < sj
+		}
+		return fi.Kind < fj.Kind
+	})
+	return list
+}
+
+// CompareVersions returns -1, 0, or +1 depending on whether
+// the Go version x is older than, the same as, or newer than y.
+// Versions have the form "1", "1.8", and so on.
+func CompareVersions(x, y string) int {
+	nx, ny := minorVersion(x), minorVersion(y)
+	switch {
+	case nx < ny:
+		return -1
+	case nx > ny:
+		return +1
+	}
+	return 0
+}
+
+// ValidVersion reports whether v is a Go version of the form "1" or "1.N".
+func ValidVersion(v string) bool {
+	return minorVersion(v) >= 0
+}
+
+// minorVersion returns N for the Go version "1.N", 0 for "1",
+// and -1 for anything else.
+func minorVersion(v string) int {
+	if v == "1" {
+		return 0
+	}
+	n, ok := strings.CutPrefix(v, "1.")
+	if !ok || n == "" || strings.Trim(n, "0123456789") != "" {
+		return -1
+	}
+	i, err := strconv.Atoi(n)
+	if err != nil {
+		return -1
+	}
+	return i
+}
@@ -0,0 +1,285 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package blog
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"golang.org/x/website/internal/web"
+)
+
+// postsPerPage is the number of posts on each page of an archive listing.
+// It is a variable so that tests can change it.
+var postsPerPage = 25
+
+// An archive is a list of blog posts sharing a tag, an author, or a year.
+type archive struct {
+	url   string     // URL of listing, like /blog/tag/generics or /blog/2023/
+	title string     // page title, like “Posts tagged generics”
+	id    string     // Atom feed ID suffix, like /tag/generics
+	posts []web.Page // posts, newest first
+}
+
+// An archivePost is a post as listed on an archive page.
+type archivePost struct {
+	URL     string
+	Title   string
+	Date    time.Time
+	Summary string
+	Authors []archiveLink
+	Tags    []archiveLink
+}
+
+// An archiveLink is a link to another archive, for a tag or an author.
+type archiveLink struct {
+	Name string
+	URL  string
+}
+
+// archives holds the archives for the blog, indexed by URL.
+type archives struct {
+	site  *web.Site
+	list  map[string]*archive // by URL
+	tags  map[string]string   // tag slug → name, for links
+	by    map[string]string   // author slug → name, for links
+	years []string            // years with posts, newest first
+
+	mu    sync.Mutex
+	feeds map[string][]byte // rendered feeds, by URL
+}
+
+// newArchives returns the tag, author, and year archives for the
+// dated blog posts in site.
+func newArchives(site *web.Site) (*archives, error) {
+	pages, err := site.Pages("/blog/*")
+	if err != nil {
+		return nil, err
+	}
+	a := &archives{
+		site:  site,
+		list:  make(map[string]*archive),
+		tags:  make(map[string]string),
+		by:    make(map[string]string),
+		feeds: make(map[string][]byte),
+	}
+	add := func(url, title, id string, p web.Page) {
+		ar := a.list[url]
+		if ar == nil {
+			ar = &archive{url: url, title: title, id: id}
+			a.list[url] = ar
+		}
+		ar.posts = append(ar.posts, p)
+	}
+	for _, p := range newestPosts(pages) {
+		date, _ := p["date"].(time.Time)
+		year := strconv.Itoa(date.Year())
+		if n := len(a.years); n == 0 || a.years[n-1] != year {
+			a.years = append(a.years, year)
+		}
+		add("/blog/"+year+"/", "Posts from "+year, "/"+year, p)
+		for _, tag := range stringList(p["tags"]) {
+			s := slug(tag)
+			if s == "" {
+				continue
+			}
+			if _, ok := a.tags[s]; !ok {
+				a.tags[s] = tag
+			}
+			add("/blog/tag/"+s, "Posts tagged "+a.tags[s], "/tag/"+s, p)
+		}
+		for _, name := range stringList(p["by"]) {
+			s := slug(name)
+			if s == "" {
+				continue
+			}
+			if _, ok := a.by[s]; !ok {
+				a.by[s] = name
+			}
+			add("/blog/author/"+s, "Posts by "+a.by[s], "/author/"+s, p)
+		}
+	}
+	return a, nil
+}
+
+// ServeHTTP serves an archive listing or one of its feeds:
+// /blog/tag/t, /blog/author/name, or /blog/year/,
+// or feed.atom or feed.json in the directory of the same name.
+func (a *archives) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	url, feed := r.URL.Path, ""
+	for _, name := range []string{"feed.atom", "feed.json"} {
+		if u, ok := strings.CutSuffix(url, "/"+name); ok {
+			url, feed = u, name
+			if !strings.HasPrefix(url, "/blog/tag/") && !strings.HasPrefix(url, "/blog/author/") {
+				url += "/" // year
+			}
+			break
+		}
+	}
+	ar := a.list[url]
+	if ar == nil && feed == "" {
+		// Redirect /blog/tag/BCP%2047 to /blog/tag/bcp-47.
+		for _, prefix := range []string{"/blog/tag/", "/blog/author/"} {
+			if name, ok := strings.CutPrefix(url, prefix); ok && a.list[prefix+slug(name)] != nil {
+				http.Redirect(w, r, prefix+slug(name), http.StatusMovedPermanently)
+				return
+			}
+		}
+	}
+	if ar == nil {
+		a.site.ServeErrorStatus(w, r, fmt.Errorf("no blog posts for %s", strings.TrimPrefix(url, "/blog/")), http.StatusNotFound)
+		return
+	}
+	switch feed {
+	case "feed.atom":
+		a.serveFeed(w, r, ar, feed, "application/atom+xml; charset=utf-8")
+	case "feed.json":
+		a.serveFeed(w, r, ar, feed, "application/json; charset=utf-8")
+	default:
+		a.serveList(w, r, ar)
+	}
+}
+
+// feedURL returns the URL of the named feed for ar.
+func (ar *archive) feedURL(name string) string {
+	return strings.TrimSuffix(ar.url, "/") + "/" + name
+}
+
+// serveList serves the listing page for ar,
+// showing the page of posts selected by the page URL query parameter.
+func (a *archives) serveList(w http.ResponseWriter, r *http.Request, ar *archive) {
+	n := (len(ar.posts) + postsPerPage - 1) / postsPerPage
+	page := 1
+	if s := r.FormValue("page"); s != "" {
+		i, err := strconv.Atoi(s)
+		if err != nil || i < 1 || i > n || s != strconv.Itoa(i) {
+			a.site.ServeErrorStatus(w, r, fmt.Errorf("no page %s of %s", s, ar.url), http.StatusNotFound)
+			return
+		}
+		page = i
+	}
+
+	var posts []archivePost
+	for _, p := range ar.posts[(page-1)*postsPerPage : min(page*postsPerPage, len(ar.posts))] {
+		posts = append(posts, a.post(p))
+	}
+	pageURL := func(i int) string {
+		if i < 1 || i > n {
+			return ""
+		}
+		if i == 1 {
+			return ar.url
+		}
+		return fmt.Sprintf("%s?page=%d", ar.url, i)
+	}
+	a.site.ServePage(w, r, web.Page{
+		"URL":      ar.url,
+		"title":    ar.title,
+		"layout":   "archive",
+		"posts":    posts,
+		"page":     page,
+		"numPages": n,
+		"prev":     pageURL(page - 1),
+		"next":     pageURL(page + 1),
+		"atom":     ar.feedURL("feed.atom"),
+		"json":     ar.feedURL("feed.json"),
+	})
+}
+
+// post returns the archive listing entry for the blog post p.
+func (a *archives) post(p web.Page) archivePost {
+	post := archivePost{}
+	post.URL, _ = p["URL"].(string)
+	post.Title, _ = p["title"].(string)
+	post.Date, _ = p["date"].(time.Time)
+	post.Summary, _ = p["summary"].(string)
+	for _, name := range stringList(p["by"]) {
+		if s := slug(name); s != "" {
+			post.Authors = append(post.Authors, archiveLink{name, "/blog/author/" + s})
+		}
+	}
+	for _, tag := range stringList(p["tags"]) {
+		if s := slug(tag); s != "" {
+			post.Tags = append(post.Tags, archiveLink{a.tags[s], "/blog/tag/" + s})
+		}
+	}
+	return post
+}
+
+// serveFeed serves the named feed for ar, rendering it on first use.
+func (a *archives) serveFeed(w http.ResponseWriter, r *http.Request, ar *archive, name, ctype string) {
+	url := ar.feedURL(name)
+	a.mu.Lock()
+	data, ok := a.feeds[url]
+	a.mu.Unlock()
+	if !ok {
+		pages := ar.posts[:min(maxFeed, len(ar.posts))]
+		var err error
+		if name == "feed.atom" {
+			data, err = atomFeed(a.site, pages, "The Go Blog - "+ar.title, blogID+ar.id, url)
+		} else {
+			data, err = jsonFeed(a.site, pages)
+		}
+		if err != nil {
+			a.site.ServeError(w, r, err)
+			return
+		}
+		a.mu.Lock()
+		a.feeds[url] = data
+		a.mu.Unlock()
+	}
+	w.Header().Set("Content-type", ctype)
+	w.Write(data)
+}
+
+// stringList returns the strings in the metadata list v,
+// such as the tags: or by: list of a blog post.
+// Numbers, like the tag 47 in “tags: [BCP, 47]”, count as strings.
+func stringList(v any) []string {
+	list, _ := v.([]any)
+	var out []string
+	for _, x := range list {
+		switch x := x.(type) {
+		case string:
+			out = append(out, x)
+		case int, float64:
+			out = append(out, fmt.Sprint(x))
+		}
+	}
+	return out
+}
+
+// TagURL returns the URL path of the archive listing the blog posts
+// with the given tag, like /blog/tag/type-aliases for “type aliases”.
+// As in stringList, a number counts as a string.
+func TagURL(tag any) string {
+	return "/blog/tag/" + slug(fmt.Sprint(tag))
+}
+
+// slug returns the URL path element for a tag or author name:
+// the name in lower case, with each run of characters other than
+// letters, digits, and dots replaced by a single hyphen.
+// For example, slug("Russ Cox") is "russ-cox" and slug("BCP 47") is "bcp-47".
+func slug(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			dash = false
+			b.WriteRune(r)
+		} else {
+			dash = true
+		}
+	}
+	return b.String()
+}
@@ -11,9 +11,11 @@ import (
 	"html"
 	"io"
 	"net/http"
+	"path"
 	"regexp"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/website/internal/blog/atom"
@@ -22,13 +24,13 @@ import (
 
 const maxFeed = 10
 
-// atomFeed returns the Atom feed for the go.dev blog, given the go.dev site.
-func atomFeed(site *web.Site) ([]byte, error) {
-	pages, err := feedPages(site)
-	if err != nil {
-		return nil, err
-	}
+// blogID is the Atom ID of the blog feed.
+// Posts and the other feeds have IDs beginning with blogID.
+const blogID = "tag:blog.golang.org,2013:blog.golang.org" // keep original blog ID
 
+// atomFeed returns the Atom feed with the given title, ID, and URL path
+// for the blog posts pages, given the go.dev site.
+func atomFeed(site *web.Site, pages []web.Page, title, id, self string) ([]byte, error) {
 	var updated time.Time
 	if len(pages) > 0 {
 		updated, _ = pages[0]["date"].(time.Time)
@@ -36,12 +38,12 @@ func atomFeed(site *web.Site) ([]byte, error) {
 
 	baseURL := "https://go.dev"
 	feed := &atom.Feed{
-		Title:   "The Go Blog",
-		ID:      "tag:blog.golang.org,2013:blog.golang.org", // keep original blog ID
+		Title:   title,
+		ID:      id,
 		Updated: atom.Time(updated),
 		Link: []atom.Link{{
 			Rel:  "self",
-			Href: baseURL + "/blog/feed.atom",
+			Href: baseURL + self,
 		}},
 	}
 
@@ -61,7 +63,7 @@ func atomFeed(site *web.Site) ([]byte, error) {
 
 		e := &atom.Entry{
 			Title: title,
-			ID:    feed.ID + strings.TrimPrefix(url, "/blog"),
+			ID:    blogID + strings.TrimPrefix(url, "/blog"),
 			Link: []atom.Link{{
 				Rel:  "alternate",
 				Href: baseURL + url,
@@ -95,13 +97,8 @@ type jsonItem struct {
 	Author  string
 }
 
-// jsonFeed returns the JSON feed for the go.dev blog, given the go.dev site.
-func jsonFeed(site *web.Site) ([]byte, error) {
-	pages, err := feedPages(site)
-	if err != nil {
-		return nil, err
-	}
-
+// jsonFeed returns the JSON feed for the blog posts pages, given the go.dev site.
+func jsonFeed(site *web.Site, pages []web.Page) ([]byte, error) {
 	baseURL := "https://go.dev"
 	var feed []jsonItem
 	for _, p := range pages {
@@ -131,27 +128,34 @@ func jsonFeed(site *web.Site) ([]byte, error) {
 	return json.Marshal(feed)
 }
 
+// feedPages returns the newest blog posts, for the blog feeds.
 func feedPages(site *web.Site) ([]web.Page, error) {
 	pages, err := site.Pages("/blog/*")
 	if err != nil {
 		return nil, err
 	}
-	sort.Slice(pages, func(i, j int) bool {
-		ti, _ := pages[i]["date"].(time.Time)
-		tj, _ := pages[j]["date"].(time.Time)
-		return ti.After(tj)
-	})
+	pages = newestPosts(pages)
 	if len(pages) > maxFeed {
 		pages = pages[:maxFeed]
 	}
-	for ; len(pages) > 0; pages = pages[:len(pages)-1] {
-		last := pages[len(pages)-1]
-		t, _ := last["date"].(time.Time)
-		if !t.IsZero() {
-			break
+	return pages, nil
+}
+
+// newestPosts returns the dated pages in pages, sorted newest first.
+// Pages without dates, like the blog index, are not posts.
+func newestPosts(pages []web.Page) []web.Page {
+	var posts []web.Page
+	for _, p := range pages {
+		if t, _ := p["date"].(time.Time); !t.IsZero() {
+			posts = append(posts, p)
 		}
 	}
-	return pages, nil
+	sort.SliceStable(posts, func(i, j int) bool {
+		ti, _ := posts[i]["date"].(time.Time)
+		tj, _ := posts[j]["date"].(time.Time)
+		return ti.After(tj)
+	})
+	return posts
 }
 
 func authors(p web.Page) (string, error) {
@@ -181,23 +185,42 @@ var validJSONPFunc = regexp.MustCompile(`(?i)^[a-z_][a-z0-9_.]*$`)
 
 // RegisterFeeds registers the blog Atom and JSON feeds for site on mux,
 // using host as a host prefix on the registered paths.
-func RegisterFeeds(mux *http.ServeMux, host string, site *web.Site) error {
-	atom, err := atomFeed(site)
-	if err != nil {
-		return err
+// It also registers the archive pages listing the posts with a given tag
+// (/blog/tag/t), by a given author (/blog/author/name), or from a given year
+// (/blog/2009/), each with its own feeds (feed.atom and feed.json
+// in the directory of the same name, like /blog/tag/t/feed.atom).
+// Archive pages are rendered using the “archive” layout.
+// The year archives are served by a handler for the /blog/ tree,
+// which passes the requests for other paths to next,
+// the handler that serves the blog pages themselves.
+//
+// The feeds and archives omit unpublished posts (see web.Published).
+// They are rebuilt when a post scheduled for a future date is published
+// and after a call to the returned Feeds' Invalidate method.
+func RegisterFeeds(mux *http.ServeMux, host string, site *web.Site, next http.Handler) (*Feeds, error) {
+	f := &Feeds{site: site, next: next}
+	if _, err := f.load(); err != nil {
+		return nil, err
 	}
+
 	atomHandler := func(w http.ResponseWriter, r *http.Request) {
+		fd, err := f.load()
+		if err != nil {
+			site.ServeError(w, r, err)
+			return
+		}
 		w.Header().Set("Content-type", "application/atom+xml; charset=utf-8")
-		w.Write(atom)
+		w.Write(fd.atom)
 	}
 	mux.HandleFunc("/blog/feed.atom", atomHandler)
 	mux.HandleFunc("/blog/feeds/posts/default", atomHandler)
 
-	json, err := jsonFeed(site)
-	if err != nil {
-		return err
-	}
 	jsonHandler := func(w http.ResponseWriter, r *http.Request) {
+		fd, err := f.load()
+		if err != nil {
+			site.ServeError(w, r, err)
+			return
+		}
 		if p := r.FormValue("jsonp"); validJSONPFunc.MatchString(p) {
 			w.Header().Set("Content-type", "application/javascript; charset=utf-8")
 			io.WriteString(w, p+"(")
@@ -205,8 +228,128 @@ func RegisterFeeds(mux *http.ServeMux, host string, site *web.Site) error {
 		} else {
 			w.Header().Set("Content-type", "application/json; charset=utf-8")
 		}
-		w.Write(json)
+		w.Write(fd.json)
 	}
 	mux.HandleFunc("/blog/.json", jsonHandler)
-	return nil
+
+	mux.HandleFunc(host+"/blog/tag/", f.serveArchive)
+	mux.HandleFunc(host+"/blog/author/", f.serveArchive)
+	mux.HandleFunc(host+"/blog/", f.serveBlog)
+	return f, nil
+}
+
+// Feeds holds the blog feeds and archives,
+// rebuilding them when a scheduled post is published
+// or the site's content changes.
+type Feeds struct {
+	site *web.Site
+	next http.Handler // handler for /blog/ paths other than year archives
+
+	mu      sync.Mutex
+	data    *feedData
+	expires time.Time // when the next scheduled post is published; zero if none
+}
+
+// feedData is the rendered feeds and archives for the published posts.
+type feedData struct {
+	atom      []byte
+	json      []byte
+	archives  *archives
+	years     map[string]bool // years with posts
+	scheduled []time.Time     // dates of posts scheduled for the future
+}
+
+// Invalidate discards the feeds and archives,
+// so that they are rebuilt from the site's current content when next needed.
+// It should be called after the content of the site changes.
+func (f *Feeds) Invalidate() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.data = nil
+}
+
+// serveArchive serves a tag, author, or year archive.
+func (f *Feeds) serveArchive(w http.ResponseWriter, r *http.Request) {
+	fd, err := f.load()
+	if err != nil {
+		f.site.ServeError(w, r, err)
+		return
+	}
+	fd.archives.ServeHTTP(w, r)
+}
+
+// serveBlog serves the /blog/ tree: the archive for a year with posts,
+// like /blog/2009/ and its feeds, or else whatever f.next serves.
+func (f *Feeds) serveBlog(w http.ResponseWriter, r *http.Request) {
+	year, _, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/blog/"), "/")
+	if ok && len(year) == 4 {
+		fd, err := f.load()
+		if err != nil {
+			f.site.ServeError(w, r, err)
+			return
+		}
+		if fd.years[year] {
+			fd.archives.ServeHTTP(w, r)
+			return
+		}
+	}
+	f.next.ServeHTTP(w, r)
+}
+
+// load returns the current feeds and archives,
+// first rebuilding them if a scheduled post has been published
+// or the feeds have been invalidated.
+func (f *Feeds) load() (*feedData, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.data != nil && (f.expires.IsZero() || time.Now().Before(f.expires)) {
+		return f.data, nil
+	}
+
+	pages, err := feedPages(f.site)
+	if err != nil {
+		return nil, err
+	}
+	fd := new(feedData)
+	fd.atom, err = atomFeed(f.site, pages, "The Go Blog", blogID, "/blog/feed.atom")
+	if err != nil {
+		return nil, err
+	}
+	fd.json, err = jsonFeed(f.site, pages)
+	if err != nil {
+		return nil, err
+	}
+	fd.archives, err = newArchives(f.site)
+	if err != nil {
+		return nil, err
+	}
+	fd.years = make(map[string]bool)
+	for _, year := range fd.archives.years {
+		fd.years[year] = true
+	}
+	fd.scheduled = scheduledPosts(f.site)
+
+	f.data, f.expires = fd, time.Time{}
+	for _, t := range fd.scheduled {
+		if f.expires.IsZero() || t.Before(f.expires) {
+			f.expires = t
+		}
+	}
+	return fd, nil
+}
+
+// scheduledPosts returns the dates of the blog posts in site
+// that are scheduled to be published in the future.
+func scheduledPosts(site *web.Site) []time.Time {
+	var list []time.Time
+	site.WalkPages("blog", func(file string, p web.Page, err error) error {
+		if err != nil || path.Dir(file) != "blog" {
+			return nil
+		}
+		if t := web.Scheduled(p); !t.IsZero() {
+			list = append(list, t)
+		}
+		return nil
+	})
+	return list
 }
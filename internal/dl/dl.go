@@ -17,6 +17,9 @@
 //
 //	https://go.dev/dl/?mode=json
 //
+// The query param json (as in https://go.dev/dl/?json) does the same,
+// matching the site's other JSON endpoints.
+//
 // An additional query param, include=all, when used with the mode=json
 // query param, will serve a full list of available downloads, including
 // unstable, stable, and archived releases, in JSON format:
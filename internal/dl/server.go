@@ -63,7 +63,9 @@ func (h server) listHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if r.URL.Query().Get("mode") == "json" {
+	// ?json is the site-wide convention for JSON responses;
+	// ?mode=json is the original form, kept for existing clients.
+	if q := r.URL.Query(); q.Has("json") || q.Get("mode") == "json" {
 		serveJSON(w, r, d)
 		return
 	}
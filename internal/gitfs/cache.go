@@ -0,0 +1,305 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gitfs
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// An objDir is a directory of Git objects stored in loose object format,
+// like the .git/objects directory of a Git checkout.
+// The object with hash 0123456789... is stored in the file 01/23456789...,
+// as the zlib-compressed object header "type size\x00" followed by the object data.
+// See https://git-scm.com/book/en/v2/Git-Internals-Git-Objects.
+type objDir string
+
+// file returns the name of the file holding the object with hash h.
+func (d objDir) file(h Hash) string {
+	x := h.String()
+	return filepath.Join(string(d), x[:2], x[2:])
+}
+
+// read returns the type and data for the object with hash h.
+// If there is no such object, or it cannot be read, read returns 0, nil.
+// The caller is responsible for checking that the data matches the hash.
+func (d objDir) read(h Hash) (objType, []byte) {
+	f, err := os.Open(d.file(h))
+	if err != nil {
+		return objNone, nil
+	}
+	defer f.Close()
+	zr, err := zlib.NewReader(f)
+	if err != nil {
+		return objNone, nil
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return objNone, nil
+	}
+	typ, data, err := parseLoose(data)
+	if err != nil {
+		return objNone, nil
+	}
+	return typ, data
+}
+
+// parseLoose parses the decompressed loose object data,
+// returning the object type and content.
+func parseLoose(data []byte) (objType, []byte, error) {
+	hdr, data, ok := bytes.Cut(data, []byte{0})
+	if !ok {
+		return objNone, nil, fmt.Errorf("malformed loose object: no header")
+	}
+	name, size, ok := bytes.Cut(hdr, []byte{' '})
+	if !ok {
+		return objNone, nil, fmt.Errorf("malformed loose object header %q", hdr)
+	}
+	n, err := strconv.Atoi(string(size))
+	if err != nil || n != len(data) {
+		return objNone, nil, fmt.Errorf("malformed loose object header %q", hdr)
+	}
+	for typ, s := range objTypes {
+		if s != "" && s == string(name) {
+			return objType(typ), data, nil
+		}
+	}
+	return objNone, nil, fmt.Errorf("malformed loose object: unknown type %q", name)
+}
+
+// write writes the object with the given type, hash, and data to d,
+// unless it is already there.
+func (d objDir) write(typ objType, h Hash, data []byte) error {
+	file := d.file(h)
+	if _, err := os.Stat(file); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o777); err != nil {
+		return err
+	}
+
+	// Write to a temporary file and rename it into place,
+	// so that a concurrent reader never sees a partial object.
+	f, err := os.CreateTemp(filepath.Dir(file), "tmp-")
+	if err != nil {
+		return err
+	}
+	zw := zlib.NewWriter(f)
+	fmt.Fprintf(zw, "%s %d\x00", typ, len(data))
+	zw.Write(data)
+	err = zw.Close()
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), file)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// A cachedCommit is a commit recorded in an objDir by addCommit.
+type cachedCommit struct {
+	hash  Hash
+	depth int // commits of history stored, following first parents
+}
+
+// commits returns the list of commits recorded by addCommit, oldest first.
+// The trees of each commit and of depth-1 generations of its history
+// are stored in d, but older ancestors may not be.
+// The list is kept in the file "commits", one "hash depth" line per commit.
+func (d objDir) commits() []cachedCommit {
+	data, _ := os.ReadFile(filepath.Join(string(d), "commits"))
+	var list []cachedCommit
+	for _, line := range strings.Split(string(data), "\n") {
+		f := strings.Fields(line)
+		if len(f) != 2 {
+			continue
+		}
+		h, err := parseHash(f[0])
+		depth, err1 := strconv.Atoi(f[1])
+		if err == nil && err1 == nil && depth > 0 {
+			list = append(list, cachedCommit{h, depth})
+		}
+	}
+	return list
+}
+
+// depth returns the depth recorded by addCommit for the commit h,
+// or 0 if h has not been recorded.
+func (d objDir) depth(h Hash) int {
+	for _, c := range d.commits() {
+		if c.hash == h {
+			return c.depth
+		}
+	}
+	return 0
+}
+
+// maxCacheCommits is the number of commits an objDir lists.
+// Once addCommit has recorded more, it forgets the oldest
+// and prunes the objects that only they needed.
+const maxCacheCommits = 16
+
+// addCommit records that d holds the commit h and its tree,
+// along with depth-1 generations of its history.
+// If h is already recorded with more history, addCommit keeps the larger depth.
+func (d objDir) addCommit(h Hash, depth int) error {
+	var list []cachedCommit
+	for _, c := range d.commits() {
+		if c.hash == h {
+			depth = max(depth, c.depth)
+			continue
+		}
+		list = append(list, c)
+	}
+	list = append(list, cachedCommit{h, depth})
+	var dropped bool
+	if len(list) > maxCacheCommits {
+		list = list[len(list)-maxCacheCommits:]
+		dropped = true
+	}
+
+	var buf bytes.Buffer
+	for _, c := range list {
+		fmt.Fprintf(&buf, "%s %d\n", c.hash, c.depth)
+	}
+	f, err := os.CreateTemp(string(d), "tmp-")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(buf.Bytes())
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), filepath.Join(string(d), "commits"))
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if dropped {
+		return d.prune(list)
+	}
+	return nil
+}
+
+// pruneAge is how old an unneeded object must be for prune to remove it.
+// Newer objects may belong to a commit that another Repo sharing
+// the directory has saved but not yet recorded with addCommit.
+const pruneAge = time.Hour
+
+// prune removes the objects in d that are not reachable from the commits
+// in list within their recorded depths, like 'git prune' does,
+// except for objects written in the last pruneAge.
+func (d objDir) prune(list []cachedCommit) error {
+	keep := make(map[Hash]bool)
+	var markTree func(Hash)
+	markTree = func(tree Hash) {
+		if keep[tree] {
+			return
+		}
+		keep[tree] = true
+		_, data := d.read(tree)
+		for len(data) > 0 {
+			e, size := parseDirEntry(data)
+			if size == 0 {
+				break
+			}
+			data = data[size:]
+			switch {
+			case e.mode == 0o160000:
+				// Submodule commit, not stored in this repo.
+			case e.mode&0o40000 != 0:
+				markTree(e.hash)
+			default:
+				keep[e.hash] = true
+			}
+		}
+	}
+	for _, c := range list {
+		h := c.hash
+		for i := 0; i < c.depth; i++ {
+			keep[h] = true
+			typ, data := d.read(h)
+			for j := 0; typ == objTag && j < 10; j++ {
+				obj, _ := commitKeyValue(data, "object")
+				th, err := parseHash(string(obj))
+				if err != nil {
+					break
+				}
+				h = th
+				keep[h] = true
+				typ, data = d.read(h)
+			}
+			if typ != objCommit {
+				break
+			}
+			_, tree, parents, err := parseCommit(h, data)
+			if err != nil {
+				break
+			}
+			markTree(tree)
+			if len(parents) == 0 {
+				break
+			}
+			h = parents[0]
+		}
+	}
+
+	dirs, err := os.ReadDir(string(d))
+	if err != nil {
+		return err
+	}
+	old := time.Now().Add(-pruneAge)
+	for _, dir := range dirs {
+		if !dir.IsDir() || len(dir.Name()) != 2 {
+			continue
+		}
+		files, err := os.ReadDir(filepath.Join(string(d), dir.Name()))
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			h, err := parseHash(dir.Name() + file.Name())
+			if err != nil && !strings.HasPrefix(file.Name(), "tmp-") {
+				continue // neither an object nor a temporary file left by write
+			}
+			if err == nil && keep[h] {
+				continue
+			}
+			info, err := file.Info()
+			if err != nil || !info.ModTime().Before(old) {
+				continue
+			}
+			if err := os.Remove(filepath.Join(string(d), dir.Name(), file.Name())); err != nil {
+				return err
+			}
+		}
+		// Remove the directory if it is now empty.
+		os.Remove(filepath.Join(string(d), dir.Name()))
+	}
+	return nil
+}
+
+// save writes every object in s to d.
+func (d objDir) save(s *store) error {
+	for h, e := range s.index {
+		if err := d.write(e.typ, h, s.data[e.off:e.off+e.len]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -152,6 +152,12 @@ type store struct {
 	sha1  hashpkg.Hash    // reused hash state
 	index map[Hash]stored // lookup index
 	data  []byte          // concatenation of all object data
+
+	// lookup, if non-nil, is consulted for objects missing from the index.
+	// Objects it returns are copied into the store.
+	// It is only set while the store is being filled;
+	// a store in use by a treeFS must not change.
+	lookup func(Hash) (objType, []byte)
 }
 
 // A stored describes a single stored object.
@@ -192,11 +198,66 @@ func (s *store) add(typ objType, data []byte) (Hash, []byte) {
 func (s *store) object(h Hash) (typ objType, data []byte) {
 	d, ok := s.index[h]
 	if !ok {
+		if s.lookup == nil {
+			return 0, nil
+		}
+		typ, data := s.lookup(h)
+		if typ == objNone {
+			return 0, nil
+		}
+		if h1, data := s.add(typ, data); h1 == h {
+			return typ, data
+		}
+		// Corrupt data. The add stored it under a different hash,
+		// where it is harmless.
 		return 0, nil
 	}
 	return d.typ, s.data[d.off : d.off+d.len]
 }
 
+// fill makes sure that s holds the commit with hash h
+// and every tree and blob reachable from it,
+// copying objects from s.lookup as needed.
+func (s *store) fill(h Hash) error {
+	t, err := s.commit(h)
+	if err != nil {
+		return err
+	}
+	seen := make(map[Hash]bool)
+	var walk func(Hash) error
+	walk = func(tree Hash) error {
+		if seen[tree] {
+			return nil
+		}
+		seen[tree] = true
+		typ, data := s.object(tree)
+		if typ != objTree {
+			return fmt.Errorf("commit %s: missing tree %s", h, tree)
+		}
+		for len(data) > 0 {
+			e, size := parseDirEntry(data)
+			if size == 0 {
+				return fmt.Errorf("commit %s: malformed tree %s", h, tree)
+			}
+			data = data[size:]
+			switch {
+			case e.mode == 0o160000:
+				// Submodule commit, not stored in this repo.
+			case e.mode&0o40000 != 0:
+				if err := walk(e.hash); err != nil {
+					return err
+				}
+			default:
+				if typ, _ := s.object(e.hash); typ != objBlob {
+					return fmt.Errorf("commit %s: missing blob %s", h, e.hash)
+				}
+			}
+		}
+		return nil
+	}
+	return walk(t.tree)
+}
+
 // commit returns a treeFS for the file system tree associated with the given commit hash.
 func (s *store) commit(h Hash) (*treeFS, error) {
 	// The commit object data starts with key-value pairs
@@ -204,6 +265,18 @@ func (s *store) commit(h Hash) (*treeFS, error) {
 	if typ == objNone {
 		return nil, fmt.Errorf("commit %s: no such hash", h)
 	}
+	for i := 0; typ == objTag && i < 10; i++ {
+		// Annotated tag; find the commit it refers to.
+		obj, ok := commitKeyValue(data, "object")
+		if !ok {
+			return nil, fmt.Errorf("commit %s: tag has no object", h)
+		}
+		th, err := parseHash(string(obj))
+		if err != nil {
+			return nil, fmt.Errorf("commit %s: invalid tag object %q", h, obj)
+		}
+		typ, data = s.object(th)
+	}
 	if typ != objCommit {
 		return nil, fmt.Errorf("commit %s: unexpected type %s", h, typ)
 	}
@@ -215,13 +288,14 @@ func (s *store) commit(h Hash) (*treeFS, error) {
 	if err != nil {
 		return nil, fmt.Errorf("commit %s: invalid tree %q", h, treeHash)
 	}
-	return &treeFS{s, h}, nil
+	return &treeFS{s: s, tree: h}, nil
 }
 
 // A treeFS is an fs.FS serving a Git file system tree rooted at a given tree object hash.
 type treeFS struct {
 	s    *store
-	tree Hash // root tree
+	tree Hash               // root tree
+	mod  map[string]*Commit // last commit to change each file; nil if no history
 }
 
 // Open opens the given file or directory, implementing the fs.FS Open method.
@@ -258,7 +332,7 @@ func (t *treeFS) Open(name string) (f fs.File, err error) {
 
 	// The hash h is the hash for name. Load its object.
 	typ, data := t.s.object(h)
-	info := fileInfo{name, name[start:], 0, 0}
+	info := fileInfo{name, name[start:], 0, 0, t.mod[name]}
 	if typ == objBlob {
 		// Regular file.
 		info.mode = 0444
@@ -268,27 +342,38 @@ func (t *treeFS) Open(name string) (f fs.File, err error) {
 	if typ == objTree {
 		// Directory.
 		info.mode = fs.ModeDir | 0555
-		return &dirFile{t.s, info, data, 0}, nil
+		return &dirFile{t.s, t.mod, info, data, 0}, nil
 	}
 	return nil, &fs.PathError{Path: name, Op: "open", Err: fmt.Errorf("unexpected git object type %s", typ)}
 }
 
 // fileInfo implements fs.FileInfo.
 type fileInfo struct {
-	path string
-	name string
-	mode fs.FileMode
-	size int64
+	path   string
+	name   string
+	mode   fs.FileMode
+	size   int64
+	commit *Commit // last commit to change file; nil if unknown
 }
 
-func (i *fileInfo) Name() string               { return i.name }
-func (i *fileInfo) Type() fs.FileMode          { return i.mode & fs.ModeType }
-func (i *fileInfo) Mode() fs.FileMode          { return i.mode }
-func (i *fileInfo) Sys() interface{}           { return nil }
+func (i *fileInfo) Name() string      { return i.name }
+func (i *fileInfo) Type() fs.FileMode { return i.mode & fs.ModeType }
+func (i *fileInfo) Mode() fs.FileMode { return i.mode }
+func (i *fileInfo) Sys() interface{} {
+	if i.commit == nil {
+		return nil
+	}
+	return i.commit
+}
 func (i *fileInfo) IsDir() bool                { return i.mode&fs.ModeDir != 0 }
 func (i *fileInfo) Size() int64                { return i.size }
 func (i *fileInfo) Info() (fs.FileInfo, error) { return i, nil }
-func (i *fileInfo) ModTime() time.Time         { return time.Time{} }
+func (i *fileInfo) ModTime() time.Time {
+	if i.commit == nil {
+		return time.Time{}
+	}
+	return i.commit.CommitTime
+}
 
 func (i *fileInfo) err(op string, err error) error {
 	return &fs.PathError{Path: i.path, Op: op, Err: err}
@@ -307,6 +392,7 @@ func (f *blobFile) Stat() (fs.FileInfo, error) { return &f.info, nil }
 // A dirFile implements fs.File for a directory.
 type dirFile struct {
 	s    *store
+	mod  map[string]*Commit
 	info fileInfo
 	data []byte
 	off  int
@@ -349,7 +435,11 @@ func (f *dirFile) ReadDir(n int) (list []fs.DirEntry, err error) {
 			infoSize = int64(len(data))
 		}
 		name := string(e.name)
-		list = append(list, &fileInfo{name, name, mode, infoSize})
+		file := name
+		if f.info.path != "." {
+			file = f.info.path + "/" + name
+		}
+		list = append(list, &fileInfo{file, name, mode, infoSize, f.mod[file]})
 	}
 	if len(list) == 0 && n > 0 {
 		return list, io.EOF
@@ -3,6 +3,7 @@
 // license that can be found in the LICENSE file.
 
 // Package gitfs presents a file tree downloaded from a remote Git repo as an in-memory fs.FS.
+// It can also read local Git repositories and bundles, for testing and offline use.
 package gitfs
 
 import (
@@ -13,20 +14,49 @@ import (
 	"io/fs"
 	"log"
 	"net/http"
+	"os"
 	pathpkg "path"
 	"strings"
+	"sync"
 
 	"golang.org/x/mod/semver"
 )
 
-// A Repo is a connection to a remote repository served over HTTP or HTTPS.
+// A Repo is a connection to a remote repository served over HTTP or HTTPS,
+// or else a local repository or bundle.
 type Repo struct {
-	url  string // trailing slash removed
-	caps map[string]string
+	url   string // trailing slash removed
+	caps  map[string]string
+	local source // local repository or bundle; nil for a remote repo
+	cache objDir // on-disk object cache; "" for none
+	depth int    // number of commits of history to load; 0 for none
+
+	mu         sync.Mutex
+	last       *store // objects from the most recent fetch
+	lastCommit Hash   // commit loaded by the most recent fetch
 }
 
 // NewRepo connects to a Git repository at the given http:// or https:// URL.
+//
+// If url is instead a local file path, optionally beginning with file://,
+// NewRepo opens the Git repository stored there, which can be a bare repository,
+// a checkout containing a .git directory, or a bundle file created by 'git bundle'.
+// A local repository directory is read as needed, so that Resolve sees new commits,
+// and its packs are rescanned when an object is missing, so that Clone sees
+// objects moved into new packs by 'git gc' or 'git repack'.
+// Other URL schemes, like ssh:// and git://, are not supported.
 func NewRepo(url string) (*Repo, error) {
+	local, err := isLocal(url)
+	if err != nil {
+		return nil, err
+	}
+	if local {
+		src, err := openLocal(url)
+		if err != nil {
+			return nil, err
+		}
+		return &Repo{url: url, local: src}, nil
+	}
 	r := &Repo{url: strings.TrimSuffix(url, "/")}
 	if err := r.handshake(); err != nil {
 		return nil, err
@@ -34,6 +64,39 @@ func NewRepo(url string) (*Repo, error) {
 	return r, nil
 }
 
+// SetCache makes r keep a copy of every object it downloads
+// in the directory dir, using Git's loose object format
+// (so that, for example, 'git cat-file' can inspect it),
+// and look for objects there before downloading them.
+// Pointing a new Repo at the cache directory of an earlier one
+// lets it load previously fetched commits without any download.
+// The cache records how much history it holds for each commit,
+// so that a Repo wanting more history (see SetHistory) downloads it.
+// It lists only the most recent commits, removing the objects
+// that only older ones need, much as 'git gc' would.
+func (r *Repo) SetCache(dir string) error {
+	if err := os.MkdirAll(dir, 0o777); err != nil {
+		return err
+	}
+	r.cache = objDir(dir)
+	return nil
+}
+
+// SetHistory makes r load up to depth commits of history
+// (following first parents) for each commit it clones,
+// so that each file in the returned fs.FS reports the commit
+// that last changed it. See Commit for details.
+// A file unchanged in the loaded history reports no commit
+// and a zero modification time, unless the history reaches
+// the repository's first commit, which then counts as changing it.
+// History missing from the cache (see SetCache) is downloaded;
+// history missing from a shallow local repository makes Clone fail.
+// The default depth is 0, meaning no history is loaded
+// and files report a zero modification time.
+func (r *Repo) SetHistory(depth int) {
+	r.depth = depth
+}
+
 // handshake runs the initial Git opening handshake, learning the capabilities of the server.
 // See https://git-scm.com/docs/protocol-v2#_initial_client_request.
 func (r *Repo) handshake() error {
@@ -163,6 +226,9 @@ func (r *Repo) Resolve(ref string) (Hash, error) {
 // The result maps a Git reference such as "refs/heads/main",
 // "refs/tags/v1.0.0", or "HEAD" to a hexadecimal commit hash.
 func (r *Repo) refs(prefixes ...string) (map[string]Hash, error) {
+	if r.local != nil {
+		return r.local.refs(prefixes...)
+	}
 	if _, ok := r.caps["ls-refs"]; !ok {
 		return nil, fmt.Errorf("refs: server does not support ls-refs")
 	}
@@ -247,6 +313,125 @@ func (r *Repo) CloneHash(h Hash) (fs.FS, error) {
 
 // fetch returns the fs.FS for a given hash.
 func (r *Repo) fetch(h Hash) (fs.FS, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// If the previous fetch, the disk cache, or the local repository
+	// has every object reachable from h, there is nothing to download.
+	// A commit cached with less history than r wants must be deepened.
+	s := &store{lookup: r.lookup}
+	err := s.fillHistory(h, r.depth)
+	if err == nil && r.local == nil && r.cache != "" && (r.last == nil || r.lastCommit != h) {
+		if d := r.cache.depth(h); d > 0 && d < r.depth {
+			err = fmt.Errorf("commit %s cached with %d commits of history, want %d", h, d, r.depth)
+		}
+	}
+	if err != nil {
+		if r.local != nil {
+			return nil, fmt.Errorf("fetch: %v", err)
+		}
+		// Download only what is missing, by telling the server
+		// which commits we already have.
+		// If that goes wrong (perhaps a cached commit is incomplete),
+		// fall back to downloading everything.
+		haves := r.haves()
+		err := r.fetchInto(s, h, haves)
+		if err != nil && len(haves) > 0 {
+			log.Printf("gitfs: incremental fetch of %s: %v; retrying full fetch", h, err)
+			err = r.fetchInto(s, h, nil)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	s.lookup = nil
+	if r.cache != "" {
+		if err := r.cache.save(s); err != nil {
+			log.Printf("gitfs: saving %s to cache: %v", h, err)
+		} else if err := r.cache.addCommit(h, max(r.depth, 1)); err != nil {
+			log.Printf("gitfs: saving %s to cache: %v", h, err)
+		}
+	}
+	r.last = s
+	r.lastCommit = h
+
+	tfs, err := s.commit(h)
+	if err != nil {
+		return nil, fmt.Errorf("fetch: %v", err)
+	}
+	if r.depth > 0 {
+		tfs.mod, err = s.history(h, r.depth)
+		if err != nil {
+			return nil, fmt.Errorf("fetch: %v", err)
+		}
+	}
+	return tfs, nil
+}
+
+// fetchInto downloads the commit h, telling the server that
+// the commits in haves are already available locally,
+// and unpacks it into s, which must end up holding every object
+// reachable from h.
+func (r *Repo) fetchInto(s *store, h Hash, haves []Hash) error {
+	data, err := r.fetchPack(h, haves)
+	if err != nil {
+		return err
+	}
+	if err := unpack(s, data); err != nil {
+		return fmt.Errorf("fetch: %v", err)
+	}
+	if err := s.fillHistory(h, r.depth); err != nil {
+		return fmt.Errorf("fetch: %v", err)
+	}
+	return nil
+}
+
+// maxHaves is the maximum number of commits haves returns.
+const maxHaves = 8
+
+// haves returns the commits whose objects are available locally,
+// most recent first.
+func (r *Repo) haves() []Hash {
+	var list []Hash
+	if r.last != nil {
+		list = append(list, r.lastCommit)
+	}
+	if r.cache != "" {
+		commits := r.cache.commits()
+		for i := len(commits) - 1; i >= 0 && len(list) < maxHaves; i-- {
+			if c := commits[i].hash; r.last == nil || c != r.lastCommit {
+				list = append(list, c)
+			}
+		}
+	}
+	return list
+}
+
+// lookup returns the type and data for the object with hash h
+// from the previous fetch, the disk cache, or the local repository.
+// If neither has the object, lookup returns 0, nil.
+func (r *Repo) lookup(h Hash) (objType, []byte) {
+	if r.last != nil {
+		if typ, data := r.last.object(h); typ != objNone {
+			return typ, data
+		}
+	}
+	if r.cache != "" {
+		if typ, data := r.cache.read(h); typ != objNone {
+			return typ, data
+		}
+	}
+	if r.local != nil {
+		return r.local.object(h)
+	}
+	return objNone, nil
+}
+
+// fetchPack downloads a packfile containing the commit h from the remote server.
+// The packfile omits objects reachable from the commits in haves,
+// and it may be thin, meaning that it encodes objects as deltas
+// against those omitted objects.
+func (r *Repo) fetchPack(h Hash, haves []Hash) ([]byte, error) {
 	// Fetch a shallow packfile from the remote server.
 	// Shallow means it only contains the tree at that one commit,
 	// not the entire history of the repo.
@@ -261,13 +446,7 @@ func (r *Repo) fetch(h Hash) (fs.FS, error) {
 
 	// Prepare and send request for pack file.
 	var buf bytes.Buffer
-	pw := newPktLineWriter(&buf)
-	pw.WriteString("command=fetch")
-	pw.Delim()
-	pw.WriteString("deepen 1")
-	pw.WriteString("want " + h.String())
-	pw.WriteString("done")
-	pw.Close()
+	writeFetchRequest(&buf, h, max(r.depth, 1), haves)
 	postbody := buf.Bytes()
 
 	req, _ := http.NewRequest("POST", r.url+"/git-upload-pack", &buf)
@@ -331,14 +510,33 @@ func (r *Repo) fetch(h Hash) (fs.FS, error) {
 		return nil, fmt.Errorf("fetch: malformed response: not packfile")
 	}
 
-	// Unpack pack file and return fs.FS for the commit we downloaded.
-	var s store
-	if err := unpack(&s, data); err != nil {
-		return nil, fmt.Errorf("fetch: %v", err)
+	return data, nil
+}
+
+// writeFetchRequest writes to w the fetch command requesting
+// a shallow pack for the commit h and depth-1 generations of its history,
+// given that the commits in haves (and their trees, but not necessarily
+// their parents) are already available.
+func writeFetchRequest(w io.Writer, h Hash, depth int, haves []Hash) {
+	pw := newPktLineWriter(w)
+	pw.WriteString("command=fetch")
+	pw.Delim()
+	if len(haves) > 0 {
+		pw.WriteString("thin-pack")
+		pw.WriteString("ofs-delta")
+	}
+	pw.WriteString(fmt.Sprintf("deepen %d", depth))
+	// Each commit we have is the edge of a shallow history:
+	// without these lines, the server would assume we also
+	// have their parents, and it might omit objects from the pack
+	// that only the parents (and h) refer to.
+	for _, c := range haves {
+		pw.WriteString("shallow " + c.String())
 	}
-	tfs, err := s.commit(h)
-	if err != nil {
-		return nil, fmt.Errorf("fetch: %v", err)
+	pw.WriteString("want " + h.String())
+	for _, c := range haves {
+		pw.WriteString("have " + c.String())
 	}
-	return tfs, nil
+	pw.WriteString("done")
+	pw.Close()
 }
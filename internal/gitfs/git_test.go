@@ -5,9 +5,17 @@
 package gitfs
 
 import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/binary"
+	"fmt"
 	"io/fs"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestGerrit(t *testing.T) {
@@ -71,3 +79,570 @@ func TestPack(t *testing.T) {
 	}
 	println(string(data))
 }
+
+func TestCache(t *testing.T) {
+	data, err := os.ReadFile("testdata/scratch.pack")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s store
+	if err := unpack(&s, data); err != nil {
+		t.Fatal(err)
+	}
+	h := Hash{0xf6, 0xf7, 0x39, 0x2a, 0x99, 0x9b, 0x3d, 0x75, 0xe2, 0x1c, 0xae, 0xe3, 0x3a, 0xeb, 0x6d, 0x01, 0x92, 0xe8, 0xdc, 0x6b}
+	tfs, err := s.commit(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := fs.ReadFile(tfs, "rsc/greeting.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	if err := objDir(dir).save(&s); err != nil {
+		t.Fatal(err)
+	}
+
+	// A Repo with no server connection must load the commit from the cache.
+	r := new(Repo)
+	if err := r.SetCache(dir); err != nil {
+		t.Fatal(err)
+	}
+	fsys, err := r.CloneHash(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	have, err := fs.ReadFile(fsys, "rsc/greeting.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(have, want) {
+		t.Errorf("rsc/greeting.go from cache:\n%s\nwant:\n%s", have, want)
+	}
+
+	// A missing object must send the Repo back to the server,
+	// which fails, since there is none.
+	typ, data := s.object(h)
+	os.Remove(objDir(dir).file(h))
+	if _, err := new(Repo).CloneHash(h); err == nil {
+		t.Errorf("CloneHash without cache succeeded")
+	}
+	r = new(Repo)
+	r.SetCache(dir)
+	if _, err := r.CloneHash(h); err == nil {
+		t.Errorf("CloneHash with incomplete cache succeeded")
+	}
+
+	// Corrupt objects must be ignored.
+	objDir(dir).write(typ, h, append(data[:len(data):len(data)], '!'))
+	if _, err := r.CloneHash(h); err == nil {
+		t.Errorf("CloneHash with corrupt cache succeeded")
+	}
+}
+
+func TestCacheDepthAndPrune(t *testing.T) {
+	const (
+		initial = "65c938eade731e6586285a4b1db7a6f331d78eac"
+		greet   = "72e4aaa45c8a97c9d77589e25516880a98d77d94"
+	)
+	dir := t.TempDir()
+	clone := func(r *Repo, ref string) error {
+		t.Helper()
+		_, err := r.CloneHash(mustParseHash(t, ref))
+		return err
+	}
+	local := func(depth int) *Repo {
+		t.Helper()
+		r, err := NewRepo("testdata/local.git")
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.SetCache(dir)
+		r.SetHistory(depth)
+		return r
+	}
+	cached := func(depth int) *Repo {
+		r := new(Repo)
+		r.SetCache(dir)
+		r.SetHistory(depth)
+		return r
+	}
+	listed := func() string {
+		var list []string
+		for _, c := range objDir(dir).commits() {
+			list = append(list, fmt.Sprintf("%.7s %d", c.hash, c.depth))
+		}
+		return strings.Join(list, ", ")
+	}
+
+	r := local(1)
+	if err := clone(r, initial); err != nil {
+		t.Fatal(err)
+	}
+	if err := clone(r, greet); err != nil {
+		t.Fatal(err)
+	}
+	if have, want := listed(), "65c938e 1, 72e4aaa 1"; have != want {
+		t.Errorf("cached commits = %s, want %s", have, want)
+	}
+	if err := clone(local(2), greet); err != nil {
+		t.Fatal(err)
+	}
+	if have, want := listed(), "65c938e 1, 72e4aaa 2"; have != want {
+		t.Errorf("cached commits after deeper clone = %s, want %s", have, want)
+	}
+
+	// The cache holds all three commits, but it only promises two commits
+	// of history for greet, so a Repo wanting three must go to the server,
+	// which fails, since there is none.
+	if err := clone(cached(2), greet); err != nil {
+		t.Errorf("clone with cached history: %v", err)
+	}
+	if err := clone(cached(3), greet); err == nil {
+		t.Errorf("clone with more history than cached succeeded")
+	}
+
+	// Pruning to greet alone removes the objects only initial needs,
+	// but not recent ones, which may be about to be recorded.
+	if err := objDir(dir).prune([]cachedCommit{{mustParseHash(t, greet), 1}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := clone(cached(1), initial); err != nil {
+		t.Errorf("clone of recent object after prune: %v", err)
+	}
+	old := time.Now().Add(-2 * pruneAge)
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			os.Chtimes(path, old, old)
+		}
+		return nil
+	})
+	if err := objDir(dir).prune([]cachedCommit{{mustParseHash(t, greet), 1}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := clone(cached(1), greet); err != nil {
+		t.Errorf("clone of kept commit after prune: %v", err)
+	}
+	if err := clone(cached(1), initial); err == nil {
+		t.Errorf("clone of pruned commit succeeded")
+	}
+}
+
+func mustParseHash(t *testing.T, text string) Hash {
+	t.Helper()
+	h, err := parseHash(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func TestFetchRequest(t *testing.T) {
+	h := Hash{1}
+	have := Hash{2}
+	var buf bytes.Buffer
+	writeFetchRequest(&buf, h, 1, []Hash{have})
+	lines, err := newPktLineReader(&buf).Lines()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"command=fetch",
+		"", // delimiter
+		"thin-pack",
+		"ofs-delta",
+		"deepen 1",
+		"shallow " + have.String(),
+		"want " + h.String(),
+		"have " + have.String(),
+		"done",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("fetch request:\n%s\nwant:\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestThinPack(t *testing.T) {
+	// The old store has a blob.
+	// The thin pack has a new version of the blob,
+	// encoded as a delta against the old one,
+	// which the pack does not include.
+	var old store
+	base, _ := old.add(objBlob, []byte("hello, world\n"))
+
+	var delta []byte
+	delta = binary.AppendUvarint(delta, 13)     // base size
+	delta = binary.AppendUvarint(delta, 15)     // target size
+	delta = append(delta, 0x80|0x01|0x10, 0, 7) // copy "hello, "
+	delta = append(delta, 8)                    // insert "gophers\n"
+	delta = append(delta, "gophers\n"...)
+
+	var pack bytes.Buffer
+	pack.WriteString("PACK\x00\x00\x00\x02\x00\x00\x00\x01")
+	u := uint64(len(delta)&15) | uint64(objRefDelta)<<4 | uint64(len(delta)>>4)<<7
+	pack.Write(binary.AppendUvarint(nil, u))
+	pack.Write(base[:])
+	zw := zlib.NewWriter(&pack)
+	zw.Write(delta)
+	zw.Close()
+	sum := sha1.Sum(pack.Bytes())
+	pack.Write(sum[:])
+
+	var s store
+	if err := unpack(&s, pack.Bytes()); err == nil {
+		t.Fatalf("unpack succeeded without delta base")
+	}
+	s = store{lookup: old.object}
+	if err := unpack(&s, pack.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	var found bool
+	for h := range s.index {
+		if h == base {
+			continue
+		}
+		typ, data := s.object(h)
+		if typ != objBlob || string(data) != "hello, gophers\n" {
+			t.Errorf("unpacked %v %q, want blob %q", typ, data, "hello, gophers\n")
+		}
+		found = true
+	}
+	if !found {
+		t.Errorf("unpack did not store new blob")
+	}
+}
+
+func TestLocal(t *testing.T) {
+	for _, name := range []string{"testdata/local.git", "testdata/local.bundle"} {
+		t.Run(name, func(t *testing.T) {
+			r, err := NewRepo(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The tip commit's objects are loose in local.git;
+			// older ones are in a pack, some of them delta-encoded.
+			clones := []struct {
+				ref   string
+				hello string
+				lines int
+			}{
+				{"HEAD", "hello, gophers\n", 45},
+				{"refs/heads/main", "hello, gophers\n", 45},
+				{"refs/tags/v1.0.0", "hello, world\n", 45},
+				{"65c938eade731e6586285a4b1db7a6f331d78eac", "hello, world\n", 40},
+			}
+			for _, tt := range clones {
+				_, fsys, err := r.Clone(tt.ref)
+				if err != nil {
+					t.Errorf("Clone(%q): %v", tt.ref, err)
+					continue
+				}
+				data, err := fs.ReadFile(fsys, "hello.txt")
+				if err != nil || string(data) != tt.hello {
+					t.Errorf("Clone(%q): hello.txt = %q, %v, want %q", tt.ref, data, err, tt.hello)
+				}
+				data, err = fs.ReadFile(fsys, "doc/guide.md")
+				if n := bytes.Count(data, []byte("\n")); err != nil || n != tt.lines {
+					t.Errorf("Clone(%q): doc/guide.md has %d lines, %v, want %d", tt.ref, n, err, tt.lines)
+				}
+			}
+
+			h, err := r.Resolve("HEAD")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := "72e4aaa45c8a97c9d77589e25516880a98d77d94"; h.String() != want {
+				t.Errorf("Resolve(HEAD) = %v, want %v", h, want)
+			}
+			if _, err := r.Resolve("refs/heads/missing"); err == nil {
+				t.Errorf("Resolve(refs/heads/missing) succeeded")
+			}
+		})
+	}
+}
+
+func TestLocalRepack(t *testing.T) {
+	// Open a copy of local.git without its pack, as if before a repack,
+	// and then add the pack: Clone must find the objects in the new pack.
+	dir := t.TempDir()
+	if err := os.CopyFS(dir, os.DirFS("testdata/local.git")); err != nil {
+		t.Fatal(err)
+	}
+	pack := filepath.Join(dir, "objects/pack")
+	saved := t.TempDir()
+	moveAll := func(from, to string) {
+		t.Helper()
+		files, err := os.ReadDir(from)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, f := range files {
+			if err := os.Rename(filepath.Join(from, f.Name()), filepath.Join(to, f.Name())); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	moveAll(pack, saved)
+
+	r, err := NewRepo(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := r.Clone("refs/tags/v1.0.0"); err == nil {
+		t.Fatalf("Clone(v1.0.0) succeeded without its pack")
+	}
+	moveAll(saved, pack)
+	_, fsys, err := r.Clone("refs/tags/v1.0.0")
+	if err != nil {
+		t.Fatalf("Clone(v1.0.0) after adding pack: %v", err)
+	}
+	if data, err := fs.ReadFile(fsys, "hello.txt"); err != nil || string(data) != "hello, world\n" {
+		t.Errorf("hello.txt = %q, %v, want %q", data, err, "hello, world\n")
+	}
+}
+
+func TestHistoryShallowCache(t *testing.T) {
+	const (
+		extend = "80648d5fb4f174b74f8305592d837beab3320490"
+		greet  = "72e4aaa45c8a97c9d77589e25516880a98d77d94"
+	)
+	// Fill the cache with greet alone, as a clone without history would,
+	// and forget the recorded depth, as if the objects were left by another Repo.
+	dir := t.TempDir()
+	r, err := NewRepo("testdata/local.git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.SetCache(dir)
+	if _, err := r.CloneHash(mustParseHash(t, greet)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dir, "commits")); err != nil {
+		t.Fatal(err)
+	}
+
+	// The cache lacks greet's parent, so its history is incomplete.
+	s := &store{lookup: objDir(dir).read}
+	err = s.fillHistory(mustParseHash(t, greet), 3)
+	if err == nil || !strings.Contains(err.Error(), "history incomplete") || !strings.Contains(err.Error(), extend) {
+		t.Errorf("fillHistory from shallow cache: err = %v, want incomplete history missing %s", err, extend)
+	}
+
+	// So a Repo wanting history must go to the server, which fails,
+	// since there is none, instead of leaving every file unattributed.
+	cached := new(Repo)
+	cached.SetCache(dir)
+	cached.SetHistory(3)
+	if _, err := cached.CloneHash(mustParseHash(t, greet)); err == nil {
+		t.Errorf("clone with history from shallow cache succeeded")
+	}
+	cached.SetHistory(1)
+	if _, err := cached.CloneHash(mustParseHash(t, greet)); err != nil {
+		t.Errorf("clone without history from shallow cache: %v", err)
+	}
+
+	// Given the local repository as well, the Repo finds the rest of the history.
+	r, err = NewRepo("testdata/local.git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.SetCache(dir)
+	r.SetHistory(3)
+	fsys, err := r.CloneHash(mustParseHash(t, greet))
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := fs.Stat(fsys, "doc/guide.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, _ := info.Sys().(*Commit); c == nil || c.Hash.String() != extend {
+		t.Errorf("doc/guide.md commit = %v, want %s", info.Sys(), extend)
+	}
+}
+
+func TestPackDeltaChain(t *testing.T) {
+	// Write a pack holding a blob followed by a long chain of deltas,
+	// each appending a line to the object before it.
+	const n = 200
+	var pack bytes.Buffer
+	pack.WriteString("PACK\x00\x00\x00\x02")
+	binary.Write(&pack, binary.BigEndian, uint32(n+1))
+	record := func(typ objType, extra, data []byte) {
+		u := uint64(len(data)&15) | uint64(typ)<<4 | uint64(len(data)>>4)<<7
+		pack.Write(binary.AppendUvarint(nil, u))
+		pack.Write(extra)
+		zw := zlib.NewWriter(&pack)
+		zw.Write(data)
+		zw.Close()
+	}
+	offs := []int64{int64(pack.Len())}
+	want := "line 0\n"
+	record(objBlob, nil, []byte(want))
+	for i := 1; i <= n; i++ {
+		line := fmt.Sprintf("line %d\n", i)
+		var delta []byte
+		delta = binary.AppendUvarint(delta, uint64(len(want)))
+		delta = binary.AppendUvarint(delta, uint64(len(want)+len(line)))
+		delta = append(delta, 0x80|0x10|0x20, byte(len(want)), byte(len(want)>>8)) // copy base
+		delta = append(delta, byte(len(line)))                                     // insert line
+		delta = append(delta, line...)
+		want += line
+
+		// Offset of base, encoded as in parseDeltaOffset.
+		d := int64(pack.Len()) - offs[len(offs)-1]
+		enc := []byte{byte(d & 0x7f)}
+		for d >>= 7; d > 0; d >>= 7 {
+			d--
+			enc = append([]byte{0x80 | byte(d&0x7f)}, enc...)
+		}
+		offs = append(offs, int64(pack.Len()))
+		record(objOfsDelta, enc, delta)
+	}
+
+	file := filepath.Join(t.TempDir(), "chain.pack")
+	if err := os.WriteFile(file, pack.Bytes(), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	p := &packFile{f: f}
+	typ, data, err := p.read(offs[n], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if typ != objBlob || string(data) != want {
+		t.Fatalf("read end of chain = %v, %d bytes, want blob, %d bytes", typ, len(data), len(want))
+	}
+
+	// Resolving the end of the chain cached every object in it,
+	// so the others are read without resolving any deltas,
+	// even after the pack data is gone.
+	if p.bases.lru.Len() != n+1 {
+		t.Errorf("cached %d objects, want %d", p.bases.lru.Len(), n+1)
+	}
+	f.Close()
+	typ, data, err = p.read(offs[n/2], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if typ != objBlob || !strings.HasPrefix(want, string(data)) || !strings.HasSuffix(string(data), fmt.Sprintf("line %d\n", n/2)) {
+		t.Errorf("read middle of chain = %v, %q", typ, data)
+	}
+}
+
+func TestIsLocal(t *testing.T) {
+	tests := []struct {
+		name  string
+		local bool
+		err   bool
+	}{
+		{"https://go.googlesource.com/go", false, false},
+		{"http://localhost:8080/repo", false, false},
+		{"file:///tmp/repo.git", true, false},
+		{"/tmp/repo.git", true, false},
+		{"testdata/local.bundle", true, false},
+		{`C:\repo`, true, false},
+		{"ssh://git@github.com/golang/go", false, true},
+		{"git://example.com/repo", false, true},
+		{"git@github.com:golang/go.git", false, true},
+	}
+	for _, tt := range tests {
+		local, err := isLocal(tt.name)
+		if local != tt.local || (err != nil) != tt.err {
+			t.Errorf("isLocal(%q) = %v, %v, want %v, error %v", tt.name, local, err, tt.local, tt.err)
+		}
+	}
+	if _, err := NewRepo("ssh://git@github.com/golang/go"); err == nil {
+		t.Errorf("NewRepo(ssh://...) succeeded")
+	}
+}
+
+func TestHistory(t *testing.T) {
+	const (
+		initial = "65c938eade731e6586285a4b1db7a6f331d78eac"
+		extend  = "80648d5fb4f174b74f8305592d837beab3320490"
+		greet   = "72e4aaa45c8a97c9d77589e25516880a98d77d94"
+	)
+	tests := []struct {
+		depth int
+		want  map[string]string // file -> commit; "" for unknown
+	}{
+		{1, map[string]string{".": "", "hello.txt": "", "doc": "", "doc/guide.md": ""}},
+		{2, map[string]string{".": greet, "hello.txt": greet, "doc": "", "doc/guide.md": ""}},
+		{10, map[string]string{".": greet, "hello.txt": greet, "doc": extend, "doc/guide.md": extend}},
+	}
+	for _, tt := range tests {
+		r, err := NewRepo("testdata/local.git")
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.SetHistory(tt.depth)
+		_, fsys, err := r.Clone("HEAD")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for file, want := range tt.want {
+			info, err := fs.Stat(fsys, file)
+			if err != nil {
+				t.Errorf("depth %d: %v", tt.depth, err)
+				continue
+			}
+			if want == "" {
+				if info.Sys() != nil || !info.ModTime().IsZero() {
+					t.Errorf("depth %d: Stat(%s) = %v, %v, want nil, zero time", tt.depth, file, info.Sys(), info.ModTime())
+				}
+				continue
+			}
+			c, ok := info.Sys().(*Commit)
+			if !ok {
+				t.Errorf("depth %d: Stat(%s).Sys() = %T, want *Commit", tt.depth, file, info.Sys())
+				continue
+			}
+			if c.Hash.String() != want {
+				t.Errorf("depth %d: Stat(%s) commit = %s %q, want %s", tt.depth, file, c.Hash, c.Subject, want)
+			}
+			if !info.ModTime().Equal(c.CommitTime) {
+				t.Errorf("depth %d: Stat(%s).ModTime() = %v, want %v", tt.depth, file, info.ModTime(), c.CommitTime)
+			}
+		}
+	}
+
+	// Check commit details and directory listings.
+	r, err := NewRepo("testdata/local.git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.SetHistory(10)
+	_, fsys, err := r.Clone("HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := fs.ReadDir(fsys, "doc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := list[0].Info()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, _ := info.Sys().(*Commit)
+	if c == nil || c.Hash.String() != extend {
+		t.Fatalf("ReadDir(doc)[0].Sys() = %v, want commit %s", info.Sys(), extend)
+	}
+	want := &Commit{
+		Author:     "Gopher",
+		Email:      "gopher@golang.org",
+		Time:       time.Date(2026, 2, 3, 10, 0, 0, 0, time.UTC),
+		CommitTime: time.Date(2026, 2, 3, 10, 0, 0, 0, time.UTC),
+		Subject:    "extend guide",
+	}
+	if c.Author != want.Author || c.Email != want.Email || !c.Time.Equal(want.Time) || !c.CommitTime.Equal(want.CommitTime) || c.Subject != want.Subject {
+		t.Errorf("commit = %+v, want %+v", c, want)
+	}
+}
@@ -0,0 +1,210 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gitfs
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// A Commit describes a Git commit.
+//
+// In a file system returned by a Repo with history enabled (see Repo.SetHistory),
+// the Sys method of each file's fs.FileInfo returns the *Commit that last changed the file,
+// and the ModTime method returns that commit's CommitTime.
+// For a file not changed in the commits loaded, Sys returns nil
+// and ModTime returns the zero time, since the commit that last changed it is unknown.
+type Commit struct {
+	Hash       Hash
+	Author     string    // author name
+	Email      string    // author email address
+	Time       time.Time // author time
+	CommitTime time.Time // committer time
+	Subject    string    // first line of commit message
+}
+
+// parseCommit parses the commit object data for the commit with hash h.
+// It returns the commit description, the commit's tree, and its parents.
+func parseCommit(h Hash, data []byte) (c *Commit, tree Hash, parents []Hash, err error) {
+	c = &Commit{Hash: h}
+	hdr, msg, _ := bytes.Cut(data, []byte("\n\n"))
+	for _, line := range bytes.Split(hdr, []byte("\n")) {
+		key, val, _ := bytes.Cut(line, []byte(" "))
+		switch string(key) {
+		case "tree":
+			tree, err = parseHash(string(val))
+		case "parent":
+			var p Hash
+			p, err = parseHash(string(val))
+			parents = append(parents, p)
+		case "author":
+			c.Author, c.Email, c.Time, err = parseIdent(val)
+		case "committer":
+			_, _, c.CommitTime, err = parseIdent(val)
+		}
+		if err != nil {
+			return nil, Hash{}, nil, fmt.Errorf("commit %s: malformed %s line: %q", h, key, line)
+		}
+	}
+	subject, _, _ := bytes.Cut(msg, []byte("\n"))
+	c.Subject = string(subject)
+	return c, tree, parents, nil
+}
+
+// parseIdent parses a commit identity line like
+// "Gopher <gopher@golang.org> 1257894000 -0500",
+// returning the name, email address, and time.
+func parseIdent(line []byte) (name, email string, t time.Time, err error) {
+	i := bytes.IndexByte(line, '<')
+	j := bytes.LastIndexByte(line, '>')
+	if i < 0 || j < i {
+		return "", "", time.Time{}, fmt.Errorf("malformed identity")
+	}
+	name = string(bytes.TrimSpace(line[:i]))
+	email = string(line[i+1 : j])
+	sec, zone, _ := bytes.Cut(bytes.TrimSpace(line[j+1:]), []byte(" "))
+	unix, err := strconv.ParseInt(string(sec), 10, 64)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+	t = time.Unix(unix, 0)
+	if z, err := strconv.Atoi(string(zone)); err == nil && len(zone) == 5 {
+		// Zone is ±hhmm.
+		off := (z/100*60 + z%100) * 60
+		t = t.In(time.FixedZone(string(zone), off))
+	}
+	return name, email, t, nil
+}
+
+// fillHistory makes sure that s holds the commit with hash h
+// and up to depth-1 of its ancestors, following first parents,
+// along with all the trees and blobs reachable from them.
+// The history can end early only at a root commit:
+// if an ancestor is unavailable, as in a shallow clone or cache,
+// fillHistory returns an error, so that the caller can fetch
+// the missing history instead of reporting partial history as complete.
+func (s *store) fillHistory(h Hash, depth int) error {
+	if err := s.fill(h); err != nil {
+		return err
+	}
+	c := h
+	for i := 1; i < depth; i++ {
+		typ, data := s.object(c)
+		if typ != objCommit {
+			break // annotated tag or the like; no history
+		}
+		_, _, parents, err := parseCommit(c, data)
+		if err != nil {
+			return err
+		}
+		if len(parents) == 0 {
+			break
+		}
+		if err := s.fill(parents[0]); err != nil {
+			return fmt.Errorf("commit %s: history incomplete after %d commits: %v", h, i, err)
+		}
+		c = parents[0]
+	}
+	return nil
+}
+
+// history returns a map from the name of each file and directory
+// in the tree of commit h (including "." for the root) to the
+// commit that last changed it.
+// history considers at most depth commits, following first parents
+// from h, which fillHistory must have loaded into s.
+// Files not changed in the commits considered map to nil (unknown),
+// unless the oldest commit considered is a root commit, which created them.
+func (s *store) history(h Hash, depth int) (map[string]*Commit, error) {
+	typ, data := s.object(h)
+	if typ != objCommit {
+		return nil, fmt.Errorf("commit %s: not found", h)
+	}
+	c, tree, parents, err := parseCommit(h, data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Start with every name in the tree, unattributed.
+	mod := make(map[string]*Commit)
+	mod["."] = nil
+	s.diffTrees("", tree, Hash{}, func(name string) { mod[name] = nil })
+	left := len(mod)
+
+	for i := 1; i < depth && len(parents) > 0 && left > 0; i++ {
+		typ, data := s.object(parents[0])
+		if typ != objCommit {
+			break
+		}
+		pc, ptree, pparents, err := parseCommit(parents[0], data)
+		if err != nil {
+			break
+		}
+		if ptree != tree {
+			attrib := func(name string) {
+				if old, ok := mod[name]; ok && old == nil {
+					mod[name] = c
+					left--
+				}
+			}
+			attrib(".")
+			s.diffTrees("", tree, ptree, attrib)
+		}
+		c, tree, parents = pc, ptree, pparents
+	}
+	if len(parents) == 0 {
+		// c is the root commit, so it added every file still unattributed.
+		for name, old := range mod {
+			if old == nil {
+				mod[name] = c
+			}
+		}
+	}
+	return mod, nil
+}
+
+// diffTrees calls f for the name of each file and directory in the tree
+// with hash a whose content differs from the one with the same name
+// in tree b. The name of each file is prefix followed by its path in a.
+// If b is the zero Hash, or b is missing from s, diffTrees calls f for
+// every file and directory in a.
+func (s *store) diffTrees(prefix string, a, b Hash, f func(name string)) {
+	_, adata := s.object(a)
+	var bdata []byte
+	if b != (Hash{}) {
+		_, bdata = s.object(b)
+	}
+	old := make(map[string]dirEntry)
+	for len(bdata) > 0 {
+		e, size := parseDirEntry(bdata)
+		if size == 0 {
+			break
+		}
+		bdata = bdata[size:]
+		old[string(e.name)] = e
+	}
+	for len(adata) > 0 {
+		e, size := parseDirEntry(adata)
+		if size == 0 {
+			break
+		}
+		adata = adata[size:]
+		o, ok := old[string(e.name)]
+		if ok && o.hash == e.hash && o.mode == e.mode {
+			continue
+		}
+		name := prefix + string(e.name)
+		f(name)
+		if e.mode&0o170000 == 0o40000 {
+			var ob Hash
+			if ok && o.mode&0o170000 == 0o40000 {
+				ob = o.hash
+			}
+			s.diffTrees(name+"/", e.hash, ob, f)
+		}
+	}
+}
@@ -0,0 +1,608 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gitfs
+
+import (
+	"bytes"
+	"compress/zlib"
+	"container/list"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// A source is a local supply of Git refs and objects,
+// used in place of a remote server.
+type source interface {
+	// refs returns the refs with the given prefixes,
+	// as in Repo.refs.
+	refs(prefixes ...string) (map[string]Hash, error)
+
+	// object returns the type and data for the object with hash h,
+	// or 0, nil if there is no such object.
+	object(h Hash) (objType, []byte)
+}
+
+// isLocal reports whether the repository name refers to
+// a local directory or file instead of an HTTP server.
+// It returns an error for a URL with any other scheme, like ssh:// or git://,
+// or in the scp-like form user@host:path, which gitfs cannot fetch.
+func isLocal(name string) (bool, error) {
+	if strings.HasPrefix(name, "http://") || strings.HasPrefix(name, "https://") {
+		return false, nil
+	}
+	if strings.HasPrefix(name, "file://") {
+		return true, nil
+	}
+	if scheme, _, ok := strings.Cut(name, "://"); ok && isScheme(scheme) {
+		return false, fmt.Errorf("%s: unsupported URL scheme %s://", name, scheme)
+	}
+	// A colon before the first slash means host:path, as in git@github.com:golang/go,
+	// except for a Windows drive letter like C:.
+	if i := strings.IndexByte(name, ':'); i > 1 && !strings.Contains(name[:i], "/") {
+		return false, fmt.Errorf("%s: unsupported remote repository; use an http:// or https:// URL", name)
+	}
+	return true, nil
+}
+
+// isScheme reports whether s is a valid URL scheme (see RFC 3986).
+func isScheme(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z':
+		case i > 0 && ('0' <= c && c <= '9' || c == '+' || c == '-' || c == '.'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// openLocal opens the local repository or bundle with the given name,
+// which is a file path, optionally preceded by file://.
+func openLocal(name string) (source, error) {
+	file := strings.TrimPrefix(name, "file://")
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return openBundle(file)
+	}
+	if _, err := os.Stat(filepath.Join(file, ".git")); err == nil {
+		file = filepath.Join(file, ".git")
+	}
+	return openRepoDir(file)
+}
+
+// A repoDir is a Git repository directory,
+// either a bare repository or the .git directory of a checkout.
+// It reads refs and objects directly from the file system,
+// so that it sees commits made after it was opened.
+type repoDir struct {
+	dir   string
+	loose objDir
+
+	mu    sync.Mutex
+	packs map[string]*packFile // keyed by base name, like "pack-1234...abcd"
+}
+
+// openRepoDir opens the Git repository directory dir.
+func openRepoDir(dir string) (*repoDir, error) {
+	if _, err := os.Stat(filepath.Join(dir, "HEAD")); err != nil {
+		return nil, fmt.Errorf("%s: not a git repository", dir)
+	}
+	r := &repoDir{dir: dir, loose: objDir(filepath.Join(dir, "objects"))}
+	if _, err := r.scanPacks(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// scanPacks updates r.packs to match the packs in the objects/pack directory,
+// which change when 'git gc' or 'git repack' runs:
+// it opens packs added since the last scan and closes packs since removed.
+// It reports whether it opened any packs.
+func (r *repoDir) scanPacks() (added bool, err error) {
+	idxs, err := filepath.Glob(filepath.Join(r.dir, "objects/pack/*.idx"))
+	if err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old := r.packs
+	r.packs = make(map[string]*packFile)
+	for _, idx := range idxs {
+		base := strings.TrimSuffix(filepath.Base(idx), ".idx")
+		if p := old[base]; p != nil {
+			r.packs[base] = p
+			delete(old, base)
+			continue
+		}
+		p, err := openPackFile(strings.TrimSuffix(idx, ".idx"))
+		if err != nil {
+			// The pack may be half-written or removed by a concurrent repack.
+			// Skip it; the next scan will try again.
+			continue
+		}
+		r.packs[base] = p
+		added = true
+	}
+	for _, p := range old {
+		p.f.Close()
+	}
+	return added, nil
+}
+
+// object returns the object with hash h.
+// If h is not found, object rescans the pack directory,
+// in case a repack has moved h into a new pack, and tries again.
+func (r *repoDir) object(h Hash) (objType, []byte) {
+	if typ, data := r.findObject(h); typ != objNone {
+		return typ, data
+	}
+	if added, err := r.scanPacks(); err != nil || !added {
+		return objNone, nil
+	}
+	return r.findObject(h)
+}
+
+// findObject returns the object with hash h from the loose objects
+// or the packs found by the last scan.
+func (r *repoDir) findObject(h Hash) (objType, []byte) {
+	if typ, data := r.loose.read(h); typ != objNone {
+		return typ, data
+	}
+	r.mu.Lock()
+	packs := make([]*packFile, 0, len(r.packs))
+	for _, p := range r.packs {
+		packs = append(packs, p)
+	}
+	r.mu.Unlock()
+	for _, p := range packs {
+		if off, ok := p.find(h); ok {
+			typ, data, err := p.read(off, r.object)
+			if err != nil {
+				return objNone, nil
+			}
+			return typ, data
+		}
+	}
+	return objNone, nil
+}
+
+func (r *repoDir) refs(prefixes ...string) (map[string]Hash, error) {
+	// Refs are stored in the packed-refs file
+	// and in individual files in the refs directory,
+	// which take precedence.
+	all := make(map[string]string)
+	data, err := os.ReadFile(filepath.Join(r.dir, "packed-refs"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		// Skip comments and ^peeled lines.
+		if line == "" || line[0] == '#' || line[0] == '^' {
+			continue
+		}
+		hash, name, ok := strings.Cut(line, " ")
+		if !ok {
+			return nil, fmt.Errorf("malformed packed-refs line: %q", line)
+		}
+		all[name] = hash
+	}
+	err = fs.WalkDir(os.DirFS(r.dir), "refs", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(filepath.Join(r.dir, name))
+		if err != nil {
+			return err
+		}
+		all[name] = strings.TrimSpace(string(data))
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	data, err = os.ReadFile(filepath.Join(r.dir, "HEAD"))
+	if err != nil {
+		return nil, err
+	}
+	all["HEAD"] = strings.TrimSpace(string(data))
+
+	refs := make(map[string]Hash)
+	for name := range all {
+		if !hasAnyPrefix(name, prefixes) {
+			continue
+		}
+		// Follow symbolic refs like "ref: refs/heads/main".
+		val := all[name]
+		for i := 0; i < 10 && strings.HasPrefix(val, "ref: "); i++ {
+			val = all[strings.TrimPrefix(val, "ref: ")]
+		}
+		if val == "" {
+			continue // symbolic ref to nonexistent branch
+		}
+		h, err := parseHash(val)
+		if err != nil {
+			return nil, fmt.Errorf("malformed ref %s: %q", name, val)
+		}
+		refs[name] = h
+	}
+	return refs, nil
+}
+
+// hasAnyPrefix reports whether name has any of the prefixes.
+func hasAnyPrefix(name string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(name, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// A packFile is a Git packfile with an index,
+// as found in a repository's objects/pack directory.
+// The index is held in memory, but the pack data is read
+// from the file as needed.
+// See https://git-scm.com/docs/pack-format.
+type packFile struct {
+	f     *os.File
+	names []byte    // sorted object hashes, 20 bytes each
+	offs  []int64   // offs[i] is file offset of object names[20*i:]
+	bases baseCache // recently read objects
+}
+
+// openPackFile opens the packfile base.pack and its index base.idx.
+func openPackFile(base string) (*packFile, error) {
+	idx, err := os.ReadFile(base + ".idx")
+	if err != nil {
+		return nil, err
+	}
+	p, err := parseIndex(idx)
+	if err != nil {
+		return nil, fmt.Errorf("%s.idx: %v", base, err)
+	}
+	p.f, err = os.Open(base + ".pack")
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// parseIndex parses the pack index data,
+// returning a packFile with names and offs filled in.
+func parseIndex(data []byte) (*packFile, error) {
+	// Both index versions start with a fan-out table of 256 counts,
+	// but version 2 and later start with a magic number and version.
+	// The last count is the number of objects.
+	vers := 1
+	if bytes.HasPrefix(data, []byte("\xfftOc")) {
+		if len(data) < 8 || binary.BigEndian.Uint32(data[4:]) != 2 {
+			return nil, fmt.Errorf("unsupported index version")
+		}
+		vers = 2
+		data = data[8:]
+	}
+	if len(data) < 256*4 {
+		return nil, fmt.Errorf("malformed index")
+	}
+	n := int(binary.BigEndian.Uint32(data[255*4:]))
+	data = data[256*4:]
+
+	p := &packFile{offs: make([]int64, n)}
+	if vers == 1 {
+		// Each entry is a 4-byte offset and a 20-byte hash.
+		if len(data) < n*24 {
+			return nil, fmt.Errorf("malformed index")
+		}
+		p.names = make([]byte, 0, n*20)
+		for i := 0; i < n; i++ {
+			e := data[i*24:]
+			p.offs[i] = int64(binary.BigEndian.Uint32(e))
+			p.names = append(p.names, e[4:24]...)
+		}
+		return p, nil
+	}
+
+	// Version 2 has tables of n hashes, n CRCs, and n 4-byte offsets,
+	// followed by 8-byte offsets for any that do not fit in 31 bits.
+	if len(data) < n*28 {
+		return nil, fmt.Errorf("malformed index")
+	}
+	p.names = data[:n*20]
+	offs := data[n*24:]
+	large := data[n*28:]
+	for i := 0; i < n; i++ {
+		off := binary.BigEndian.Uint32(offs[i*4:])
+		if off&0x80000000 != 0 {
+			j := int(off&0x7fffffff) * 8
+			if len(large)-j < 8 {
+				return nil, fmt.Errorf("malformed index")
+			}
+			p.offs[i] = int64(binary.BigEndian.Uint64(large[j:]))
+			continue
+		}
+		p.offs[i] = int64(off)
+	}
+	return p, nil
+}
+
+// find returns the offset in the pack of the object with hash h.
+func (p *packFile) find(h Hash) (int64, bool) {
+	n := len(p.offs)
+	i := sort.Search(n, func(i int) bool {
+		return bytes.Compare(p.names[i*20:i*20+20], h[:]) >= 0
+	})
+	if i < n && bytes.Equal(p.names[i*20:i*20+20], h[:]) {
+		return p.offs[i], true
+	}
+	return 0, false
+}
+
+// A packEntry describes the object record at some offset in a pack.
+type packEntry struct {
+	typ      objType // record type, possibly objOfsDelta or objRefDelta
+	dataOff  int64   // offset of the compressed data
+	dataSize int64   // size of the uncompressed data
+	baseOff  int64   // offset of the delta base, for objOfsDelta
+	baseRef  Hash    // hash of the delta base, for objRefDelta
+}
+
+// entry reads the header of the object record at offset off in the pack.
+// The record format is the same as in unpackObject.
+func (p *packFile) entry(off int64) (*packEntry, error) {
+	var hdr [32]byte
+	n, err := p.f.ReadAt(hdr[:], off)
+	if n == 0 {
+		return nil, err
+	}
+	u, size := binary.Uvarint(hdr[:n])
+	if size <= 0 {
+		return nil, fmt.Errorf("invalid object: bad varint header")
+	}
+	e := &packEntry{
+		typ:      objType((u >> 4) & 7),
+		dataSize: int64(u&15 | u>>7<<4),
+	}
+	switch e.typ {
+	case objRefDelta:
+		if n-size < 20 {
+			return nil, fmt.Errorf("invalid object: bad delta ref")
+		}
+		copy(e.baseRef[:], hdr[size:])
+		size += 20
+	case objOfsDelta:
+		d, dsize, ok := parseDeltaOffset(hdr[size:n])
+		if !ok || d == 0 || d > off {
+			return nil, fmt.Errorf("invalid object: bad delta offset")
+		}
+		size += dsize
+		e.baseOff = off - d
+	}
+	e.dataOff = off + int64(size)
+	return e, nil
+}
+
+// inflate reads and decompresses the data of the object record e.
+func (p *packFile) inflate(e *packEntry) ([]byte, error) {
+	zr, err := zlib.NewReader(io.NewSectionReader(p.f, e.dataOff, 1<<62))
+	if err != nil {
+		return nil, fmt.Errorf("invalid object deflate: %v", err)
+	}
+	data, err := io.ReadAll(io.LimitReader(zr, e.dataSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("invalid object: bad deflate: %v", err)
+	}
+	if int64(len(data)) != e.dataSize {
+		return nil, fmt.Errorf("invalid object: deflate size %d != %d", len(data), e.dataSize)
+	}
+	return data, nil
+}
+
+// read reads the object at offset off in the pack,
+// using lookup to find the bases of objRefDelta objects.
+//
+// An object can be a delta against a base that is itself a delta,
+// in chains that can be dozens of objects long. Like Git, read keeps
+// recently resolved objects in a cache keyed by offset (see baseCache):
+// it walks back along the chain only to the nearest cached object
+// or whole object, and then applies the deltas forward, caching the results,
+// so that reading the other objects in the chain reuses the work.
+func (p *packFile) read(off int64, lookup func(Hash) (objType, []byte)) (objType, []byte, error) {
+	type delta struct {
+		off  int64
+		data []byte
+	}
+	var chain []delta // deltas to apply, last one first
+	var typ objType
+	var data []byte
+	for {
+		if t, d, ok := p.bases.get(off); ok {
+			typ, data = t, d
+			break
+		}
+		e, err := p.entry(off)
+		if err != nil {
+			return 0, nil, err
+		}
+		d, err := p.inflate(e)
+		if err != nil {
+			return 0, nil, err
+		}
+		switch e.typ {
+		default:
+			return 0, nil, fmt.Errorf("invalid object: unknown object type")
+		case objCommit, objTree, objBlob, objTag:
+			typ, data = e.typ, d
+			p.bases.add(off, typ, data)
+		case objRefDelta:
+			chain = append(chain, delta{off, d})
+			typ, data = lookup(e.baseRef)
+			if typ == objNone {
+				return 0, nil, fmt.Errorf("invalid object: unknown delta ref %v", e.baseRef)
+			}
+		case objOfsDelta:
+			chain = append(chain, delta{off, d})
+			off = e.baseOff
+			continue
+		}
+		break
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		var err error
+		data, err = undelta(data, chain[i].data)
+		if err != nil {
+			return 0, nil, fmt.Errorf("invalid object: %v", err)
+		}
+		p.bases.add(chain[i].off, typ, data)
+	}
+	return typ, data, nil
+}
+
+// maxBaseCache is the number of bytes of objects a baseCache holds,
+// like Git's core.deltaBaseCacheLimit.
+const maxBaseCache = 16 << 20
+
+// A baseCache is a cache of the objects most recently read from a pack,
+// keyed by offset in the pack, for use as delta bases.
+// The zero value is an empty cache, ready to use.
+// It is safe for concurrent use by multiple goroutines.
+type baseCache struct {
+	mu   sync.Mutex
+	size int                     // total size of cached data
+	lru  list.List               // cached *baseObject, most recently used first
+	m    map[int64]*list.Element // offset -> element in lru
+}
+
+// A baseObject is an object in a baseCache.
+type baseObject struct {
+	off  int64
+	typ  objType
+	data []byte
+}
+
+// get returns the object cached for offset off.
+func (c *baseCache) get(off int64) (objType, []byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.m[off]
+	if !ok {
+		return 0, nil, false
+	}
+	c.lru.MoveToFront(e)
+	b := e.Value.(*baseObject)
+	return b.typ, b.data, true
+}
+
+// add caches the object at offset off, evicting the least recently
+// used objects as needed to stay within maxBaseCache bytes.
+// The cache keeps data, so the caller must not modify it.
+func (c *baseCache) add(off int64, typ objType, data []byte) {
+	if len(data) > maxBaseCache/4 {
+		return // too big to be worth evicting so much for
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.m[off]; ok {
+		return
+	}
+	if c.m == nil {
+		c.m = make(map[int64]*list.Element)
+	}
+	c.m[off] = c.lru.PushFront(&baseObject{off, typ, data})
+	c.size += len(data)
+	for c.size > maxBaseCache {
+		b := c.lru.Remove(c.lru.Back()).(*baseObject)
+		delete(c.m, b.off)
+		c.size -= len(b.data)
+	}
+}
+
+// A bundle is a file created by 'git bundle',
+// holding a list of refs and a packfile with their objects.
+// See https://git-scm.com/docs/gitformat-bundle.
+type bundle struct {
+	refMap map[string]Hash
+	store  store
+}
+
+// openBundle reads the bundle file.
+func openBundle(file string) (*bundle, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	b, err := parseBundle(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	return b, nil
+}
+
+// parseBundle parses the bundle data.
+func parseBundle(data []byte) (*bundle, error) {
+	line, data, _ := bytes.Cut(data, []byte("\n"))
+	switch string(line) {
+	default:
+		return nil, fmt.Errorf("not a git bundle")
+	case "# v2 git bundle", "# v3 git bundle":
+		// ok
+	}
+
+	b := &bundle{refMap: make(map[string]Hash)}
+	for {
+		line, data, _ = bytes.Cut(data, []byte("\n"))
+		if len(line) == 0 {
+			break
+		}
+		switch line[0] {
+		case '@':
+			// Version 3 capability.
+			if string(line) != "@object-format=sha1" && !bytes.HasPrefix(line, []byte("@filter=")) {
+				return nil, fmt.Errorf("unsupported bundle capability %q", line)
+			}
+		case '-':
+			return nil, fmt.Errorf("incremental bundles are not supported")
+		default:
+			hash, name, _ := strings.Cut(string(line), " ")
+			h, err := parseHash(hash)
+			if err != nil {
+				return nil, fmt.Errorf("malformed bundle ref: %q", line)
+			}
+			b.refMap[name] = h
+		}
+	}
+	if err := unpack(&b.store, data); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (b *bundle) object(h Hash) (objType, []byte) {
+	return b.store.object(h)
+}
+
+func (b *bundle) refs(prefixes ...string) (map[string]Hash, error) {
+	refs := make(map[string]Hash)
+	for name, h := range b.refMap {
+		if hasAnyPrefix(name, prefixes) {
+			refs[name] = h
+		}
+	}
+	return refs, nil
+}
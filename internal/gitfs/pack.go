@@ -110,20 +110,12 @@ func unpackObject(s *store, objs []byte, off int) (typ objType, h Hash, content
 		if len(objs)-i < 20 {
 			return fail(fmt.Errorf("invalid object: too short"))
 		}
-		// Base block identified by relative offset to earlier position in objs,
-		// using a varint-like but not-quite-varint encoding.
-		// Look for "offset encoding:" in https://git-scm.com/docs/pack-format.
-		d := int64(objs[i] & 0x7f)
-		for objs[i]&0x80 != 0 {
-			i++
-			if i-(off+size) > 10 {
-				return fail(fmt.Errorf("invalid object: malformed delta offset"))
-			}
-			d = d<<7 | int64(objs[i]&0x7f)
-			d += 1 << 7
+		// Base block identified by relative offset to earlier position in objs.
+		d, n, ok := parseDeltaOffset(objs[i:])
+		if !ok {
+			return fail(fmt.Errorf("invalid object: malformed delta offset"))
 		}
-		i++
-		size = i - off
+		size += n
 
 		// Re-unpack the object at the earlier offset to find its type and content.
 		if d == 0 || d > int64(off) {
@@ -162,27 +154,64 @@ func unpackObject(s *store, objs []byte, off int) (typ objType, h Hash, content
 		// Actual object type is the type of the base object.
 		typ = deltaTyp
 
-		// Delta encoding starts with size of base object and size of new object.
-		baseSize, s := binary.Uvarint(data)
-		data = data[s:]
-		if baseSize != uint64(len(deltaBase)) {
-			return fail(fmt.Errorf("invalid object: mismatched delta src size"))
-		}
-		targSize, s := binary.Uvarint(data)
-		data = data[s:]
-
-		// Apply delta to base object, producing new object.
-		targ := make([]byte, targSize)
-		if err := applyDelta(targ, deltaBase, data); err != nil {
+		data, err = undelta(deltaBase, data)
+		if err != nil {
 			return fail(fmt.Errorf("invalid object: %v", err))
 		}
-		data = targ
 	}
 
 	h, data = s.add(typ, data)
 	return typ, h, data, encSize, nil
 }
 
+// parseDeltaOffset parses the offset at the start of b that identifies
+// the base of an objOfsDelta object, returning the offset (backward from
+// the start of the object) and the number of bytes it occupied.
+func parseDeltaOffset(b []byte) (d int64, size int, ok bool) {
+	// The offset uses a varint-like but not-quite-varint encoding.
+	// Look for "offset encoding:" in https://git-scm.com/docs/pack-format.
+	if len(b) == 0 {
+		return 0, 0, false
+	}
+	i := 0
+	d = int64(b[i] & 0x7f)
+	for b[i]&0x80 != 0 {
+		i++
+		if i > 10 || i >= len(b) {
+			return 0, 0, false
+		}
+		d = d<<7 | int64(b[i]&0x7f)
+		d += 1 << 7
+	}
+	return d, i + 1, true
+}
+
+// undelta applies the delta-encoded data to the base object,
+// returning the new object.
+func undelta(base, data []byte) ([]byte, error) {
+	// Delta encoding starts with size of base object and size of new object.
+	baseSize, s := binary.Uvarint(data)
+	if s <= 0 {
+		return nil, fmt.Errorf("malformed delta")
+	}
+	data = data[s:]
+	if baseSize != uint64(len(base)) {
+		return nil, fmt.Errorf("mismatched delta src size")
+	}
+	targSize, s := binary.Uvarint(data)
+	if s <= 0 || targSize > 1<<32 {
+		return nil, fmt.Errorf("malformed delta")
+	}
+	data = data[s:]
+
+	// Apply delta to base object, producing new object.
+	targ := make([]byte, targSize)
+	if err := applyDelta(targ, base, data); err != nil {
+		return nil, err
+	}
+	return targ, nil
+}
+
 // applyDelta applies the delta encoding to src, producing dst,
 // which has already been allocated to the expected final size.
 // See https://git-scm.com/docs/pack-format#_deltified_representation for docs.
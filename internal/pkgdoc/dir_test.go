@@ -8,6 +8,7 @@ import (
 	"go/token"
 	"os"
 	"runtime"
+	"slices"
 	"sort"
 	"testing"
 	"testing/fstest"
@@ -62,3 +63,18 @@ func BenchmarkNewDirectory(b *testing.B) {
 		newDir(fs, token.NewFileSet(), "src")
 	}
 }
+
+func TestURLs(t *testing.T) {
+	fsys := fstest.MapFS{
+		"src/fmt/print.go":               {Data: []byte("package fmt\n")},
+		"src/net/http/server.go":         {Data: []byte("package http\n")},
+		"src/net/internal/socktest/x.go": {Data: []byte("package socktest\n")},
+		"src/cmd/go/main.go":             {Data: []byte("package main\n")},
+		"src/fmt/testdata/x.go":          {Data: []byte("package x\n")},
+	}
+	got := URLs(fsys)
+	want := []string{"/pkg/", "/cmd/", "/cmd/go/", "/pkg/fmt/", "/pkg/net/", "/pkg/net/http/"}
+	if !slices.Equal(got, want) {
+		t.Errorf("URLs() = %q, want %q", got, want)
+	}
+}
@@ -25,6 +25,8 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"unicode"
 	"unicode/utf8"
 
@@ -38,6 +40,16 @@ type docs struct {
 	site     *web.Site
 	root     *Dir
 	forceOld func(*http.Request) bool
+	symbols  *SymbolIndex // index for /pkg/?q=; nil if none; see SetSymbolIndex
+
+	mod      *Module            // module being documented; nil for GOROOT
+	mods     map[string][]*docs // docs for each module version, keyed by module path, default version first
+	rootOnce sync.Once          // for building root of a module
+
+	releasesOnce sync.Once    // for building releaseList
+	releaseList  []APIRelease // API features in api, by release
+
+	ports atomic.Pointer[portCache] // data for comparePorts; nil until needed or after Invalidate
 }
 
 // NewServer returns an HTTP handler serving package docs
@@ -46,7 +58,39 @@ type docs struct {
 // If forceOld is not nil and returns true for a given request,
 // NewServer will serve docs itself instead of redirecting to pkg.go.dev
 // (forcing the ?m=old behavior).
-func NewServer(fsys fs.FS, site *web.Site, forceOld func(*http.Request) bool) (http.Handler, error) {
+//
+// See SetSymbolIndex for serving a search of the package symbols.
+//
+// The handler also serves docs for the packages in mods,
+// at /pkg/path@version/dir/ for each module version, and at /pkg/path/dir/
+// for the module's default version: the unversioned tree, if any,
+// or else the latest semantic version.
+// It serves the Go source files in a module version as well,
+// at /pkg/path@version/dir/file.go.
+// The module docs are always served directly, never redirecting to pkg.go.dev.
+//
+// With a ?json URL parameter, the handler serves the docs for a package
+// as JSON instead of HTML, never redirecting to pkg.go.dev.
+// The JSON gives the package's declarations, examples, and BUG notes,
+// each with its doc comment and source position, and for GOROOT, the Go version
+// that added each type, function, and method, if after Go 1.
+// Adding a ?sym=Name or ?sym=Type.Method parameter narrows the JSON to that declaration.
+//
+// Package docs show a single port, set by the ?GOOS= and ?GOARCH= parameters
+// and defaulting to the server's. With ?m=ports, the docs also build the package
+// for each first-class port and mark the symbols declared on only some of them.
+// With ?diff=a,b, as in ?diff=linux/amd64,windows/amd64, the docs list the symbols
+// declared on only one of the two ports, which may be any port listed by
+// “go tool dist list” for the GOROOT in fsys.
+//
+// The handler also serves the history of the API in fsys's api/go*.txt
+// and api/next/*.txt files: with ?m=history, the docs for a standard library package
+// list the API added to it in each release after Go 1, and the pages under /doc/api/
+// list the API added to all packages, in one release (/doc/api/go1.N)
+// or in every release after a given one (/doc/api/?since=go1.N),
+// using the “api” layout. The /doc/api/ pages also serve JSON, with ?json.
+// To serve them, register the handler for /doc/api/ as well as /pkg/ and /cmd/.
+func NewServer(fsys fs.FS, site *web.Site, forceOld func(*http.Request) bool, mods ...Module) (http.Handler, error) {
 	apiDB, err := api.Load(fsys)
 	if err != nil {
 		return nil, err
@@ -66,6 +110,9 @@ func NewServer(fsys fs.FS, site *web.Site, forceOld func(*http.Request) bool) (h
 		root:     root,
 		forceOld: forceOld,
 	}
+	if err := docs.addModules(mods); err != nil {
+		return nil, err
+	}
 	return docs, nil
 }
 
@@ -87,6 +134,20 @@ type Page struct {
 	IsMain     bool           // true for package main
 	IsFiltered bool           // true if results were filtered
 
+	// module info
+	Module   *Module       // module holding the package; nil for GOROOT
+	Versions []VersionLink // links to the package in each version of Module
+	explicit bool          // URL names the module version explicitly
+
+	// port info, for ?m=ports and ?diff=a,b
+	Ports    []string            // first-class ports compared, like "linux/amd64"
+	PortRows []PortRow           // symbols declared on only some of Ports
+	Diff     *PortDiff           // differences between two ports
+	portsOf  map[string][]string // ports declaring each symbol in PortRows
+
+	// API history, for ?m=history
+	History []APIRelease // API added to the package in each release after Go 1, newest first
+
 	// directory info
 	Dirs    []DirEntry // nil if no directory information
 	DirFlat bool       // if set, show directory in a flat (non-indented) manner
@@ -99,6 +160,8 @@ const (
 	modeFlat                     // show directory in a flat (non-indented) manner
 	modeMethods                  // show all embedded methods
 	modeOld                      // do not redirect to pkg.go.dev
+	modePorts                    // compare symbols across first-class ports
+	modeHistory                  // list the API added in each release
 	modeBuiltin                  // don't associate consts, vars, and factory functions with types (not exposed via ?m= query parameter, used for package builtin, see issue 6645)
 )
 
@@ -109,6 +172,8 @@ var modeNames = []string{
 	"flat",
 	"methods",
 	"old",
+	"ports",
+	"history",
 }
 
 // generate a query string for persisting the mode m between pages.
@@ -242,7 +307,7 @@ func (d *docs) open(dir string, mode mode, goos, goarch string) *Page {
 		if mode&modeMethods != 0 {
 			m |= doc.AllMethods
 		}
-		info.PDoc = doc.New(pkg, strings.TrimPrefix(dir, "src/"), m)
+		info.PDoc = doc.New(pkg, d.importPath(dir), m)
 		if mode&modeBuiltin != 0 {
 			for _, t := range info.PDoc.Types {
 				info.PDoc.Consts = append(info.PDoc.Consts, t.Consts...)
@@ -267,7 +332,7 @@ func (d *docs) open(dir string, mode mode, goos, goarch string) *Page {
 		info.Bugs = info.PDoc.Notes["BUG"]
 	}
 
-	info.Dirs = d.root.lookup(dir).list(func(path string) bool { return d.includePath(path, mode) })
+	info.Dirs = d.tree().lookup(dir).list(func(path string) bool { return d.includePath(path, mode) })
 	info.DirFlat = mode&modeFlat != 0
 
 	return info
@@ -404,6 +469,14 @@ func startsWithUppercase(s string) bool {
 }
 
 func (d *docs) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, "/doc/api/") {
+		d.serveAPI(w, r)
+		return
+	}
+	if md, name, explicit, ok := d.lookupModule(r.URL.Path); ok {
+		d.serveModule(w, r, md, name, explicit)
+		return
+	}
 	if maybeRedirect(w, r) {
 		return
 	}
@@ -413,13 +486,23 @@ func (d *docs) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	relpath = strings.TrimPrefix(relpath, "/")
 
 	mode := parseMode(r.FormValue("m"))
+	if mode&modeHistory != 0 {
+		// pkg.go.dev has no history view, so ?m=history implies ?m=old.
+		mode |= modeOld
+	}
+
+	if q := r.FormValue("q"); relpath == "" && q != "" {
+		d.serveSymbolSearch(w, r, q, mode)
+		return
+	}
 
 	// Redirect to pkg.go.dev.
 	// We provide two overrides for the redirect.
 	// First, the request can set ?m=old to get the old pages.
 	// Second, the request can come from China:
 	// since pkg.go.dev is not available in China, we serve the docs directly.
-	if mode&modeOld == 0 && (d.forceOld == nil || !d.forceOld(r)) {
+	// Requests for JSON docs are never redirected.
+	if !wantJSON(r) && mode&modeOld == 0 && (d.forceOld == nil || !d.forceOld(r)) {
 		if relpath == "" {
 			relpath = "std"
 		}
@@ -452,10 +535,29 @@ func (d *docs) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	info := d.open("src/"+relpath, mode, r.FormValue("GOOS"), r.FormValue("GOARCH"))
 	if info.Err != nil {
 		log.Print(info.Err)
+		if wantJSON(r) {
+			serveJSONError(w, info.Err, d.jsonErrorStatus("src/"+relpath))
+			return
+		}
 		d.site.ServeError(w, r, info.Err)
 		return
 	}
 	info.OldDocs = mode&modeOld != 0
+	if mode&modeHistory != 0 {
+		info.History = d.history(relpath)
+	}
+	if err := d.comparePorts(info, r.URL.Query()); err != nil {
+		if wantJSON(r) {
+			serveJSONError(w, err, http.StatusBadRequest)
+			return
+		}
+		d.site.ServeErrorStatus(w, r, err, http.StatusBadRequest)
+		return
+	}
+	if wantJSON(r) {
+		d.serveJSON(w, r, info)
+		return
+	}
 
 	var tabtitle, title, subtitle string
 	switch {
@@ -490,13 +592,22 @@ func (d *docs) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if info.Dirname == "src" {
 		layout = "pkgroot"
 	}
-	d.site.ServePage(w, r, web.Page{
+	page := web.Page{
 		"title":    title,
 		"tabTitle": tabtitle,
 		"subtitle": subtitle,
 		"layout":   layout,
 		"pkg":      info,
-	})
+	}
+	if info.PDoc != nil {
+		// Describe the package for link previews and search engines.
+		page["summary"] = info.PDoc.Synopsis(info.PDoc.Doc)
+		page["meta"] = map[string]interface{}{
+			"schema":     "SoftwareSourceCode",
+			"repository": "https://go.googlesource.com/go",
+		}
+	}
+	d.site.ServePage(w, r, page)
 }
 
 // ModeQuery returns the "?m=..." query for the current page.
@@ -5,6 +5,16 @@
 package pkgdoc
 
 import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io/fs"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
 	"testing/fstest"
 
@@ -115,3 +125,244 @@ func (T[A, B]) M() {}
 		t.Errorf("meth.Name = %q; want %q", got, want)
 	}
 }
+
+func TestModules(t *testing.T) {
+	mod := func(doc string) fstest.MapFS {
+		return fstest.MapFS{
+			"go.mod":           {Data: []byte("module example.com/m\n")},
+			"m.go":             {Data: []byte("// Package m " + doc + ".\n//\n// See [example.com/m/sub.S].\npackage m\n\nfunc F() {}\n")},
+			"sub/sub.go":       {Data: []byte("package sub\n\ntype S int\n")},
+			"cmd/tool/main.go": {Data: []byte("package main\n\nfunc main() {}\n")},
+			"nested/go.mod":    {Data: []byte("module example.com/m/nested\n")},
+			"nested/n.go":      {Data: []byte("package nested\n")},
+		}
+	}
+	fsys := fstest.MapFS{
+		"site.tmpl":     {Data: []byte(`{{block "layout" .}}{{end}}`)},
+		"error.tmpl":    {Data: []byte(`{{define "layout"}}{{.error}}{{end}}`)},
+		"texthtml.tmpl": {Data: []byte(`{{define "layout"}}{{.texthtml}}{{end}}`)},
+		"pkg.tmpl": {Data: []byte(`{{define "layout"}}{{.title}}
+{{range .pkg.Versions}}{{.Name}} {{.URL}}{{if .Current}} *{{end}}
+{{end}}{{with .pkg.PDoc}}{{$.pkg.Comment .Doc}}{{range .Funcs}}{{$.pkg.SrcPosLink .Decl}}{{end}}{{end}}
+{{range .pkg.Dirs}}dir {{.Path}}
+{{end}}{{end}}`)},
+	}
+	site := web.NewSite(fsys)
+	m1, err := DirModule(mod("version one"), "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m2, err := DirModule(mod("version two"), "v1.2.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := NewServer(fsys, site, nil, m1, m2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(path string, code int, body ...string) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != code {
+			t.Fatalf("GET %s: code %d, want %d\n%s", path, w.Code, code, w.Body)
+		}
+		for _, b := range body {
+			if code/100 == 3 {
+				if loc := w.Header().Get("Location"); loc != b {
+					t.Errorf("GET %s: Location %q, want %q", path, loc, b)
+				}
+				continue
+			}
+			if !strings.Contains(w.Body.String(), b) {
+				t.Errorf("GET %s: body lacks %q:\n%s", path, b, w.Body)
+			}
+		}
+	}
+
+	get("/pkg/example.com/m/", 200, "Package m\n", "version two.", `<a href="/pkg/example.com/m/sub/#S">`)
+	get("/pkg/example.com/m@v1.0.0/sub/", 200, "Package sub\n")
+	get("/pkg/example.com/m", 301, "/pkg/example.com/m/")
+	get("/pkg/example.com/m?m=old", 301, "/pkg/example.com/m/?m=old")
+	get("/pkg/example.com/m/?m=old", 200,
+		"Package m\n",
+		"version two.",
+		"v1.2.0 /pkg/example.com/m/ *\nv1.0.0 /pkg/example.com/m@v1.0.0/\n",
+		`<a href="/pkg/example.com/m/sub/?m=old#S">`,
+		`/pkg/example.com/m@v1.2.0/m.go?s=`,
+		"dir sub\n", "dir cmd/tool\n")
+	get("/pkg/example.com/m@v1.0.0/?m=old", 200,
+		"version one.",
+		"v1.2.0 /pkg/example.com/m/\nv1.0.0 /pkg/example.com/m@v1.0.0/ *\n",
+		`<a href="/pkg/example.com/m@v1.0.0/sub/?m=old#S">`)
+	get("/pkg/example.com/m@v1.0.0/sub/?m=old", 200, "Package sub\n")
+	get("/pkg/example.com/m/cmd/tool/?m=old", 200, "Command tool\n")
+	get("/pkg/example.com/m/cmd/?m=old", 200, "Directory example.com/m/cmd\n")
+	get("/pkg/example.com/m@v1.0.0/m.go", 200, `<span class="comment">// Package m version one.`)
+	get("/pkg/example.com/m@v1.0.0/m.go?m=text", 200, "// Package m version one.")
+	get("/pkg/example.com/m@v9.9.9/?m=old", 404)
+	get("/pkg/example.com/m/missing/?m=old", 404)
+	get("/pkg/example.com/m/go.mod", 404)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/pkg/example.com/m/?m=old", nil))
+	if strings.Contains(w.Body.String(), "nested") {
+		t.Errorf("module page lists nested module:\n%s", w.Body)
+	}
+}
+
+func TestProxyModules(t *testing.T) {
+	dir := t.TempDir()
+	vdir := filepath.Join(dir, "example.com", "!my!mod", "@v")
+	if err := os.MkdirAll(vdir, 0777); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, data := range map[string]string{
+		"example.com/!my!mod@v1.0.0/go.mod": "module example.com/MyMod\n",
+		"example.com/!my!mod@v1.0.0/m.go":   "package m\n",
+	} {
+		// Zip files use the module path, not its escaped form.
+		name = strings.Replace(name, "!my!mod", "MyMod", 1)
+		f, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(data))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(vdir, "v1.0.0.zip"), buf.Bytes(), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(vdir, "v1.0.0.mod"), []byte("module example.com/MyMod\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	mods, err := ProxyModules(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mods) != 1 || mods[0].Path != "example.com/MyMod" || mods[0].Version != "v1.0.0" {
+		t.Fatalf("ProxyModules = %+v, want example.com/MyMod@v1.0.0", mods)
+	}
+	data, err := fs.ReadFile(mods[0].FS, "m.go")
+	if err != nil || string(data) != "package m\n" {
+		t.Fatalf("ReadFile(m.go) = %q, %v, want %q", data, err, "package m\n")
+	}
+}
+
+func TestJSON(t *testing.T) {
+	fsys := fstest.MapFS{
+		"src/example.com/p/p.go": {Data: []byte(`// Package p is a test.
+package p
+
+// Limits.
+const (
+	Min = 1
+	Max = 2
+)
+
+// T is a type.
+type T struct{}
+
+// NewT returns a T.
+func NewT() *T { return nil }
+
+// M is a method.
+func (t *T) M() {}
+
+// BUG(rsc): M does nothing.
+`)},
+		"src/example.com/p/p_test.go": {Data: []byte(`package p_test
+
+import "fmt"
+
+func ExampleT_M() {
+	fmt.Println("hi")
+	// Output: hi
+}
+`)},
+		"src/example.com/p/sub/sub.go": {Data: []byte("// Package sub is a subpackage.\npackage sub\n")},
+	}
+	site := web.NewSite(fsys)
+	h, err := NewServer(fsys, site, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(url string, code int, v interface{}) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+		if w.Code != code {
+			t.Fatalf("GET %s: code %d, want %d\n%s", url, w.Code, code, w.Body)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("GET %s: Content-Type %q, want application/json", url, ct)
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+			t.Fatalf("GET %s: %v\n%s", url, err, w.Body)
+		}
+	}
+
+	var pkg jsonPackage
+	get("/pkg/example.com/p/?json", 200, &pkg)
+	if pkg.ImportPath != "example.com/p" || pkg.Name != "p" || pkg.Synopsis != "Package p is a test." {
+		t.Errorf("package = %q %q %q, want example.com/p p %q", pkg.ImportPath, pkg.Name, pkg.Synopsis, "Package p is a test.")
+	}
+	if len(pkg.Consts) != 1 || !slices.Equal(pkg.Consts[0].Names, []string{"Min", "Max"}) {
+		t.Errorf("Consts = %+v, want one group declaring Min, Max", pkg.Consts)
+	}
+	if len(pkg.Bugs) != 1 || pkg.Bugs[0].UID != "rsc" || pkg.Bugs[0].Body != "M does nothing.\n" {
+		t.Errorf("Bugs = %+v, want rsc: M does nothing.", pkg.Bugs)
+	}
+	if len(pkg.Dirs) != 1 || pkg.Dirs[0].ImportPath != "example.com/p/sub" || !pkg.Dirs[0].HasPkg {
+		t.Errorf("Dirs = %+v, want example.com/p/sub", pkg.Dirs)
+	}
+
+	var sym jsonSymbol
+	get("/pkg/example.com/p/?json&sym=T.M", 200, &sym)
+	want := jsonSymbol{
+		Kind: "method",
+		Name: "M",
+		Recv: "*T",
+		Decl: "func (t *T) M()",
+		Doc:  "M is a method.\n",
+		Pos:  jsonPos{File: "src/example.com/p/p.go", Line: 17, URL: "/src/example.com/p/p.go?s=178:193#L7"},
+		Examples: []*jsonExample{
+			{Name: "T_M", Code: `fmt.Println("hi")`, Output: "hi\n"},
+		},
+	}
+	if len(sym.Examples) == 1 {
+		if !strings.Contains(sym.Examples[0].Play, "func main() {") {
+			t.Errorf("sym=T.M: example Play = %q, want program", sym.Examples[0].Play)
+		}
+		sym.Examples[0].Play = ""
+	}
+	if !reflect.DeepEqual(sym, want) {
+		js, _ := json.Marshal(sym)
+		t.Errorf("sym=T.M: have %s", js)
+	}
+
+	sym = jsonSymbol{}
+	get("/pkg/example.com/p/?json&sym=Max", 200, &sym)
+	if sym.Kind != "const" || sym.Name != "Min" {
+		t.Errorf("sym=Max: Kind, Name = %q, %q, want const, Min", sym.Kind, sym.Name)
+	}
+	sym = jsonSymbol{}
+	get("/pkg/example.com/p/?json&sym=NewT", 200, &sym)
+	if sym.Kind != "func" || sym.Name != "NewT" {
+		t.Errorf("sym=NewT: Kind, Name = %q, %q, want func, NewT", sym.Kind, sym.Name)
+	}
+
+	var e struct{ Error string }
+	get("/pkg/example.com/p/?json&sym=T.Missing", 404, &e)
+	get("/pkg/example.com/missing/?json", 404, &e)
+	if e.Error == "" {
+		t.Errorf("missing package: no error in JSON")
+	}
+}
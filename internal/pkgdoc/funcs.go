@@ -162,6 +162,12 @@ func (p *Page) Comment(text string) template.HTML {
 		if strings.HasPrefix(url, "/pkg/cmd/") {
 			url = url[len("/pkg"):]
 		}
+		if m := p.Module; m != nil && p.explicit && m.Version != "" {
+			// Stay in the same version of the module.
+			if rest, ok := strings.CutPrefix(url, "/pkg/"+m.Path); ok && (rest == "" || rest[0] == '/' || rest[0] == '#') {
+				url = "/pkg/" + m.Path + "@" + m.Version + rest
+			}
+		}
 		if p.OldDocs {
 			if base, frag, ok := strings.Cut(url, "#"); ok {
 				url = base + "?m=old#" + frag
@@ -225,6 +231,65 @@ func (p *Page) Since(kind, receiver, name string) string {
 	return p.docs.api.Func(pkg, kind, receiver, name)
 }
 
+// valueSince reports the Go versions that introduced the names
+// declared by the const or var declaration v.
+// If all the names were added in the same version, valueSince returns
+// that version and a nil map. Otherwise it returns "" and a map from
+// each name added after Go 1 to its version.
+func (p *Page) valueSince(v *doc.Value) (since string, byName map[string]string) {
+	kind := v.Decl.Tok.String()
+	for i, name := range v.Names {
+		ver := p.Since(kind, "", name)
+		if i == 0 {
+			since = ver
+		}
+		if ver != since && byName == nil {
+			byName = make(map[string]string)
+			for _, prev := range v.Names[:i] {
+				if since != "" {
+					byName[prev] = since
+				}
+			}
+		}
+		if byName != nil && ver != "" {
+			byName[name] = ver
+		}
+	}
+	if byName != nil {
+		return "", byName
+	}
+	return since, nil
+}
+
+// ValueSince describes the Go versions that introduced the names
+// declared by the const or var declaration v, like "Added in Go 1.8."
+// or "B added in Go 1.8; C added in Go 1.9.".
+// It returns "" if all the names were present in Go 1.
+func (p *Page) ValueSince(v *doc.Value) string {
+	since, byName := p.valueSince(v)
+	if byName == nil {
+		if since == "" {
+			return ""
+		}
+		return "Added in Go " + since + "."
+	}
+	var vers []string
+	names := make(map[string][]string)
+	for _, name := range v.Names {
+		if ver := byName[name]; ver != "" {
+			if names[ver] == nil {
+				vers = append(vers, ver)
+			}
+			names[ver] = append(names[ver], name)
+		}
+	}
+	var parts []string
+	for _, ver := range vers {
+		parts = append(parts, strings.Join(names[ver], ", ")+" added in Go "+ver)
+	}
+	return strings.Join(parts, "; ") + "."
+}
+
 type Example struct {
 	Page   *Page
 	Name   string
@@ -450,12 +515,15 @@ func (p *Page) SrcPosLink(n interface{}) template.HTML {
 		high = p.fset.Position(end).Offset
 	}
 
+	if p.docs.mod != nil {
+		relpath = p.docs.moduleURL(relpath, true)
+	}
 	return srcPosLink(relpath, line, low, high)
 }
 
 func srcPosLink(s string, line, low, high int) template.HTML {
 	s = path.Clean("/" + s)
-	if !strings.HasPrefix(s, "/src/") {
+	if !strings.HasPrefix(s, "/src/") && !strings.HasPrefix(s, "/pkg/") {
 		s = "/src" + s
 	}
 	var buf bytes.Buffer
@@ -0,0 +1,164 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file contains the code serving the history of the standard library API.
+
+package pkgdoc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"strings"
+
+	"golang.org/x/website/internal/api"
+	"golang.org/x/website/internal/web"
+)
+
+// An APIRelease lists the API features added in a Go release.
+type APIRelease struct {
+	Version  string       // like "1.21"
+	Count    int          // number of features added
+	Packages []APIPackage // packages gaining features, sorted by import path
+}
+
+// An APIPackage lists the API features added to a package in a release.
+type APIPackage struct {
+	Path     string // import path
+	Features []api.Feature
+}
+
+// releases returns the API features in d.api grouped by release, newest first.
+func (d *docs) releases() []APIRelease {
+	d.releasesOnce.Do(func() {
+		var list []APIRelease
+		for _, f := range d.api.Features() {
+			if len(list) == 0 || list[0].Version != f.Version {
+				list = append([]APIRelease{{Version: f.Version}}, list...)
+			}
+			r := &list[0]
+			if len(r.Packages) == 0 || r.Packages[len(r.Packages)-1].Path != f.Package {
+				r.Packages = append(r.Packages, APIPackage{Path: f.Package})
+			}
+			p := &r.Packages[len(r.Packages)-1]
+			p.Features = append(p.Features, f)
+			r.Count++
+		}
+		d.releaseList = list
+	})
+	return d.releaseList
+}
+
+// history returns the API features added to the package with the given import path,
+// grouped by release, newest first.
+func (d *docs) history(importPath string) []APIRelease {
+	var list []APIRelease
+	for _, r := range d.releases() {
+		for _, p := range r.Packages {
+			if p.Path == importPath {
+				list = append(list, APIRelease{Version: r.Version, Count: len(p.Features), Packages: []APIPackage{p}})
+				break
+			}
+		}
+	}
+	return list
+}
+
+// ShowHistory reports whether the page should list the API added
+// to the package in each release, as requested by ?m=history.
+func (p *Page) ShowHistory() bool {
+	return p.mode&modeHistory != 0
+}
+
+// serveAPI serves the pages under /doc/api/ listing the API features
+// added to the standard library in each Go release after Go 1:
+// /doc/api/ lists the releases, /doc/api/go1.N lists the features added in Go 1.N,
+// and /doc/api/?since=go1.N lists those added in the releases after Go 1.N.
+// The pages use the “api” layout, with the page keys “releases” (a []APIRelease),
+// “versions” (the Go versions adding features, newest first),
+// and “version” and “since” (the Go versions in the URL, if any).
+// With ?json, serveAPI serves the features as a JSON list of api.Feature instead;
+// /doc/api/?json lists them all.
+func (d *docs) serveAPI(w http.ResponseWriter, r *http.Request) {
+	all := d.releases()
+	var versions []string
+	for _, rel := range all {
+		versions = append(versions, rel.Version)
+	}
+	page := web.Page{
+		"layout":   "api",
+		"versions": versions,
+		"version":  "", // for /doc/api/go1.N
+		"since":    "", // for /doc/api/?since=go1.N
+	}
+	fail := func(err error, status int) {
+		if wantJSON(r) {
+			serveJSONError(w, err, status)
+			return
+		}
+		d.site.ServeErrorStatus(w, r, err, status)
+	}
+
+	var releases []APIRelease
+	name := strings.TrimPrefix(r.URL.Path, "/doc/api/")
+	switch {
+	case name == "" && r.FormValue("since") != "":
+		since := strings.TrimPrefix(r.FormValue("since"), "go")
+		if !api.ValidVersion(since) {
+			fail(fmt.Errorf("invalid since=%s: want a Go version like go1.21", r.FormValue("since")), http.StatusBadRequest)
+			return
+		}
+		for _, rel := range all {
+			if api.CompareVersions(rel.Version, since) > 0 {
+				releases = append(releases, rel)
+			}
+		}
+		page["title"] = "API added since Go " + since
+		page["since"] = since
+
+	case name == "":
+		releases = all
+		page["title"] = "Go API History"
+
+	case strings.HasPrefix(name, "go"):
+		for _, rel := range all {
+			if "go"+rel.Version == name {
+				releases = append(releases, rel)
+				page["title"] = "API added in Go " + rel.Version
+				page["version"] = rel.Version
+			}
+		}
+		if releases == nil {
+			fail(fmt.Errorf("no API additions for %s: %w", name, fs.ErrNotExist), http.StatusNotFound)
+			return
+		}
+
+	default:
+		fail(errors.New("page not found"), http.StatusNotFound)
+		return
+	}
+
+	if wantJSON(r) {
+		features := []api.Feature{}
+		// releases is newest first; list the features oldest first, like api.DB.Features.
+		for i := len(releases) - 1; i >= 0; i-- {
+			for _, p := range releases[i].Packages {
+				features = append(features, p.Features...)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", " ")
+		if err := enc.Encode(features); err != nil {
+			log.Printf("ERROR rendering JSON for %s: %v", r.URL, err)
+		}
+		return
+	}
+
+	page["releases"] = releases
+	d.site.ServePage(w, r, page)
+}
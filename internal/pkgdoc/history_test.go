@@ -0,0 +1,142 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkgdoc
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/doc"
+	"go/token"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"golang.org/x/website/internal/api"
+	"golang.org/x/website/internal/web"
+)
+
+func TestAPIHistory(t *testing.T) {
+	fsys := fstest.MapFS{
+		"api/go1.txt":        {Data: []byte("pkg p, func Old()\n")},
+		"api/go1.1.txt":      {Data: []byte("pkg p, func One()\npkg q, type T struct\n")},
+		"api/go1.2.txt":      {Data: []byte("pkg p, method (*T) Two()\n")},
+		"api/next/12345.txt": {Data: []byte("pkg q, var Three error #12345\n")},
+		"src/p/p.go":         {Data: []byte("// Package p is a test.\npackage p\n\nfunc Old() {}\n")},
+		"site.tmpl":          {Data: []byte(`{{block "layout" .}}{{end}}`)},
+		"error.tmpl":         {Data: []byte(`{{define "layout"}}{{.error}}{{end}}`)},
+	}
+	h, err := NewServer(fsys, web.NewSite(fsys), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func(url string, code int, v interface{}) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+		if w.Code != code {
+			t.Fatalf("GET %s: code %d, want %d\n%s", url, w.Code, code, w.Body)
+		}
+		if v == nil {
+			return
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+			t.Fatalf("GET %s: %v\n%s", url, err, w.Body)
+		}
+	}
+
+	one := api.Feature{Version: "1.1", Package: "p", Kind: "func", Name: "One"}
+	t1 := api.Feature{Version: "1.1", Package: "q", Kind: "type", Name: "T"}
+	two := api.Feature{Version: "1.2", Package: "p", Kind: "method", Recv: "*T", Name: "Two"}
+	three := api.Feature{Version: "1.3", Package: "q", Kind: "var", Name: "Three"}
+
+	for _, tt := range []struct {
+		url  string
+		want []api.Feature
+	}{
+		{"/doc/api/?json", []api.Feature{one, t1, two, three}},
+		{"/doc/api/go1.2?json", []api.Feature{two}},
+		{"/doc/api/?since=go1.1&json", []api.Feature{two, three}},
+		{"/doc/api/?since=1.3&json", []api.Feature{}},
+	} {
+		var got []api.Feature
+		get(tt.url, 200, &got)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("GET %s = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+	get("/doc/api/go1.4?json", 404, new(struct{ Error string }))
+	get("/doc/api/?since=go2&json", 400, new(struct{ Error string }))
+	get("/doc/api/go1.4", 404, nil)
+
+	var pkg jsonPackage
+	get("/pkg/p/?json&m=history", 200, &pkg)
+	if want := []api.Feature{one, two}; !reflect.DeepEqual(pkg.History, want) {
+		t.Errorf("History = %v, want %v", pkg.History, want)
+	}
+}
+
+func TestValueSince(t *testing.T) {
+	fsys := fstest.MapFS{
+		"api/go1.txt":   {Data: []byte("pkg p, const A = 1\n")},
+		"api/go1.1.txt": {Data: []byte("pkg p, const B = 2\npkg p, const C = 3\npkg p, var V error\n")},
+		"api/go1.2.txt": {Data: []byte("pkg p, const D = 4\npkg p, var W error\n")},
+		"src/p/p.go": {Data: []byte(`// Package p is a test.
+package p
+
+const (
+	A = 1
+	B = 2
+	C = 3
+	D = 4
+)
+
+var V, W error
+`)},
+		"site.tmpl":  {Data: []byte(`{{block "layout" .}}{{end}}`)},
+		"error.tmpl": {Data: []byte(`{{define "layout"}}{{.error}}{{end}}`)},
+	}
+	h, err := NewServer(fsys, web.NewSite(fsys), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/pkg/p/?json", nil))
+	var pkg jsonPackage
+	if err := json.Unmarshal(w.Body.Bytes(), &pkg); err != nil {
+		t.Fatalf("GET /pkg/p/?json: %v\n%s", err, w.Body)
+	}
+	if len(pkg.Consts) != 1 || pkg.Consts[0].Since != "" ||
+		!reflect.DeepEqual(pkg.Consts[0].NameSince, map[string]string{"B": "1.1", "C": "1.1", "D": "1.2"}) {
+		t.Errorf("Consts = %+v, want NameSince B, C: 1.1, D: 1.2", pkg.Consts)
+	}
+	if len(pkg.Vars) != 1 || pkg.Vars[0].Since != "" ||
+		!reflect.DeepEqual(pkg.Vars[0].NameSince, map[string]string{"V": "1.1", "W": "1.2"}) {
+		t.Errorf("Vars = %+v, want NameSince V: 1.1, W: 1.2", pkg.Vars)
+	}
+
+	db, err := api.Load(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &Page{docs: &docs{api: db}, PDoc: &doc.Package{ImportPath: "p"}}
+	value := func(tok token.Token, names ...string) *doc.Value {
+		return &doc.Value{Names: names, Decl: &ast.GenDecl{Tok: tok}}
+	}
+	for _, tt := range []struct {
+		v    *doc.Value
+		want string
+	}{
+		{value(token.CONST, "A"), ""},
+		{value(token.CONST, "B", "C"), "Added in Go 1.1."},
+		{value(token.CONST, "A", "B", "C", "D"), "B, C added in Go 1.1; D added in Go 1.2."},
+		{value(token.VAR, "W", "V"), "W added in Go 1.2; V added in Go 1.1."},
+		{value(token.VAR, "B"), ""},
+	} {
+		if got := p.ValueSince(tt.v); got != tt.want {
+			t.Errorf("ValueSince(%s %v) = %q, want %q", tt.v.Decl.Tok, tt.v.Names, got, tt.want)
+		}
+	}
+}
@@ -29,6 +29,7 @@ func TestSrcPosLink(t *testing.T) {
 		{"/src/fmt/print.go", 0, 1, 5, "/src/fmt/print.go?s=1:5#L1"},
 		{"fmt/print.go", 0, 0, 0, "/src/fmt/print.go"},
 		{"fmt/print.go", 0, 1, 5, "/src/fmt/print.go?s=1:5#L1"},
+		{"/pkg/example.com/m@v1.0.0/p.go", 3, 0, 0, "/pkg/example.com/m@v1.0.0/p.go#L3"},
 	} {
 		if got := srcPosLink(tc.src, tc.line, tc.low, tc.high); got != tc.want {
 			t.Errorf("srcPosLink(%v, %v, %v, %v) = %v; want %v", tc.src, tc.line, tc.low, tc.high, got, tc.want)
@@ -0,0 +1,355 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file contains the code serving package docs as JSON.
+
+package pkgdoc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/format"
+	"go/printer"
+	"go/token"
+	"html"
+	"io/fs"
+	"log"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+
+	"golang.org/x/website/internal/api"
+)
+
+// wantJSON reports whether the request asks for docs as JSON,
+// with a ?json URL parameter.
+func wantJSON(r *http.Request) bool {
+	return r.URL.Query().Has("json")
+}
+
+// A jsonPackage is the JSON form of the docs for a package directory.
+// For a directory holding no package, only ImportPath and Dirs are set.
+type jsonPackage struct {
+	ImportPath string
+	Module     string         `json:",omitempty"` // module path; empty for GOROOT
+	Version    string         `json:",omitempty"` // module version
+	Name       string         `json:",omitempty"` // package name
+	IsCommand  bool           `json:",omitempty"` // package main
+	Synopsis   string         `json:",omitempty"`
+	Doc        string         `json:",omitempty"`
+	Consts     []*jsonSymbol  `json:",omitempty"`
+	Vars       []*jsonSymbol  `json:",omitempty"`
+	Funcs      []*jsonSymbol  `json:",omitempty"`
+	Types      []*jsonSymbol  `json:",omitempty"`
+	Examples   []*jsonExample `json:",omitempty"` // package examples
+	Bugs       []*jsonNote    `json:",omitempty"`
+	Dirs       []*jsonDir     `json:",omitempty"` // subdirectories
+	Ports      []string       `json:",omitempty"` // for ?m=ports, the ports compared
+	Diff       *PortDiff      `json:",omitempty"` // for ?diff=a,b, the differences between ports a and b
+	History    []api.Feature  `json:",omitempty"` // for ?m=history, the API added after Go 1, oldest first
+}
+
+// A jsonSymbol is the JSON form of the docs for a declaration in a package.
+type jsonSymbol struct {
+	Kind      string            // "const", "var", "func", "type", or "method"
+	Name      string            // name; for a const or var declaration, the first name declared
+	Names     []string          `json:",omitempty"` // for a const or var declaration, all names declared
+	Recv      string            `json:",omitempty"` // for a method, the receiver type, like "*Server"
+	Decl      string            // declaration, formatted as Go source
+	Doc       string            `json:",omitempty"`
+	Since     string            `json:",omitempty"` // Go version adding a GOROOT symbol after Go 1, like "1.8"
+	NameSince map[string]string `json:",omitempty"` // for a const or var declaration adding names in different versions, each name's Since
+	Ports     []string          `json:",omitempty"` // for ?m=ports, the ports declaring the symbol, if not all
+	Pos       jsonPos           // location of the declaration
+	Examples  []*jsonExample    `json:",omitempty"`
+
+	// For a type, the declarations associated with it.
+	Consts  []*jsonSymbol `json:",omitempty"`
+	Vars    []*jsonSymbol `json:",omitempty"`
+	Funcs   []*jsonSymbol `json:",omitempty"`
+	Methods []*jsonSymbol `json:",omitempty"`
+}
+
+// A jsonPos is the JSON form of a location in a source file.
+type jsonPos struct {
+	File string // file name, relative to the GOROOT or module root
+	Line int
+	URL  string // URL path of the source file, selecting the location
+}
+
+// A jsonExample is the JSON form of an example.
+type jsonExample struct {
+	Name   string // name of example function, without the "Example" prefix, like "Server_Shutdown"
+	Doc    string `json:",omitempty"`
+	Code   string // example code, formatted as Go source
+	Play   string `json:",omitempty"` // complete program for the playground, if any
+	Output string `json:",omitempty"` // expected output, if any
+}
+
+// A jsonNote is the JSON form of a marked comment, like a BUG(uid) note.
+type jsonNote struct {
+	UID  string
+	Body string
+	Pos  jsonPos
+}
+
+// A jsonDir is the JSON form of a subdirectory listing.
+type jsonDir struct {
+	ImportPath string
+	HasPkg     bool
+	Synopsis   string `json:",omitempty"`
+}
+
+// serveJSON serves the docs in p as JSON: the whole package,
+// or with a ?sym=Name or ?sym=Type.Method URL parameter, just that symbol.
+func (d *docs) serveJSON(w http.ResponseWriter, r *http.Request, p *Page) {
+	pkg := p.json()
+	var v interface{} = pkg
+	if sym := r.FormValue("sym"); sym != "" {
+		s := pkg.lookup(sym)
+		if s == nil {
+			serveJSONError(w, fmt.Errorf("%s: no symbol %s", pkg.ImportPath, sym), http.StatusNotFound)
+			return
+		}
+		v = s
+	}
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", " ")
+	if err := enc.Encode(v); err != nil {
+		log.Printf("ERROR rendering JSON for %s: %v", r.URL, err)
+	}
+}
+
+// serveJSONError responds to a request for JSON docs with the error err,
+// as JSON of the form {"Error": "..."}.
+func serveJSONError(w http.ResponseWriter, err error, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", " ")
+	enc.Encode(struct{ Error string }{err.Error()})
+}
+
+// jsonErrorStatus returns the HTTP status for the error opening dir in d:
+// 404 if dir does not exist, 500 otherwise.
+func (d *docs) jsonErrorStatus(dir string) int {
+	if _, err := fs.Stat(d.fs, path.Clean(dir)); err != nil {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// json returns the JSON form of the docs in p.
+func (p *Page) json() *jsonPackage {
+	d := p.docs
+	pkg := &jsonPackage{ImportPath: d.importPath(p.Dirname)}
+	if d.mod == nil && p.Dirname == "src" {
+		pkg.ImportPath = "std"
+	}
+	if p.Module != nil {
+		pkg.Module = p.Module.Path
+		pkg.Version = p.Module.Version
+	}
+	for _, e := range p.Dirs {
+		pkg.Dirs = append(pkg.Dirs, &jsonDir{
+			ImportPath: d.importPath(path.Join(p.Dirname, e.Path)),
+			HasPkg:     e.HasPkg,
+			Synopsis:   e.Synopsis,
+		})
+	}
+
+	pd := p.PDoc
+	if pd == nil {
+		return pkg
+	}
+	pkg.Name = pd.Name
+	pkg.IsCommand = p.IsMain
+	pkg.Synopsis = pd.Synopsis(pd.Doc)
+	pkg.Doc = pd.Doc
+	pkg.Consts = p.jsonValues(pd.Consts)
+	pkg.Vars = p.jsonValues(pd.Vars)
+	pkg.Funcs = p.jsonFuncs(pd.Funcs, "")
+	for _, t := range pd.Types {
+		s := p.jsonSymbol("type", t.Name, "", t.Decl, t.Doc)
+		s.Since = p.Since("type", "", t.Name)
+		s.Ports = p.portsOf[t.Name]
+		s.Examples = p.jsonExamples(t.Name)
+		s.Consts = p.jsonValues(t.Consts)
+		s.Vars = p.jsonValues(t.Vars)
+		s.Funcs = p.jsonFuncs(t.Funcs, "")
+		s.Methods = p.jsonFuncs(t.Methods, t.Name)
+		pkg.Types = append(pkg.Types, s)
+	}
+	pkg.Examples = p.jsonExamples("")
+	pkg.Ports = p.Ports
+	pkg.Diff = p.Diff
+	for i := len(p.History) - 1; i >= 0; i-- {
+		for _, hp := range p.History[i].Packages {
+			pkg.History = append(pkg.History, hp.Features...)
+		}
+	}
+	for _, n := range p.Bugs {
+		pkg.Bugs = append(pkg.Bugs, &jsonNote{
+			UID:  n.UID,
+			Body: n.Body,
+			Pos:  p.jsonPos(n, n.Pos),
+		})
+	}
+	return pkg
+}
+
+// jsonSymbol returns the JSON form of the declaration decl, without examples.
+func (p *Page) jsonSymbol(kind, name, recv string, decl ast.Node, docText string) *jsonSymbol {
+	var buf bytes.Buffer
+	p.docs.writeNode(&buf, p, p.fset, decl)
+	return &jsonSymbol{
+		Kind: kind,
+		Name: name,
+		Recv: recv,
+		Decl: buf.String(),
+		Doc:  docText,
+		Pos:  p.jsonPos(decl, decl.Pos()),
+	}
+}
+
+// jsonValues returns the JSON forms of the const or var declarations list.
+func (p *Page) jsonValues(list []*doc.Value) []*jsonSymbol {
+	var syms []*jsonSymbol
+	for _, v := range list {
+		if len(v.Names) == 0 {
+			continue
+		}
+		s := p.jsonSymbol(v.Decl.Tok.String(), v.Names[0], "", v.Decl, v.Doc)
+		s.Names = v.Names
+		s.Since, s.NameSince = p.valueSince(v)
+		s.Ports = p.portsOf[v.Names[0]]
+		syms = append(syms, s)
+	}
+	return syms
+}
+
+// jsonFuncs returns the JSON forms of the functions in list,
+// or of the methods in list if typeName, the name of their type, is not empty.
+func (p *Page) jsonFuncs(list []*doc.Func, typeName string) []*jsonSymbol {
+	var syms []*jsonSymbol
+	for _, f := range list {
+		kind, egName, key := "func", f.Name, f.Name
+		if typeName != "" {
+			kind, egName, key = "method", typeName+"_"+f.Name, typeName+"."+f.Name
+		}
+		s := p.jsonSymbol(kind, f.Name, f.Recv, f.Decl, f.Doc)
+		s.Since = p.Since(kind, f.Recv, f.Name)
+		s.Ports = p.portsOf[key]
+		s.Examples = p.jsonExamples(egName)
+		syms = append(syms, s)
+	}
+	return syms
+}
+
+// jsonPos returns the JSON form of the location of n,
+// which begins at pos and is an ast.Node or *doc.Note.
+func (p *Page) jsonPos(n interface{}, pos token.Pos) jsonPos {
+	if !pos.IsValid() {
+		return jsonPos{}
+	}
+	xp := p.fset.Position(pos)
+	return jsonPos{
+		File: xp.Filename,
+		Line: xp.Line,
+		URL:  html.UnescapeString(string(p.SrcPosLink(n))),
+	}
+}
+
+// jsonExamples returns the JSON forms of the examples for the given function name,
+// formatted like the examples on the HTML page (see FmtExamples).
+func (p *Page) jsonExamples(funcName string) []*jsonExample {
+	var list []*jsonExample
+	for _, eg := range p.Examples {
+		if trimExampleSuffix(eg.Name) != funcName {
+			continue
+		}
+
+		var buf bytes.Buffer
+		p.docs.writeNode(&buf, p, p.fset, &printer.CommentedNode{Node: eg.Code, Comments: eg.Comments})
+		code := buf.String()
+		out := eg.Output
+		wholeFile := true
+
+		// Additional formatting if this is a function body.
+		if n := len(code); n >= 2 && code[0] == '{' && code[n-1] == '}' {
+			wholeFile = false
+			code = replaceLeadingIndentation(code[1:n-1], strings.Repeat(" ", tabWidth), "")
+			if loc := exampleOutputRx.FindStringIndex(code); loc != nil {
+				code = code[:loc[0]]
+			}
+			code = strings.TrimSpace(code)
+		}
+
+		play := ""
+		if eg.Play != nil {
+			var buf bytes.Buffer
+			eg.Play.Comments = filterOutBuildAnnotations(eg.Play.Comments)
+			if err := format.Node(&buf, p.fset, eg.Play); err != nil {
+				log.Print(err)
+			} else {
+				play = buf.String()
+			}
+		}
+
+		// Drop output, as the output comment will appear in the code.
+		if wholeFile && play == "" {
+			out = ""
+		}
+
+		list = append(list, &jsonExample{
+			Name:   eg.Name,
+			Doc:    eg.Doc,
+			Code:   code,
+			Play:   play,
+			Output: out,
+		})
+	}
+	return list
+}
+
+// lookup returns the symbol in pkg named by sym,
+// which is a top-level name like "Server" or "ErrServerClosed",
+// or a method like "Server.Shutdown".
+// It returns nil if there is no such symbol.
+func (pkg *jsonPackage) lookup(sym string) *jsonSymbol {
+	if typ, name, ok := strings.Cut(sym, "."); ok {
+		for _, t := range pkg.Types {
+			if t.Name == typ {
+				return findSymbol(name, t.Methods)
+			}
+		}
+		return nil
+	}
+	if s := findSymbol(sym, pkg.Consts, pkg.Vars, pkg.Funcs, pkg.Types); s != nil {
+		return s
+	}
+	for _, t := range pkg.Types {
+		if s := findSymbol(sym, t.Consts, t.Vars, t.Funcs); s != nil {
+			return s
+		}
+	}
+	return nil
+}
+
+// findSymbol returns the symbol declaring name in lists, or nil if there is none.
+func findSymbol(name string, lists ...[]*jsonSymbol) *jsonSymbol {
+	for _, list := range lists {
+		for _, s := range list {
+			if s.Name == name || slices.Contains(s.Names, name) {
+				return s
+			}
+		}
+	}
+	return nil
+}
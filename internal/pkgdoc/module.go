@@ -0,0 +1,401 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file contains the code dealing with modules outside GOROOT.
+
+package pkgdoc
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"go/token"
+	"html/template"
+	"io/fs"
+	"log"
+	"net/http"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+	"golang.org/x/website/internal/texthtml"
+	"golang.org/x/website/internal/web"
+)
+
+// A Module is a version of a module whose packages a server
+// documents in addition to the packages in GOROOT.
+// The server documents the packages at /pkg/path@version/dir/,
+// and at /pkg/path/dir/ for the module's default version.
+// See NewServer.
+type Module struct {
+	Path    string // module path, like "golang.org/x/tools"
+	Version string // version, like "v0.20.0" or "master"; "" for an unversioned tree
+	FS      fs.FS  // module files, with go.mod at the root
+}
+
+// DirModule returns the Module with the given version for the file tree fsys,
+// such as a local directory or a Git tree, reading the module path from its go.mod file.
+func DirModule(fsys fs.FS, version string) (Module, error) {
+	data, err := fs.ReadFile(fsys, "go.mod")
+	if err != nil {
+		return Module{}, err
+	}
+	mpath := modfile.ModulePath(data)
+	if mpath == "" {
+		return Module{}, fmt.Errorf("go.mod: no module path")
+	}
+	return Module{Path: mpath, Version: version, FS: fsys}, nil
+}
+
+// ProxyModules returns the Modules for the module zip files in dir,
+// a directory in the layout of a module proxy (see “go help goproxy”),
+// like $GOPATH/pkg/mod/cache/download.
+// The zip files are opened when first used.
+func ProxyModules(dir string) ([]Module, error) {
+	var mods []Module
+	err := filepath.WalkDir(dir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(file) != ".zip" || filepath.Base(filepath.Dir(file)) != "@v" {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, filepath.Dir(filepath.Dir(file)))
+		if err != nil {
+			return err
+		}
+		mpath, err := module.UnescapePath(filepath.ToSlash(rel))
+		if err != nil {
+			return nil // not a module
+		}
+		version, err := module.UnescapeVersion(strings.TrimSuffix(d.Name(), ".zip"))
+		if err != nil {
+			return nil
+		}
+		mods = append(mods, Module{
+			Path:    mpath,
+			Version: version,
+			FS:      &zipFS{file: file, prefix: mpath + "@" + version},
+		})
+		return nil
+	})
+	return mods, err
+}
+
+// A zipFS is a file system holding the files in a module zip file,
+// which it opens when first used.
+type zipFS struct {
+	file   string // zip file name
+	prefix string // directory in zip holding module files, like "example.com/m@v1.0.0"
+
+	once sync.Once
+	fsys fs.FS
+	err  error
+}
+
+func (z *zipFS) Open(name string) (fs.File, error) {
+	z.once.Do(func() {
+		r, err := zip.OpenReader(z.file)
+		if err != nil {
+			z.err = err
+			return
+		}
+		z.fsys, z.err = fs.Sub(r, z.prefix)
+	})
+	if z.err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: z.err}
+	}
+	return z.fsys.Open(name)
+}
+
+// addModules adds the modules in mods to d, the docs for GOROOT.
+// Each version of a module has its own docs, sorted so that
+// the default version comes first: the unversioned tree, if any,
+// then semantic versions from newest to oldest, then other versions
+// (like branch names) in the order listed.
+func (d *docs) addModules(mods []Module) error {
+	d.mods = make(map[string][]*docs)
+	for i := range mods {
+		m := &mods[i]
+		if err := module.CheckImportPath(m.Path); err != nil {
+			return err
+		}
+		for _, md := range d.mods[m.Path] {
+			if md.mod.Version == m.Version {
+				return fmt.Errorf("module %s@%s listed twice", m.Path, m.Version)
+			}
+		}
+		d.mods[m.Path] = append(d.mods[m.Path], &docs{
+			fs:       m.FS,
+			site:     d.site,
+			forceOld: d.forceOld,
+			mod:      m,
+		})
+	}
+	rank := func(v string) int {
+		switch {
+		case v == "":
+			return 0
+		case semver.IsValid(v):
+			return 1
+		}
+		return 2
+	}
+	for _, list := range d.mods {
+		sort.SliceStable(list, func(i, j int) bool {
+			vi, vj := list[i].mod.Version, list[j].mod.Version
+			if ri, rj := rank(vi), rank(vj); ri != rj {
+				return ri < rj
+			}
+			return rank(vi) == 1 && semver.Compare(vi, vj) > 0
+		})
+	}
+	return nil
+}
+
+// tree returns the directory tree for d.
+// For a module, it is built when first needed.
+func (d *docs) tree() *Dir {
+	if d.mod != nil {
+		d.rootOnce.Do(func() {
+			d.root = newDir(d.fs, token.NewFileSet(), ".")
+			if d.root == nil {
+				d.root = &Dir{Path: "."}
+			}
+			d.root.pruneModules(d.fs)
+		})
+	}
+	return d.root
+}
+
+// pruneModules removes the subdirectories of d that hold other modules,
+// which have a go.mod file of their own.
+func (d *Dir) pruneModules(fsys fs.FS) {
+	dirs := d.Dirs[:0]
+	for _, sub := range d.Dirs {
+		if _, err := fs.Stat(fsys, path.Join(sub.Path, "go.mod")); err == nil {
+			continue
+		}
+		sub.pruneModules(fsys)
+		dirs = append(dirs, sub)
+	}
+	d.Dirs = dirs
+}
+
+// importPath returns the import path for the package directory dir in d.
+func (d *docs) importPath(dir string) string {
+	if d.mod == nil {
+		return strings.TrimPrefix(dir, "src/")
+	}
+	if dir == "." {
+		return d.mod.Path
+	}
+	return d.mod.Path + "/" + dir
+}
+
+// lookupModule returns the docs for the module version holding the
+// directory or file named by the URL path (which begins with /pkg/),
+// the name of the directory or file in the module, and whether the
+// URL path names the version explicitly, as in /pkg/path@version/dir.
+// The result ok reports whether the URL path belongs to a module at all;
+// md is nil when it does but names a version the server does not have.
+func (d *docs) lookupModule(urlPath string) (md *docs, name string, explicit, ok bool) {
+	p, ok := strings.CutPrefix(urlPath, "/pkg/")
+	if !ok || len(d.mods) == 0 {
+		return nil, "", false, false
+	}
+	p = strings.Trim(path.Clean("/"+p), "/")
+	if mpath, rest, ok := strings.Cut(p, "@"); ok {
+		list := d.mods[mpath]
+		if list == nil {
+			return nil, "", false, false
+		}
+		version, name, _ := strings.Cut(rest, "/")
+		for _, md := range list {
+			if md.mod.Version == version && version != "" {
+				return md, path.Clean(name), true, true
+			}
+		}
+		return nil, "", true, true
+	}
+	for mpath := p; mpath != "." && mpath != ""; mpath = path.Dir(mpath) {
+		if list := d.mods[mpath]; list != nil {
+			return list[0], path.Clean(strings.TrimPrefix(strings.TrimPrefix(p, mpath), "/")), false, true
+		}
+	}
+	return nil, "", false, false
+}
+
+// moduleURL returns the URL path for name in the module version documented by d,
+// naming the version explicitly if explicit is true.
+func (d *docs) moduleURL(name string, explicit bool) string {
+	u := "/pkg/" + d.mod.Path
+	if explicit && d.mod.Version != "" {
+		u += "@" + d.mod.Version
+	}
+	if name != "." {
+		u += "/" + name
+	}
+	return u
+}
+
+// A VersionLink is a link to the documentation for a package
+// in another version of its module.
+type VersionLink struct {
+	Name    string // version, or "local" for an unversioned tree
+	URL     string // URL path of the package documentation in that version
+	Current bool   // whether this is the version being shown
+}
+
+// serveModule serves the documentation for the directory or file name
+// in the module version documented by md, which is listed in d.
+// If md is nil, the request names an unknown version of a module.
+func (d *docs) serveModule(w http.ResponseWriter, r *http.Request, md *docs, name string, explicit bool) {
+	if md == nil {
+		d.site.ServeErrorStatus(w, r, fmt.Errorf("unknown module version"), http.StatusNotFound)
+		return
+	}
+	fi, err := fs.Stat(md.fs, name)
+	if err != nil {
+		d.site.ServeErrorStatus(w, r, err, http.StatusNotFound)
+		return
+	}
+	if !fi.IsDir() {
+		if path.Ext(name) != ".go" {
+			d.site.ServeErrorStatus(w, r, fmt.Errorf("%s: not a Go source file", name), http.StatusNotFound)
+			return
+		}
+		md.serveSource(w, r, name, explicit)
+		return
+	}
+
+	// Unlike for GOROOT, serve the docs here instead of redirecting to pkg.go.dev,
+	// which may well not have this version of the module,
+	// such as an unversioned local tree or a Git branch,
+	// or may not be reachable from a server documenting private modules.
+	mode := parseMode(r.FormValue("m"))
+	if !strings.HasSuffix(r.URL.Path, "/") {
+		u := *r.URL
+		u.Path += "/"
+		http.Redirect(w, r, u.String(), http.StatusMovedPermanently)
+		return
+	}
+
+	info := md.open(name, mode, r.FormValue("GOOS"), r.FormValue("GOARCH"))
+	if info.Err != nil {
+		log.Print(info.Err)
+		if wantJSON(r) {
+			serveJSONError(w, info.Err, http.StatusInternalServerError)
+			return
+		}
+		d.site.ServeError(w, r, info.Err)
+		return
+	}
+	info.OldDocs = mode&modeOld != 0
+	info.Module = md.mod
+	info.explicit = explicit
+	for _, v := range d.mods[md.mod.Path] {
+		name := v.mod.Version
+		if name == "" {
+			name = "local"
+		}
+		info.Versions = append(info.Versions, VersionLink{
+			Name:    name,
+			URL:     v.moduleURL(info.Dirname, v != d.mods[md.mod.Path][0]) + "/",
+			Current: v == md,
+		})
+	}
+
+	if err := md.comparePorts(info, r.URL.Query()); err != nil {
+		if wantJSON(r) {
+			serveJSONError(w, err, http.StatusBadRequest)
+			return
+		}
+		d.site.ServeErrorStatus(w, r, err, http.StatusBadRequest)
+		return
+	}
+	if wantJSON(r) {
+		md.serveJSON(w, r, info)
+		return
+	}
+
+	importPath := md.importPath(name)
+	var tabtitle, title string
+	switch {
+	case info.PDoc != nil && info.IsMain:
+		tabtitle = path.Base(importPath)
+		title = "Command " + tabtitle
+	case info.PDoc != nil:
+		tabtitle = info.PDoc.Name
+		title = "Package " + tabtitle
+	case name == ".":
+		tabtitle = importPath
+		title = "Module " + importPath
+	default:
+		tabtitle = importPath
+		title = "Directory " + importPath
+	}
+	page := web.Page{
+		"title":    title,
+		"tabTitle": tabtitle,
+		"layout":   "pkg",
+		"pkg":      info,
+	}
+	if info.PDoc != nil {
+		page["summary"] = info.PDoc.Synopsis(info.PDoc.Doc)
+		page["meta"] = map[string]interface{}{
+			"schema": "SoftwareSourceCode",
+		}
+	}
+	d.site.ServePage(w, r, page)
+}
+
+var selRx = regexp.MustCompile(`^([0-9]+):([0-9]+)`)
+
+// serveSource serves the Go source file name in the module version documented by d,
+// formatted as HTML, or as plain text for ?m=text.
+// Like the site's own source files, it accepts ?s=start:end to select a range of bytes.
+func (d *docs) serveSource(w http.ResponseWriter, r *http.Request, name string, explicit bool) {
+	src, err := fs.ReadFile(d.fs, name)
+	if err != nil {
+		d.site.ServeError(w, r, err)
+		return
+	}
+	if r.FormValue("m") == "text" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write(src)
+		return
+	}
+	cfg := texthtml.Config{
+		GoComments: true,
+		Highlight:  r.FormValue("h"),
+		Line:       1,
+	}
+	if m := selRx.FindStringSubmatch(r.FormValue("s")); m != nil {
+		start, _ := strconv.Atoi(m[1])
+		end, _ := strconv.Atoi(m[2])
+		if start < end {
+			cfg.Selection = texthtml.Spans(texthtml.Span{Start: start, End: end})
+		}
+	}
+	var buf bytes.Buffer
+	buf.WriteString("<pre>")
+	buf.Write(texthtml.Format(src, cfg))
+	buf.WriteString("</pre>")
+	fmt.Fprintf(&buf, `<p><a href="%s?m=text">View as plain text</a></p>`, template.HTMLEscapeString(d.moduleURL(name, explicit)))
+
+	d.site.ServePage(w, r, web.Page{
+		"title":    "Text file " + d.importPath(name),
+		"tabTitle": path.Base(name),
+		"layout":   "texthtml",
+		"texthtml": template.HTML(buf.String()),
+	})
+}
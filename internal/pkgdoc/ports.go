@@ -0,0 +1,348 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file contains the code comparing a package's docs across ports.
+
+package pkgdoc
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// A Port is a GOOS/GOARCH pair supported by Go,
+// as listed by “go tool dist list -json”.
+type Port struct {
+	GOOS       string
+	GOARCH     string
+	FirstClass bool
+}
+
+func (p Port) String() string {
+	return p.GOOS + "/" + p.GOARCH
+}
+
+// firstClassPorts lists the first-class ports (see go.dev/wiki/PortingPolicy),
+// for trees that do not list their own ports, such as modules.
+var firstClassPorts = []Port{
+	{"darwin", "amd64", true},
+	{"darwin", "arm64", true},
+	{"linux", "386", true},
+	{"linux", "amd64", true},
+	{"linux", "arm", true},
+	{"linux", "arm64", true},
+	{"windows", "386", true},
+	{"windows", "amd64", true},
+}
+
+// loadPorts returns the ports supported by the Go tree in fsys,
+// sorted by GOOS and then GOARCH.
+// Like “go tool dist list”, it reads them from the tables
+// in src/cmd/dist/build.go: cgoEnabled lists every port,
+// broken the ones to omit, and firstClass the first-class ones.
+// If fsys has no such tables, loadPorts returns firstClassPorts.
+func loadPorts(fsys fs.FS) []Port {
+	data, err := fs.ReadFile(fsys, "src/cmd/dist/build.go")
+	if err != nil {
+		return firstClassPorts
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), "build.go", data, 0)
+	if err != nil {
+		return firstClassPorts
+	}
+
+	tables := make(map[string]map[string]bool)
+	for _, decl := range f.Decls {
+		d, ok := decl.(*ast.GenDecl)
+		if !ok || d.Tok != token.VAR {
+			continue
+		}
+		for _, spec := range d.Specs {
+			vs := spec.(*ast.ValueSpec)
+			if len(vs.Names) != 1 || len(vs.Values) != 1 {
+				continue
+			}
+			lit, ok := vs.Values[0].(*ast.CompositeLit)
+			if !ok {
+				continue
+			}
+			keys := make(map[string]bool)
+			for _, elt := range lit.Elts {
+				kv, ok := elt.(*ast.KeyValueExpr)
+				if !ok {
+					continue
+				}
+				if b, ok := kv.Key.(*ast.BasicLit); ok && b.Kind == token.STRING {
+					if k, err := strconv.Unquote(b.Value); err == nil {
+						keys[k] = true
+					}
+				}
+			}
+			tables[vs.Names[0].Name] = keys
+		}
+	}
+
+	var list []Port
+	for p := range tables["cgoEnabled"] {
+		goos, goarch, ok := strings.Cut(p, "/")
+		if !ok || tables["broken"][p] {
+			continue
+		}
+		list = append(list, Port{goos, goarch, tables["firstClass"][p]})
+	}
+	if len(list) == 0 {
+		return firstClassPorts
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].GOOS != list[j].GOOS {
+			return list[i].GOOS < list[j].GOOS
+		}
+		return list[i].GOARCH < list[j].GOARCH
+	})
+	return list
+}
+
+// A portCache holds the data that comparePorts loads for a docs:
+// the list of ports, loaded when first needed,
+// and the symbols declared by each package directory on each port.
+type portCache struct {
+	once  sync.Once
+	ports []Port
+
+	mu   sync.Mutex
+	syms map[portKey][]Symbol
+}
+
+// maxPortCache is the number of symbol lists a portCache holds.
+// Once full, it is emptied, to bound the memory spent on
+// comparisons of unusual ports or package directories.
+const maxPortCache = 2000
+
+// A portKey identifies the symbols declared by a package directory
+// on a port, with the mode flags affecting which symbols the docs include.
+type portKey struct {
+	dir  string
+	port Port
+	mode mode
+}
+
+// portCache returns the current port cache for d.
+func (d *docs) portCache() *portCache {
+	if c := d.ports.Load(); c != nil {
+		return c
+	}
+	d.ports.CompareAndSwap(nil, &portCache{syms: make(map[portKey][]Symbol)})
+	return d.ports.Load()
+}
+
+// Invalidate makes h, a handler returned by NewServer,
+// discard the data it has cached about the ports and the symbols
+// declared on each one, so that it reloads them from the file system.
+// It should be called after the content of the file system changes.
+func Invalidate(h http.Handler) {
+	d := h.(*docs)
+	d.ports.Store(nil)
+	for _, list := range d.mods {
+		for _, md := range list {
+			md.ports.Store(nil)
+		}
+	}
+}
+
+// findPort returns the port in list named by s, like "linux/amd64".
+func findPort(list []Port, s string) (Port, bool) {
+	for _, p := range list {
+		if p.String() == s {
+			return p, true
+		}
+	}
+	return Port{}, false
+}
+
+// A PortRow is a row in the table of symbols
+// declared on only some of the ports compared.
+type PortRow struct {
+	Kind string // "const", "var", "func", "type", "method", or "field"
+	Name string // name, qualified by its type for a method or field
+	URL  string // URL of the symbol's docs on the first port declaring it
+	On   []bool // whether each port in Page.Ports declares the symbol
+}
+
+// A PortDiff lists the differences between the API surfaces
+// of a package on two ports.
+type PortDiff struct {
+	A, B  string       // the ports, like "linux/amd64"
+	OnlyA []PortSymbol // symbols declared on A but not B
+	OnlyB []PortSymbol // symbols declared on B but not A
+}
+
+// A PortSymbol is a symbol listed in a PortDiff.
+type PortSymbol struct {
+	Kind string
+	Name string
+	URL  string // URL of the symbol's docs on the port declaring it
+}
+
+// comparePorts adds to the docs in p the comparisons requested by the URL query:
+// for ?m=ports, the ports declaring each symbol, among the first-class ports;
+// and for ?diff=a,b (like ?diff=linux/amd64,windows/amd64),
+// the symbols declared on only one of the ports a and b.
+// The docs in p are for the port named by the ?GOOS= and ?GOARCH=
+// parameters, which default to the server's.
+func (d *docs) comparePorts(p *Page, query url.Values) error {
+	diff := query.Get("diff")
+	if p.PDoc == nil || (p.mode&modePorts == 0 && diff == "") {
+		return nil
+	}
+	goos, goarch := query.Get("GOOS"), query.Get("GOARCH")
+	if goos == "" {
+		goos = build.Default.GOOS
+	}
+	if goarch == "" {
+		goarch = build.Default.GOARCH
+	}
+	current := goos + "/" + goarch
+
+	cache := d.portCache()
+	cache.once.Do(func() { cache.ports = loadPorts(d.fs) })
+	all := cache.ports
+	var diffA, diffB Port
+	if diff != "" {
+		a, b, _ := strings.Cut(diff, ",")
+		var okA, okB bool
+		diffA, okA = findPort(all, a)
+		diffB, okB = findPort(all, b)
+		if !okA || !okB || diffA == diffB {
+			return fmt.Errorf("invalid diff=%s: want two different ports like linux/amd64,windows/amd64", diff)
+		}
+	}
+
+	// Cache the symbols on each port, since the two comparisons may share ports,
+	// and later requests compare the same ports again.
+	symMode := p.mode & (modeAll | modeBuiltin)
+	symbols := func(port Port) []Symbol {
+		key := portKey{p.Dirname, port, symMode}
+		cache.mu.Lock()
+		syms, ok := cache.syms[key]
+		cache.mu.Unlock()
+		if ok {
+			return syms
+		}
+		if port.String() == current {
+			syms = packageSymbols(p)
+		} else if info := d.open(p.Dirname, p.mode&^modePorts, port.GOOS, port.GOARCH); info.PDoc != nil {
+			syms = packageSymbols(info)
+		}
+		cache.mu.Lock()
+		if len(cache.syms) >= maxPortCache {
+			clear(cache.syms)
+		}
+		cache.syms[key] = syms
+		cache.mu.Unlock()
+		return syms
+	}
+	link := func(port Port, sym Symbol) string {
+		_, anchor, _ := strings.Cut(sym.URL, "#")
+		if port.String() == current {
+			return "#" + anchor
+		}
+		return p.portURL(port) + "#" + anchor
+	}
+
+	if p.mode&modePorts != 0 {
+		for _, port := range all {
+			if port.FirstClass {
+				p.Ports = append(p.Ports, port.String())
+			}
+		}
+		// Link each symbol to its docs on this page if it is declared
+		// on the current port, or else on the first port declaring it.
+		index := make(map[string]int)
+		var rows []PortRow
+		for i, s := range p.Ports {
+			port, _ := findPort(all, s)
+			for _, sym := range symbols(port) {
+				j, ok := index[sym.Name]
+				if !ok {
+					j = len(rows)
+					index[sym.Name] = j
+					rows = append(rows, PortRow{Kind: sym.Kind, Name: sym.Name, URL: link(port, sym), On: make([]bool, len(p.Ports))})
+				} else if s == current {
+					rows[j].URL = link(port, sym)
+				}
+				rows[j].On[i] = true
+			}
+		}
+		p.portsOf = make(map[string][]string)
+		for _, row := range rows {
+			var ports []string
+			for i, ok := range row.On {
+				if ok {
+					ports = append(ports, p.Ports[i])
+				}
+			}
+			if len(ports) < len(p.Ports) {
+				p.PortRows = append(p.PortRows, row)
+				p.portsOf[row.Name] = ports
+			}
+		}
+		sort.Slice(p.PortRows, func(i, j int) bool { return p.PortRows[i].Name < p.PortRows[j].Name })
+	}
+
+	if diff != "" {
+		only := func(x, y Port) []PortSymbol {
+			have := make(map[string]bool)
+			for _, sym := range symbols(y) {
+				have[sym.Name] = true
+			}
+			var list []PortSymbol
+			for _, sym := range symbols(x) {
+				if !have[sym.Name] {
+					list = append(list, PortSymbol{sym.Kind, sym.Name, link(x, sym)})
+				}
+			}
+			sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+			return list
+		}
+		p.Diff = &PortDiff{
+			A:     diffA.String(),
+			B:     diffB.String(),
+			OnlyA: only(diffA, diffB),
+			OnlyB: only(diffB, diffA),
+		}
+	}
+	return nil
+}
+
+// portURL returns the URL of the docs in p for the given port.
+func (p *Page) portURL(port Port) string {
+	d := p.docs
+	u := "/pkg/" + d.importPath(p.Dirname) + "/"
+	if d.mod != nil {
+		u = d.moduleURL(p.Dirname, p.explicit) + "/"
+	}
+	q := "?GOOS=" + url.QueryEscape(port.GOOS) + "&GOARCH=" + url.QueryEscape(port.GOARCH)
+	if m := p.mode.String(); m != "" {
+		q += "&m=" + m
+	}
+	return u + q
+}
+
+// PortsOf returns the ports declaring the symbol with the given name
+// (qualified by its type for a method), as a list like "linux/amd64, linux/arm64",
+// if it is declared on only some of the ports compared for ?m=ports.
+// Otherwise PortsOf returns the empty string.
+func (p *Page) PortsOf(name string) string {
+	return strings.Join(p.portsOf[name], ", ")
+}
@@ -0,0 +1,162 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkgdoc
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"golang.org/x/website/internal/web"
+)
+
+var portsFS = fstest.MapFS{
+	"src/cmd/dist/build.go": {Data: []byte(`package main
+
+var cgoEnabled = map[string]bool{
+	"linux/amd64":   true,
+	"plan9/amd64":   false,
+	"linux/sparc64": true,
+	"windows/amd64": true,
+}
+
+var broken = map[string]bool{
+	"linux/sparc64": true,
+}
+
+var firstClass = map[string]bool{
+	"linux/amd64":   true,
+	"windows/amd64": true,
+}
+`)},
+	"src/p/p.go": {Data: []byte(`// Package p is a test.
+package p
+
+// Common is on every port.
+func Common() {}
+
+// T is on every port.
+type T struct {
+	Name string
+}
+`)},
+	"src/p/p_linux.go": {Data: []byte(`package p
+
+// Linux is only on Linux.
+func Linux() {}
+
+// Fd is only on Linux.
+func (t *T) Fd() int { return 0 }
+`)},
+	"src/p/p_windows.go": {Data: []byte(`package p
+
+// Windows is only on Windows.
+func Windows() {}
+`)},
+	"src/p/p_plan9.go": {Data: []byte(`package p
+
+// Plan9 is only on Plan 9.
+func Plan9() {}
+`)},
+}
+
+func TestLoadPorts(t *testing.T) {
+	want := []Port{
+		{"linux", "amd64", true},
+		{"plan9", "amd64", false},
+		{"windows", "amd64", true},
+	}
+	if got := loadPorts(portsFS); !reflect.DeepEqual(got, want) {
+		t.Errorf("loadPorts = %v, want %v", got, want)
+	}
+	if got := loadPorts(fstest.MapFS{}); !reflect.DeepEqual(got, firstClassPorts) {
+		t.Errorf("loadPorts(empty) = %v, want firstClassPorts", got)
+	}
+}
+
+func TestComparePorts(t *testing.T) {
+	fsys := maps.Clone(portsFS)
+	h, err := NewServer(fsys, web.NewSite(fsys), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func(url string, code int, v interface{}) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+		if w.Code != code {
+			t.Fatalf("GET %s: code %d, want %d\n%s", url, w.Code, code, w.Body)
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+			t.Fatalf("GET %s: %v\n%s", url, err, w.Body)
+		}
+	}
+
+	var pkg jsonPackage
+	get("/pkg/p/?json&m=ports&GOOS=linux&GOARCH=amd64", 200, &pkg)
+	if want := []string{"linux/amd64", "windows/amd64"}; !slices.Equal(pkg.Ports, want) {
+		t.Errorf("Ports = %v, want %v", pkg.Ports, want)
+	}
+	ports := make(map[string][]string)
+	for _, s := range pkg.Funcs {
+		ports[s.Name] = s.Ports
+	}
+	for _, s := range pkg.Types {
+		ports[s.Name] = s.Ports
+		for _, m := range s.Methods {
+			ports[s.Name+"."+m.Name] = m.Ports
+		}
+	}
+	wantPorts := map[string][]string{
+		"Common": nil,
+		"Linux":  {"linux/amd64"},
+		"T":      nil,
+		"T.Fd":   {"linux/amd64"},
+	}
+	if !reflect.DeepEqual(ports, wantPorts) {
+		t.Errorf("symbol ports = %v, want %v", ports, wantPorts)
+	}
+
+	pkg = jsonPackage{}
+	get("/pkg/p/?json&diff=linux/amd64,plan9/amd64&GOOS=linux&GOARCH=amd64", 200, &pkg)
+	wantDiff := &PortDiff{
+		A: "linux/amd64",
+		B: "plan9/amd64",
+		OnlyA: []PortSymbol{
+			{"func", "Linux", "#Linux"},
+			{"method", "T.Fd", "#T.Fd"},
+		},
+		OnlyB: []PortSymbol{
+			{"func", "Plan9", "/pkg/p/?GOOS=plan9&GOARCH=amd64#Plan9"},
+		},
+	}
+	if !reflect.DeepEqual(pkg.Diff, wantDiff) {
+		t.Errorf("Diff = %+v, want %+v", pkg.Diff, wantDiff)
+	}
+
+	var e struct{ Error string }
+	for _, diff := range []string{"linux/amd64", "linux/amd64,linux/amd64", "linux/amd64,linux/sparc64"} {
+		get("/pkg/p/?json&diff="+diff, 400, &e)
+	}
+
+	// The symbols on each port are cached until Invalidate.
+	fsys["src/p/p_plan9.go"] = &fstest.MapFile{Data: []byte("package p\n\nfunc Plan9() {}\n\nfunc Plan9Too() {}\n")}
+	pkg = jsonPackage{}
+	get("/pkg/p/?json&diff=linux/amd64,plan9/amd64&GOOS=linux&GOARCH=amd64", 200, &pkg)
+	if !reflect.DeepEqual(pkg.Diff, wantDiff) {
+		t.Errorf("Diff before Invalidate = %+v, want cached %+v", pkg.Diff, wantDiff)
+	}
+	Invalidate(h)
+	pkg = jsonPackage{}
+	get("/pkg/p/?json&diff=linux/amd64,plan9/amd64&GOOS=linux&GOARCH=amd64", 200, &pkg)
+	wantDiff.OnlyB = append(wantDiff.OnlyB, PortSymbol{"func", "Plan9Too", "/pkg/p/?GOOS=plan9&GOARCH=amd64#Plan9Too"})
+	if !reflect.DeepEqual(pkg.Diff, wantDiff) {
+		t.Errorf("Diff after Invalidate = %+v, want %+v", pkg.Diff, wantDiff)
+	}
+}
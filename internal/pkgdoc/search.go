@@ -0,0 +1,216 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkgdoc
+
+import (
+	"go/ast"
+	"go/doc"
+	"go/token"
+	"io/fs"
+	"strings"
+
+	"golang.org/x/website/internal/search"
+)
+
+// SearchDocs returns the search documents for the packages in fsys
+// (a tree in GOROOT layout): one for each package or command,
+// and one for each exported declaration in each package.
+func SearchDocs(fsys fs.FS) []search.Doc {
+	return IndexPackages(fsys, nil)
+}
+
+// IndexPackages walks the packages in fsys (a tree in GOROOT layout) once,
+// returning their search documents, as SearchDocs does, and,
+// if x is not nil, replacing the symbols in x by theirs, as x.Update does.
+func IndexPackages(fsys fs.FS, x *SymbolIndex) []search.Doc {
+	var out []search.Doc
+	var syms []*symbolEntry
+	walkPackages(fsys, func(info *Page) {
+		out = append(out, packageDocs(info)...)
+		if x != nil {
+			syms = append(syms, symbolEntries(info)...)
+		}
+	})
+	if x != nil {
+		x.set(syms)
+	}
+	return out
+}
+
+// walkPackages calls f with the docs for each package in fsys
+// (a tree in GOROOT layout), except for internal and vendor packages.
+func walkPackages(fsys fs.FS, f func(*Page)) {
+	src := newDir(fsys, token.NewFileSet(), "src")
+	if src == nil {
+		return
+	}
+	d := &docs{
+		fs:   fsys,
+		root: &Dir{Path: ".", Dirs: []*Dir{src}},
+	}
+	src.walk(func(dir *Dir, depth int) {
+		if !dir.HasPkg || !d.includePath(dir.Path, 0) {
+			return
+		}
+		var mode mode
+		if dir.Path == "src/builtin" {
+			mode = modeAll | modeBuiltin
+		}
+		info := d.open(dir.Path, mode, "", "")
+		if info.Err != nil || info.PDoc == nil {
+			return
+		}
+		f(info)
+	})
+}
+
+// packageDocs returns the search documents for the package described by info.
+func packageDocs(info *Page) []search.Doc {
+	pdoc := info.PDoc
+	importPath := strings.TrimPrefix(info.Dirname, "src/")
+	url := "/pkg/" + importPath + "/"
+	title := "Package " + importPath
+	if strings.HasPrefix(importPath, "cmd/") {
+		url = "/" + importPath + "/"
+	}
+	if info.IsMain {
+		title = "Command " + importPath[strings.LastIndex(importPath, "/")+1:]
+	}
+	out := []search.Doc{{
+		URL:     url,
+		Title:   title,
+		Body:    pdoc.Doc,
+		Summary: pdoc.Synopsis(pdoc.Doc),
+	}}
+	if info.IsMain {
+		return out
+	}
+
+	packageDecls(info, func(d decl) {
+		if d.member {
+			return
+		}
+		out = append(out, search.Doc{
+			URL:   url + "#" + d.anchor,
+			Title: pdoc.Name + "." + d.name,
+			Body:  d.doc,
+		})
+	})
+	return out
+}
+
+// A decl is an exported declaration in a package.
+type decl struct {
+	kind   string // "const", "var", "func", "type", "method", or "field"
+	name   string // name, qualified by its type for a method or field, like "Server.Shutdown"
+	anchor string // fragment identifying the declaration's docs on the package page
+	doc    string // doc comment
+	member bool   // struct field or interface method, documented as part of its type
+}
+
+// packageDecls calls f for each exported declaration
+// in the package described by info.
+func packageDecls(info *Page, f func(decl)) {
+	pdoc := info.PDoc
+	exported := func(name string) bool {
+		return token.IsExported(name) || info.mode&modeBuiltin != 0
+	}
+	addValues := func(values []*doc.Value) {
+		for _, v := range values {
+			for _, name := range v.Names {
+				if exported(name) {
+					f(decl{kind: v.Decl.Tok.String(), name: name, anchor: name, doc: v.Doc})
+				}
+			}
+		}
+	}
+	addFuncs := func(funcs []*doc.Func) {
+		for _, fn := range funcs {
+			f(decl{kind: "func", name: fn.Name, anchor: fn.Name, doc: fn.Doc})
+		}
+	}
+
+	addValues(pdoc.Consts)
+	addValues(pdoc.Vars)
+	addFuncs(pdoc.Funcs)
+	for _, t := range pdoc.Types {
+		f(decl{kind: "type", name: t.Name, anchor: t.Name, doc: t.Doc})
+		addValues(t.Consts)
+		addValues(t.Vars)
+		addFuncs(t.Funcs)
+		for _, m := range t.Methods {
+			name := t.Name + "." + m.Name
+			f(decl{kind: "method", name: name, anchor: name, doc: m.Doc})
+		}
+		for _, spec := range t.Decl.Specs {
+			ts, ok := spec.(*ast.TypeSpec)
+			if !ok || ts.Name.Name != t.Name {
+				continue
+			}
+			switch typ := ts.Type.(type) {
+			case *ast.StructType:
+				// The docs link to each field of a struct (see texthtml).
+				for _, fld := range typ.Fields.List {
+					for _, id := range fld.Names {
+						if exported(id.Name) {
+							name := t.Name + "." + id.Name
+							f(decl{kind: "field", name: name, anchor: name, doc: fieldDoc(fld), member: true})
+						}
+					}
+				}
+			case *ast.InterfaceType:
+				// Interface methods have no links of their own; use the type's.
+				for _, fld := range typ.Methods.List {
+					for _, id := range fld.Names {
+						if exported(id.Name) {
+							f(decl{kind: "method", name: t.Name + "." + id.Name, anchor: t.Name, doc: fieldDoc(fld), member: true})
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// fieldDoc returns the doc comment for the struct field or interface method f:
+// the comment before it or, failing that, the comment after it on the same line.
+func fieldDoc(f *ast.Field) string {
+	if f.Doc != nil {
+		return f.Doc.Text()
+	}
+	if f.Comment != nil {
+		return f.Comment.Text()
+	}
+	return ""
+}
+
+// URLs returns the URL paths of the documentation pages for the
+// packages in fsys (a tree in GOROOT layout): /pkg/ itself and
+// a page for each directory containing packages, or containing
+// directories that do, except for internal and vendor directories.
+// Commands are served at /cmd/ instead of /pkg/cmd/.
+func URLs(fsys fs.FS) []string {
+	src := newDir(fsys, token.NewFileSet(), "src")
+	if src == nil {
+		return nil
+	}
+	d := &docs{fs: fsys}
+	var out []string
+	src.walk(func(dir *Dir, depth int) {
+		if !d.includePath(dir.Path, 0) {
+			return
+		}
+		importPath := strings.TrimPrefix(strings.TrimPrefix(dir.Path, "src"), "/")
+		switch {
+		case importPath == "":
+			out = append(out, "/pkg/")
+		case importPath == "cmd" || strings.HasPrefix(importPath, "cmd/"):
+			out = append(out, "/"+importPath+"/")
+		default:
+			out = append(out, "/pkg/"+importPath+"/")
+		}
+	})
+	return out
+}
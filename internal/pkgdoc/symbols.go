@@ -0,0 +1,293 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file contains the code for searching for symbols by name.
+
+package pkgdoc
+
+import (
+	"encoding/json"
+	"io/fs"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/website/internal/web"
+)
+
+// A Symbol is a declaration listed in a SymbolIndex.
+type Symbol struct {
+	Kind       string // "const", "var", "func", "type", "method", or "field"
+	Name       string // name, qualified by its type for a method or field, like "Server.Shutdown"
+	Package    string // package name, like "http"
+	ImportPath string // package import path, like "net/http"
+	URL        string // URL path of the symbol's documentation, like "/pkg/net/http/#Server.Shutdown"
+	Synopsis   string // first sentence of the symbol's doc comment
+}
+
+// A SymbolIndex is an index of the exported symbols in the standard library:
+// the constants, variables, functions, types, methods, and struct fields
+// declared by the packages in a GOROOT tree.
+// It is safe for concurrent use by multiple goroutines.
+type SymbolIndex struct {
+	mu   sync.RWMutex
+	syms []*symbolEntry
+}
+
+// A symbolEntry is a symbol in the index,
+// with the lower-case forms of its name used for matching.
+type symbolEntry struct {
+	sym   Symbol
+	forms [4]string // name without type, like "shutdown"; name; package.name; importpath.name
+	depth int       // number of elements in import path
+}
+
+// SetSymbolIndex makes h, a handler returned by NewServer,
+// serve a search of the symbol index x at /pkg/?q=name (see SymbolIndex.Search),
+// using the “pkgsearch” layout with the page keys “query” (a string)
+// and “results” (a []Symbol); the package pages then offer a box
+// for jumping to a symbol.
+// Like the package docs, the search redirects to pkg.go.dev unless ?m=old is set.
+// SetSymbolIndex must be called before h serves any requests.
+func SetSymbolIndex(h http.Handler, x *SymbolIndex) {
+	h.(*docs).symbols = x
+}
+
+// NewSymbolIndex returns a new, empty SymbolIndex.
+// Call Update to fill it.
+func NewSymbolIndex() *SymbolIndex {
+	return new(SymbolIndex)
+}
+
+// Len returns the number of symbols in the index.
+func (x *SymbolIndex) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.syms)
+}
+
+// Update replaces the symbols in the index
+// by those in the packages in fsys (a tree in GOROOT layout).
+// Commands and the packages under cmd/ are not indexed.
+// To index the search documents for the packages too, use IndexPackages,
+// which walks the packages only once for both.
+func (x *SymbolIndex) Update(fsys fs.FS) {
+	var list []*symbolEntry
+	walkPackages(fsys, func(info *Page) {
+		list = append(list, symbolEntries(info)...)
+	})
+	x.set(list)
+}
+
+// set replaces the symbols in the index by list.
+func (x *SymbolIndex) set(list []*symbolEntry) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.syms = list
+}
+
+// symbolEntries returns the index entries for the symbols
+// declared by the package described by info,
+// or nil for a command or a package under cmd/.
+func symbolEntries(info *Page) []*symbolEntry {
+	if info.IsMain || strings.HasPrefix(info.Dirname, "src/cmd/") {
+		return nil
+	}
+	var list []*symbolEntry
+	for _, sym := range packageSymbols(info) {
+		name := strings.ToLower(sym.Name)
+		list = append(list, &symbolEntry{
+			sym: sym,
+			forms: [4]string{
+				name[strings.LastIndex(name, ".")+1:],
+				name,
+				strings.ToLower(sym.Package) + "." + name,
+				strings.ToLower(sym.ImportPath) + "." + name,
+			},
+			depth: strings.Count(sym.ImportPath, "/") + 1,
+		})
+	}
+	return list
+}
+
+// packageSymbols returns the symbols declared by the package described by info.
+func packageSymbols(info *Page) []Symbol {
+	pdoc := info.PDoc
+	importPath := strings.TrimPrefix(info.Dirname, "src/")
+	var out []Symbol
+	packageDecls(info, func(d decl) {
+		out = append(out, Symbol{
+			Kind:       d.kind,
+			Name:       d.name,
+			Package:    pdoc.Name,
+			ImportPath: importPath,
+			URL:        "/pkg/" + importPath + "/#" + d.anchor,
+			Synopsis:   pdoc.Synopsis(d.doc),
+		})
+	})
+	return out
+}
+
+// Match scores, from best to worst.
+const (
+	matchNone  = iota // no match
+	matchExact        // best
+	matchPrefix
+	matchSubstring
+	matchFuzzy
+)
+
+// Search returns up to max symbols matching the query, best first.
+// If max <= 0, Search returns all matching symbols.
+//
+// The query is a name like "Shutdown", optionally qualified by
+// a type, package, or import path, as in "Server.Shutdown", "http.Server.Shutdown",
+// or "net/http.Server.Shutdown", compared ignoring case.
+// Symbols with names equal to the query sort first, followed by those with names
+// beginning with it, those with names containing it, and finally those
+// whose names contain the query's letters in order (a fuzzy match,
+// so that "srvshut" finds Server.Shutdown).
+// Within each group, symbols in shallower import paths
+// (like "net" before "net/http") and then shorter names sort first.
+func (x *SymbolIndex) Search(query string, max int) []Symbol {
+	q := strings.ToLower(strings.TrimSpace(query))
+	if q == "" {
+		return nil
+	}
+
+	type result struct {
+		e     *symbolEntry
+		score int
+	}
+	var results []result
+	x.mu.RLock()
+	for _, e := range x.syms {
+		best := matchNone
+		for _, f := range e.forms {
+			if s := matchSymbol(q, f); s != matchNone && (best == matchNone || s < best) {
+				best = s
+			}
+		}
+		if best != matchNone {
+			results = append(results, result{e, best})
+		}
+	}
+	x.mu.RUnlock()
+
+	sort.Slice(results, func(i, j int) bool {
+		ri, rj := results[i], results[j]
+		if ri.score != rj.score {
+			return ri.score < rj.score
+		}
+		if ri.e.depth != rj.e.depth {
+			return ri.e.depth < rj.e.depth
+		}
+		if len(ri.e.sym.Name) != len(rj.e.sym.Name) {
+			return len(ri.e.sym.Name) < len(rj.e.sym.Name)
+		}
+		if ri.e.sym.ImportPath != rj.e.sym.ImportPath {
+			return ri.e.sym.ImportPath < rj.e.sym.ImportPath
+		}
+		return ri.e.sym.Name < rj.e.sym.Name
+	})
+	if max > 0 && len(results) > max {
+		results = results[:max]
+	}
+	out := make([]Symbol, len(results))
+	for i, r := range results {
+		out[i] = r.e.sym
+	}
+	return out
+}
+
+// matchSymbol returns how well the lower-case query q matches the lower-case name.
+func matchSymbol(q, name string) int {
+	switch {
+	case name == q:
+		return matchExact
+	case strings.HasPrefix(name, q):
+		return matchPrefix
+	case strings.Contains(name, q):
+		return matchSubstring
+	}
+	// Fuzzy match: the bytes of q must appear in name in order.
+	i := 0
+	for j := 0; i < len(q) && j < len(name); j++ {
+		if name[j] == q[i] {
+			i++
+		}
+	}
+	if i == len(q) && len(q) > 1 {
+		return matchFuzzy
+	}
+	return matchNone
+}
+
+// isExact reports whether the query names sym exactly, ignoring case,
+// with or without qualification.
+func isExact(query string, sym Symbol) bool {
+	name := sym.Name
+	for _, f := range []string{name[strings.LastIndex(name, ".")+1:], name, sym.Package + "." + name, sym.ImportPath + "." + name} {
+		if strings.EqualFold(query, f) {
+			return true
+		}
+	}
+	return false
+}
+
+// maxSymbolResults is the number of symbols listed on a search results page.
+const maxSymbolResults = 100
+
+// serveSymbolSearch serves the symbols matching the query q, for /pkg/?q=q.
+// With ?jump=1, if the best match names the query exactly or is the only match,
+// it redirects to that symbol's docs instead (the quick-jump box sets jump=1).
+func (d *docs) serveSymbolSearch(w http.ResponseWriter, r *http.Request, q string, mode mode) {
+	if !wantJSON(r) && mode&modeOld == 0 && (d.forceOld == nil || !d.forceOld(r)) {
+		http.Redirect(w, r, "https://pkg.go.dev/search?m=symbol&q="+url.QueryEscape(q), http.StatusTemporaryRedirect)
+		return
+	}
+
+	var results []Symbol
+	if d.symbols != nil {
+		results = d.symbols.Search(q, maxSymbolResults)
+	}
+	if mode&modeOld != 0 {
+		for i := range results {
+			base, frag, _ := strings.Cut(results[i].URL, "#")
+			results[i].URL = base + "?m=old#" + frag
+		}
+	}
+
+	if wantJSON(r) {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", " ")
+		if err := enc.Encode(results); err != nil {
+			log.Printf("ERROR rendering JSON for symbol search %q: %v", q, err)
+		}
+		return
+	}
+	if r.FormValue("jump") != "" && len(results) > 0 && (len(results) == 1 || isExact(strings.TrimSpace(q), results[0])) {
+		http.Redirect(w, r, results[0].URL, http.StatusFound)
+		return
+	}
+
+	d.site.ServePage(w, r, web.Page{
+		"title":    "Symbols matching " + q,
+		"tabTitle": q,
+		"layout":   "pkgsearch",
+		"query":    q,
+		"results":  results,
+		"oldDocs":  mode&modeOld != 0,
+	})
+}
+
+// SymbolSearch reports whether the page should offer
+// a box for searching the symbol index.
+func (p *Page) SymbolSearch() bool {
+	return p.docs.symbols != nil && p.docs.mod == nil
+}
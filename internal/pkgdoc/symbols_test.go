@@ -0,0 +1,165 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkgdoc
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"golang.org/x/website/internal/web"
+)
+
+var symbolFS = fstest.MapFS{
+	"src/net/net.go": {Data: []byte(`package net
+
+// A Conn is a connection.
+type Conn interface {
+	// Close closes the connection.
+	Close() error
+}
+`)},
+	"src/net/http/server.go": {Data: []byte(`package http
+
+// A Server is an HTTP server.
+type Server struct {
+	Addr string // TCP address to listen on
+	port int
+}
+
+// Shutdown gracefully shuts down the server.
+func (s *Server) Shutdown() error { return nil }
+
+// Close closes the server.
+func (s *Server) Close() error { return nil }
+
+// StatusOK is the status code for success.
+const StatusOK = 200
+
+func helper() {}
+`)},
+	"src/cmd/tool/main.go":      {Data: []byte("package main\n\nfunc Main() {}\n")},
+	"src/internal/x/x.go":       {Data: []byte("package x\n\nfunc Hidden() {}\n")},
+	"site.tmpl":                 {Data: []byte(`{{block "layout" .}}{{end}}`)},
+	"pkgsearch.tmpl":            {Data: []byte(`{{define "layout"}}{{range .results}}{{.URL}} {{.Kind}} {{.Synopsis}}{{"\n"}}{{end}}{{end}}`)},
+	"src/net/http/pprof/doc.go": {Data: []byte("package pprof\n\n// Handler serves profiles.\nfunc Handler() {}\n")},
+}
+
+func TestSymbolIndex(t *testing.T) {
+	x := NewSymbolIndex()
+	x.Update(symbolFS)
+
+	search := func(q string) []string {
+		var names []string
+		for _, s := range x.Search(q, 0) {
+			names = append(names, s.ImportPath+"."+s.Name)
+		}
+		return names
+	}
+	for _, tt := range []struct {
+		query string
+		want  []string
+	}{
+		// Exact matches come first, then shallower import paths.
+		{"close", []string{"net.Conn.Close", "net/http.Server.Close"}},
+		{"Server.Shutdown", []string{"net/http.Server.Shutdown"}},
+		{"http.Server", []string{"net/http.Server", "net/http.Server.Addr", "net/http.Server.Close", "net/http.Server.Shutdown"}},
+		{"srvshut", []string{"net/http.Server.Shutdown"}},
+		{"Addr", []string{"net/http.Server.Addr"}},
+		{"StatusOK", []string{"net/http.StatusOK"}},
+		{"pprof.handler", []string{"net/http/pprof.Handler"}},
+		// Unexported names, internal packages, and commands are not indexed.
+		{"port", nil},
+		{"helper", nil},
+		{"Hidden", nil},
+		{"Main", nil},
+		{"", nil},
+	} {
+		got := search(tt.query)
+		if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("Search(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+
+	syms := x.Search("Server.Addr", 1)
+	if len(syms) != 1 {
+		t.Fatalf("Search(Server.Addr) = %v, want 1 result", syms)
+	}
+	want := Symbol{
+		Kind:       "field",
+		Name:       "Server.Addr",
+		Package:    "http",
+		ImportPath: "net/http",
+		URL:        "/pkg/net/http/#Server.Addr",
+		Synopsis:   "TCP address to listen on",
+	}
+	if syms[0] != want {
+		t.Errorf("Search(Server.Addr) = %+v, want %+v", syms[0], want)
+	}
+
+	// IndexPackages produces the same symbols and search documents
+	// as Update and SearchDocs, in a single walk.
+	y := NewSymbolIndex()
+	docs := IndexPackages(symbolFS, y)
+	if y.Len() != x.Len() {
+		t.Errorf("IndexPackages indexed %d symbols, want %d", y.Len(), x.Len())
+	}
+	var urls []string
+	for _, d := range docs {
+		urls = append(urls, d.URL)
+	}
+	wantURLs := []string{
+		"/cmd/tool/",
+		"/pkg/net/", "/pkg/net/#Conn",
+		"/pkg/net/http/", "/pkg/net/http/#StatusOK", "/pkg/net/http/#Server", "/pkg/net/http/#Server.Close", "/pkg/net/http/#Server.Shutdown",
+		"/pkg/net/http/pprof/", "/pkg/net/http/pprof/#Handler",
+	}
+	if strings.Join(urls, " ") != strings.Join(wantURLs, " ") {
+		t.Errorf("IndexPackages docs = %q, want %q", urls, wantURLs)
+	}
+	if sd := SearchDocs(symbolFS); len(sd) != len(docs) {
+		t.Errorf("SearchDocs returned %d docs, IndexPackages %d", len(sd), len(docs))
+	}
+}
+
+func TestSymbolSearchServer(t *testing.T) {
+	x := NewSymbolIndex()
+	x.Update(symbolFS)
+	site := web.NewSite(symbolFS)
+	h, err := NewServer(symbolFS, site, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetSymbolIndex(h, x)
+
+	for _, tt := range []struct {
+		url  string
+		code int
+		want string // body or redirect location
+	}{
+		{"/pkg/?q=Shutdown", 307, "https://pkg.go.dev/search?m=symbol&q=Shutdown"},
+		{"/pkg/?q=Shutdown&m=old", 200, "/pkg/net/http/?m=old#Server.Shutdown method Shutdown gracefully shuts down the server.\n"},
+		{"/pkg/?q=Shutdown&m=old&jump=1", 302, "/pkg/net/http/?m=old#Server.Shutdown"},
+		{"/pkg/?q=net.conn&m=old&jump=1", 302, "/pkg/net/?m=old#Conn"},
+		{"/pkg/?q=close&m=old&jump=1", 302, "/pkg/net/?m=old#Conn"},
+		{"/pkg/?q=clos&m=old&jump=1", 200, "/pkg/net/?m=old#Conn method Close closes the connection.\n"},
+		{"/pkg/?q=srvshut&json", 200, `"URL": "/pkg/net/http/#Server.Shutdown"`},
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", tt.url, nil))
+		if w.Code != tt.code {
+			t.Errorf("GET %s: code %d, want %d\n%s", tt.url, w.Code, tt.code, w.Body)
+			continue
+		}
+		if tt.code/100 == 3 {
+			if loc := w.Header().Get("Location"); loc != tt.want {
+				t.Errorf("GET %s: Location %q, want %q", tt.url, loc, tt.want)
+			}
+		} else if !strings.Contains(w.Body.String(), tt.want) {
+			t.Errorf("GET %s: body lacks %q:\n%s", tt.url, tt.want, w.Body)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package search implements an in-memory full-text index
+// of web site pages and package documentation.
+//
+// An Index holds a set of documents (Doc values), each identified by its URL.
+// Documents are added and replaced in groups sharing a URL prefix,
+// so that one part of a site (for example /wiki/) can be reindexed
+// without rebuilding the rest of the index.
+//
+// Queries are lists of words. A document matches a query if it contains
+// every word of the query in its title, headings, or body.
+// Matches are ranked by where the words appear (title matches count
+// more than heading matches, which count more than body matches)
+// and by how rare each word is across the index.
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A Doc is a document to be indexed.
+type Doc struct {
+	URL      string   // URL path of document, possibly with #fragment
+	Title    string   // document title
+	Headings []string // section headings
+	Body     string   // plain text of document
+	Summary  string   // optional short description, indexed as body text and shown in results
+}
+
+// A Result is a single search result.
+type Result struct {
+	URL     string
+	Title   string
+	Snippet string  // summary or excerpt of the body near a matching word
+	Score   float64 // relevance; higher is better
+}
+
+// Weights for words appearing in each part of a document.
+const (
+	titleWeight   = 10
+	headingWeight = 4
+	bodyWeight    = 1
+)
+
+// An Index is a full-text index of documents.
+// It is safe for concurrent use by multiple goroutines.
+type Index struct {
+	mu    sync.RWMutex
+	docs  map[string]*entry             // URL -> entry
+	terms map[string]map[*entry]float64 // word -> entry -> weighted count
+}
+
+// An entry is a document in the index.
+type entry struct {
+	doc   Doc
+	terms map[string]float64 // word -> weighted count
+}
+
+// NewIndex returns a new, empty Index.
+func NewIndex() *Index {
+	return &Index{
+		docs:  make(map[string]*entry),
+		terms: make(map[string]map[*entry]float64),
+	}
+}
+
+// Len returns the number of documents in the index.
+func (ix *Index) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return len(ix.docs)
+}
+
+// Update replaces all documents whose URLs begin with prefix
+// by the documents in docs whose URLs begin with prefix.
+// Documents in docs with other URLs are ignored.
+func (ix *Index) Update(prefix string, docs []Doc) {
+	// Analyze documents before taking the lock.
+	var add []*entry
+	for _, d := range docs {
+		if strings.HasPrefix(d.URL, prefix) {
+			add = append(add, newEntry(d))
+		}
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	for url, e := range ix.docs {
+		if strings.HasPrefix(url, prefix) {
+			ix.remove(e)
+		}
+	}
+	for _, e := range add {
+		if old := ix.docs[e.doc.URL]; old != nil {
+			ix.remove(old)
+		}
+		ix.docs[e.doc.URL] = e
+		for t, w := range e.terms {
+			m := ix.terms[t]
+			if m == nil {
+				m = make(map[*entry]float64)
+				ix.terms[t] = m
+			}
+			m[e] = w
+		}
+	}
+}
+
+// remove removes e from the index.
+// ix.mu must be held.
+func (ix *Index) remove(e *entry) {
+	delete(ix.docs, e.doc.URL)
+	for t := range e.terms {
+		m := ix.terms[t]
+		delete(m, e)
+		if len(m) == 0 {
+			delete(ix.terms, t)
+		}
+	}
+}
+
+func newEntry(d Doc) *entry {
+	e := &entry{doc: d, terms: make(map[string]float64)}
+	for _, w := range Words(d.Title) {
+		e.terms[w] += titleWeight
+	}
+	for _, h := range d.Headings {
+		for _, w := range Words(h) {
+			e.terms[w] += headingWeight
+		}
+	}
+	for _, w := range Words(d.Body) {
+		e.terms[w] += bodyWeight
+	}
+	for _, w := range Words(d.Summary) {
+		e.terms[w] += bodyWeight
+	}
+	return e
+}
+
+// Search returns up to max results for the query, best first.
+// If max <= 0, Search returns all results.
+func (ix *Index) Search(query string, max int) []Result {
+	words := Words(query)
+	if len(words) == 0 {
+		return nil
+	}
+
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	scores := make(map[*entry]float64)
+	for i, w := range words {
+		m := ix.terms[w]
+		if len(m) == 0 {
+			return nil
+		}
+		// Rare words count more than common ones.
+		idf := math.Log(1 + float64(len(ix.docs))/float64(len(m)))
+		if i == 0 {
+			for e, n := range m {
+				scores[e] = score(n) * idf
+			}
+			continue
+		}
+		for e := range scores {
+			n, ok := m[e]
+			if !ok {
+				delete(scores, e)
+				continue
+			}
+			scores[e] += score(n) * idf
+		}
+	}
+
+	var out []Result
+	for e, s := range scores {
+		out = append(out, Result{
+			URL:     e.doc.URL,
+			Title:   e.doc.Title,
+			Snippet: snippet(e.doc, words),
+			Score:   s,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		return out[i].URL < out[j].URL
+	})
+	if max > 0 && len(out) > max {
+		out = out[:max]
+	}
+	return out
+}
+
+// score converts a weighted word count into a score,
+// damping the effect of many repetitions.
+func score(n float64) float64 {
+	return 1 + math.Log(n)
+}
+
+// Words splits text into lower-case words for indexing or searching.
+// A word is a sequence of letters, digits, and underscores.
+func Words(text string) []string {
+	f := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	for i, w := range f {
+		f[i] = strings.ToLower(w)
+	}
+	return f
+}
+
+const snippetLen = 200
+
+// snippet returns text to display for d in results for words.
+// It uses the document summary if there is one,
+// or else an excerpt of the body around the first matching word.
+func snippet(d Doc, words []string) string {
+	if d.Summary != "" {
+		return d.Summary
+	}
+	body := strings.Join(strings.Fields(d.Body), " ")
+	if len(body) <= snippetLen {
+		return body
+	}
+	lower := strings.ToLower(body)
+	start := 0
+	for _, w := range words {
+		if i := strings.Index(lower, w); i >= 0 && i < len(body) {
+			start = i
+			break
+		}
+	}
+	// Back up to a word boundary a little before the match.
+	start -= snippetLen / 4
+	if start < 0 {
+		start = 0
+	} else if i := strings.IndexByte(body[start:], ' '); i >= 0 {
+		start += i + 1
+	}
+	end := start + snippetLen
+	if end >= len(body) {
+		end = len(body)
+	} else if i := strings.LastIndexByte(body[start:end], ' '); i > 0 {
+		end = start + i
+	}
+	for start < end && !utf8.RuneStart(body[start]) {
+		start++
+	}
+	for end < len(body) && !utf8.RuneStart(body[end]) {
+		end--
+	}
+	s := body[start:end]
+	if start > 0 {
+		s = "…" + s
+	}
+	if end < len(body) {
+		s += "…"
+	}
+	return s
+}
@@ -0,0 +1,138 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package search
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"golang.org/x/website/internal/web"
+)
+
+var testFS = fstest.MapFS{
+	"site.tmpl":   {Data: []byte(`{{block "layout" .}}{{.Content}}{{end}}`)},
+	"search.tmpl": {Data: []byte(`{{define "layout"}}{{range .results}}[{{.URL}}]{{end}}{{end}}`)},
+	"doc/modules.md": {Data: []byte(`---
+title: Managing dependencies
+summary: How to manage module dependencies.
+---
+
+# Adding a dependency
+
+Use go get to add a module requirement.
+`)},
+	"doc/gc.html": {Data: []byte(`<!--{
+	"Title": "A guide to the Go garbage collector"
+}-->
+<h2 id="tuning">Tuning the collector</h2>
+<p>Set GOGC to trade memory for CPU.</p>
+<p>Modules do not matter here.</p>
+`)},
+	"doc/old.md":      {Data: []byte("---\ntitle: Old\nredirect: /doc/modules\n---\nmodule\n")},
+	"doc/untitled.md": {Data: []byte("module\n")},
+	"wiki/Modules.md": {Data: []byte("---\ntitle: Go Modules wiki\n---\nSee module docs.\n")},
+}
+
+func TestSiteDocs(t *testing.T) {
+	site := web.NewSite(testFS)
+	docs, err := SiteDocs(site, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var urls []string
+	for _, d := range docs {
+		urls = append(urls, d.URL)
+	}
+	if have, want := strings.Join(urls, " "), "/doc/gc /doc/modules /wiki/Modules"; have != want {
+		t.Fatalf("SiteDocs URLs = %s, want %s", have, want)
+	}
+	if have := docs[0].Headings; len(have) != 1 || have[0] != "Tuning the collector" {
+		t.Errorf("gc headings = %q, want [Tuning the collector]", have)
+	}
+	if have := docs[1].Headings; len(have) != 1 || have[0] != "Adding a dependency" {
+		t.Errorf("modules headings = %q, want [Adding a dependency]", have)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	site := web.NewSite(testFS)
+	docs, err := SiteDocs(site, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ix := NewIndex()
+	ix.Update("", docs)
+
+	results := func(q string) string {
+		var urls []string
+		for _, r := range ix.Search(q, 0) {
+			urls = append(urls, r.URL)
+		}
+		return strings.Join(urls, " ")
+	}
+
+	tests := []struct {
+		q    string
+		want string
+	}{
+		{"GOGC", "/doc/gc"},
+		{"gogc memory", "/doc/gc"},
+		{"gogc dependency", ""},
+		{"modules", "/wiki/Modules /doc/gc"}, // title before body
+		{"dependency", "/doc/modules"},       // heading
+		{"manage", "/doc/modules"},           // summary
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if have := results(tt.q); have != tt.want {
+			t.Errorf("Search(%q) = %q, want %q", tt.q, have, tt.want)
+		}
+	}
+
+	// Replacing the wiki leaves the other documents alone.
+	ix.Update("/wiki/", []Doc{{URL: "/wiki/GOGC", Title: "GOGC"}, {URL: "/doc/ignored", Title: "GOGC"}})
+	if have, want := results("gogc"), "/wiki/GOGC /doc/gc"; have != want {
+		t.Errorf("after Update, Search(gogc) = %q, want %q", have, want)
+	}
+	if have, want := results("modules"), "/doc/gc"; have != want {
+		t.Errorf("after Update, Search(modules) = %q, want %q", have, want)
+	}
+	if have, want := ix.Len(), 3; have != want {
+		t.Errorf("after Update, Len() = %d, want %d", have, want)
+	}
+}
+
+func TestServer(t *testing.T) {
+	site := web.NewSite(testFS)
+	docs, err := SiteDocs(site, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ix := NewIndex()
+	ix.Update("", docs)
+	srv := NewServer(site, ix)
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest("GET", "/search?q=gogc", nil))
+	if have, want := rec.Body.String(), "[/doc/gc]"; have != want {
+		t.Errorf("GET /search?q=gogc = %q, want %q", have, want)
+	}
+
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest("GET", "/search?q=gogc&json", nil))
+	var out struct {
+		Query   string
+		Results []Result
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Query != "gogc" || len(out.Results) != 1 || out.Results[0].Title != "A guide to the Go garbage collector" {
+		t.Errorf("GET /search?q=gogc&json = %+v", out)
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package search
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"golang.org/x/website/internal/web"
+)
+
+const maxResults = 50
+
+type server struct {
+	site  *web.Site
+	index *Index
+}
+
+// NewServer returns an HTTP handler serving search results from index.
+//
+// The handler responds to requests with a q=query URL parameter.
+// By default, it renders the results as a page using the “search” layout
+// in site, with the page keys “query” (a string) and “results” (a []Result).
+// With a ?json URL parameter, like the site's other JSON endpoints,
+// it instead responds with JSON of the form
+//
+//	{"Query": "...", "Results": [{"URL": ..., "Title": ..., "Snippet": ..., "Score": ...}, ...]}
+func NewServer(site *web.Site, index *Index) http.Handler {
+	return &server{site, index}
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := strings.TrimSpace(r.FormValue("q"))
+	results := s.index.Search(q, maxResults)
+
+	if r.URL.Query().Has("json") {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", " ")
+		err := enc.Encode(struct {
+			Query   string
+			Results []Result
+		}{q, results})
+		if err != nil {
+			log.Printf("ERROR rendering JSON for search %q: %v", q, err)
+		}
+		return
+	}
+
+	title := "Search"
+	if q != "" {
+		title = "Search results for " + q
+	}
+	s.site.ServePage(w, r, web.Page{
+		"title":   title,
+		"layout":  "search",
+		"query":   q,
+		"results": results,
+	})
+}
@@ -0,0 +1,108 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package search
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"golang.org/x/website/internal/web"
+)
+
+// SiteDocs returns the documents for the pages
+// in the file tree rooted at dir in site.
+// Pages without a title, redirects, unpublished pages,
+// and pages with a non-200 status are omitted.
+// Pages that cannot be loaded are reported in the returned error,
+// but they do not stop the walk.
+func SiteDocs(site *web.Site, dir string) ([]Doc, error) {
+	var docs []Doc
+	var errs []string
+	err := site.WalkPages(dir, func(file string, p web.Page, err error) error {
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", file, err))
+			return nil
+		}
+		if d, ok := PageDoc(p); ok {
+			docs = append(docs, d)
+		}
+		return nil
+	})
+	if err == nil && len(errs) > 0 {
+		err = fmt.Errorf("%s", strings.Join(errs, "\n"))
+	}
+	return docs, err
+}
+
+// PageDoc returns the document for the page p,
+// or ok=false if the page should not be indexed.
+func PageDoc(p web.Page) (d Doc, ok bool) {
+	title, _ := p["title"].(string)
+	url, _ := p["URL"].(string)
+	if title == "" || url == "" {
+		return Doc{}, false
+	}
+	if redir, _ := p["redirect"].(string); redir != "" {
+		return Doc{}, false
+	}
+	if !web.Published(p) {
+		return Doc{}, false
+	}
+	if status, ok := p["status"].(int); ok && status != 200 {
+		return Doc{}, false
+	}
+	file, _ := p["File"].(string)
+	data, _ := p["FileData"].(string)
+	summary, _ := p["summary"].(string)
+
+	data = templateRE.ReplaceAllString(data, " ")
+	var headings []string
+	if strings.HasSuffix(file, ".md") {
+		for _, m := range mdHeadingRE.FindAllStringSubmatch(data, -1) {
+			headings = append(headings, mdHeadingIDRE.ReplaceAllString(m[1], ""))
+		}
+		data = mdText(data)
+	}
+	for _, m := range htmlHeadingRE.FindAllStringSubmatch(data, -1) {
+		headings = append(headings, plainText(m[1]))
+	}
+	for i, h := range headings {
+		headings[i] = strings.TrimSpace(h)
+	}
+
+	return Doc{
+		URL:      url,
+		Title:    title,
+		Headings: headings,
+		Body:     plainText(data),
+		Summary:  summary,
+	}, true
+}
+
+var (
+	templateRE    = regexp.MustCompile(`(?s)\{\{.*?\}\}`)
+	mdHeadingRE   = regexp.MustCompile(`(?m)^#{1,6}[ \t]+(.*?)[ \t#]*$`)
+	mdHeadingIDRE = regexp.MustCompile(`\{#[^}]*\}`)
+	htmlHeadingRE = regexp.MustCompile(`(?is)<h[1-6][^>]*>(.*?)</h[1-6]>`)
+	htmlTagRE     = regexp.MustCompile(`(?s)<[^>]*>`)
+	mdLinkRE      = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+	mdMarkupRE    = regexp.MustCompile("(?m)^[ \t]*(#{1,6}|>|[-*+]|[0-9]+\\.)[ \t]|[`*]+")
+)
+
+// mdText returns the Markdown source s with the most common markup removed,
+// so that result snippets read as plain text.
+func mdText(s string) string {
+	s = mdHeadingIDRE.ReplaceAllString(s, "")
+	s = mdLinkRE.ReplaceAllString(s, "$1")
+	return mdMarkupRE.ReplaceAllString(s, "")
+}
+
+// plainText returns the text of the HTML source s
+// with tags removed and entities decoded.
+func plainText(s string) string {
+	return html.UnescapeString(htmlTagRE.ReplaceAllString(s, " "))
+}
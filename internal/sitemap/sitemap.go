@@ -0,0 +1,248 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package sitemap serves a sitemap listing the pages of a web site,
+// in the format described at https://www.sitemaps.org/protocol.html,
+// along with a robots.txt file that points crawlers to it.
+//
+// The sitemap lists every page loaded from a .md or .html file in the site,
+// except unpublished pages (see web.Published)
+// and pages with redirect: or a status: other than 200 in their metadata,
+// and any additional URLs supplied by the site's owner, such as the
+// package documentation pages. A page's lastmod time is its date: metadata,
+// as in blog posts, or else the modification time of its file, if known.
+//
+// A sitemap can list at most 50,000 URLs. For larger sites,
+// /sitemap.xml is a sitemap index, referring to a sequence of sitemaps
+// /sitemap/1.xml, /sitemap/2.xml, and so on.
+package sitemap
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/website/internal/web"
+)
+
+// maxURLs is the maximum number of URLs in a single sitemap.
+// It is a variable so that tests can change it.
+var maxURLs = 50000
+
+// A URL is a single entry in a sitemap.
+type URL struct {
+	Loc     string `xml:"loc"`               // absolute URL
+	LastMod string `xml:"lastmod,omitempty"` // date of last change, as YYYY-MM-DD
+}
+
+// A Server serves the sitemap and robots.txt for a web site.
+type Server struct {
+	fs      fs.FS           // site content
+	baseURL string          // URL prefix, like "https://go.dev"
+	extra   func() []string // additional URL paths to list
+	lang    string          // default language; see SetLanguages
+	langs   []string        // other languages
+
+	mu      sync.Mutex
+	urls    []URL     // sitemap entries; nil until first needed
+	expires time.Time // when a scheduled page is published, invalidating urls; zero if none
+}
+
+// NewServer returns a Server listing the pages in the file system fsys,
+// as served by a web.Site, with URLs beginning with baseURL
+// (for example, "https://go.dev"), followed by the URL paths
+// returned by extra, if extra is not nil.
+// The list is computed on first use; call Invalidate to recompute it
+// after fsys changes. It is also recomputed when a page scheduled
+// for a future date is published.
+func NewServer(fsys fs.FS, baseURL string, extra func() []string) *Server {
+	return &Server{
+		fs:      fsys,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		extra:   extra,
+	}
+}
+
+// SetLanguages declares the site's languages, as in web.Site.SetLanguages,
+// so that pages in other languages are not listed as separate pages.
+// SetLanguages must be called before the sitemap is first used.
+func (s *Server) SetLanguages(def string, others ...string) {
+	s.lang = def
+	s.langs = others
+}
+
+// RegisterHandlers registers s on mux to serve /sitemap.xml,
+// the /sitemap/ directory, and /robots.txt,
+// using host as a host prefix on the registered paths.
+func (s *Server) RegisterHandlers(mux *http.ServeMux, host string) {
+	mux.Handle(host+"/sitemap.xml", s)
+	mux.Handle(host+"/sitemap/", s)
+	mux.Handle(host+"/robots.txt", s)
+}
+
+// Invalidate discards the list of pages, so that the next request recomputes it.
+func (s *Server) Invalidate() {
+	s.mu.Lock()
+	s.urls = nil
+	s.expires = time.Time{}
+	s.mu.Unlock()
+}
+
+// URLs returns the sitemap entries, sorted by URL.
+func (s *Server) URLs() []URL {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.urls == nil || !s.expires.IsZero() && !time.Now().Before(s.expires) {
+		start := time.Now()
+		s.urls, s.expires = s.build()
+		log.Printf("sitemap: listed %d URLs for %s in %v", len(s.urls), s.baseURL, time.Since(start).Round(time.Millisecond))
+	}
+	return s.urls
+}
+
+// build returns the sitemap entries for the site,
+// along with the time at which the first page scheduled
+// for the future is published, or the zero time if there is none.
+func (s *Server) build() ([]URL, time.Time) {
+	var expires time.Time
+	seen := make(map[string]bool)
+	urls := []URL{} // not nil, even if empty, to mark as built
+	add := func(path, lastmod string) {
+		if !seen[path] {
+			seen[path] = true
+			urls = append(urls, URL{Loc: s.baseURL + path, LastMod: lastmod})
+		}
+	}
+	// Use a fresh site to load the pages, so that no cached pages
+	// from before a file system change are used.
+	site := web.NewSite(s.fs)
+	if s.lang != "" {
+		site.SetLanguages(s.lang, s.langs...)
+	}
+	err := site.WalkPages(".", func(file string, p web.Page, err error) error {
+		if err != nil {
+			log.Printf("sitemap: %s: %v", file, err)
+			return nil
+		}
+		if t := web.Scheduled(p); !t.IsZero() && (expires.IsZero() || t.Before(expires)) {
+			expires = t
+		}
+		if path, lastmod, ok := pageURL(p); ok {
+			add(path, lastmod)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("sitemap: %v", err)
+	}
+	if s.extra != nil {
+		for _, path := range s.extra() {
+			add(path, "")
+		}
+	}
+	sort.Slice(urls, func(i, j int) bool { return urls[i].Loc < urls[j].Loc })
+	return urls, expires
+}
+
+// pageURL returns the URL path and lastmod date to list for the page p,
+// or ok=false if the page should not be listed.
+func pageURL(p web.Page) (path, lastmod string, ok bool) {
+	path, _ = p["URL"].(string)
+	if path == "" {
+		return "", "", false
+	}
+	if redir, _ := p["redirect"].(string); redir != "" || !web.Published(p) {
+		return "", "", false
+	}
+	if status, ok := p["status"].(int); ok && status != http.StatusOK {
+		return "", "", false
+	}
+	t, _ := p["date"].(time.Time)
+	if info, ok := p["FileInfo"].(fs.FileInfo); ok && t.IsZero() {
+		t = info.ModTime()
+	}
+	if !t.IsZero() {
+		lastmod = t.UTC().Format(time.DateOnly)
+	}
+	return path, lastmod, true
+}
+
+const xmlns = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+type urlset struct {
+	XMLName xml.Name `xml:"urlset"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	URLs    []URL    `xml:"url"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name `xml:"sitemapindex"`
+	Xmlns    string   `xml:"xmlns,attr"`
+	Sitemaps []URL    `xml:"sitemap"`
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/robots.txt" {
+		s.serveRobots(w, r)
+		return
+	}
+
+	urls := s.URLs()
+	n := (len(urls) + maxURLs - 1) / maxURLs
+	var v any
+	switch {
+	case r.URL.Path == "/sitemap.xml" && n <= 1:
+		v = &urlset{Xmlns: xmlns, URLs: urls}
+	case r.URL.Path == "/sitemap.xml":
+		index := &sitemapIndex{Xmlns: xmlns}
+		for i := 1; i <= n; i++ {
+			index.Sitemaps = append(index.Sitemaps, URL{Loc: fmt.Sprintf("%s/sitemap/%d.xml", s.baseURL, i)})
+		}
+		v = index
+	default:
+		name, ok := strings.CutPrefix(r.URL.Path, "/sitemap/")
+		name, ok2 := strings.CutSuffix(name, ".xml")
+		i, err := strconv.Atoi(name)
+		if !ok || !ok2 || err != nil || i < 1 || i > n || n <= 1 || name != strconv.Itoa(i) {
+			http.NotFound(w, r)
+			return
+		}
+		v = &urlset{Xmlns: xmlns, URLs: urls[(i-1)*maxURLs : min(i*maxURLs, len(urls))]}
+	}
+
+	data, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.Write([]byte(xml.Header))
+	w.Write(data)
+	w.Write([]byte("\n"))
+}
+
+// serveRobots serves robots.txt: the site's own robots.txt file,
+// if it has one, followed by a Sitemap line.
+func (s *Server) serveRobots(w http.ResponseWriter, r *http.Request) {
+	data, err := fs.ReadFile(s.fs, "robots.txt")
+	if err != nil {
+		data = []byte("User-agent: *\nAllow: /\n")
+	}
+	var buf bytes.Buffer
+	buf.Write(data)
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		buf.WriteString("\n")
+	}
+	fmt.Fprintf(&buf, "\nSitemap: %s/sitemap.xml\n", s.baseURL)
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,92 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sitemap
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var testFS = fstest.MapFS{
+	"index.md":           {Data: []byte("Home")},
+	"doc/go1.22.md":      {Data: []byte("Notes"), ModTime: time.Date(2024, 2, 6, 12, 0, 0, 0, time.UTC)},
+	"blog/post.md":       {Data: []byte("---\ndate: 2023-08-14T10:00:00Z\n---\nPost")},
+	"blog/index.html":    {Data: []byte("Blog")},
+	"old.md":             {Data: []byte("---\nredirect: /doc/go1.22\n---\n")},
+	"gone.md":            {Data: []byte("---\nstatus: 404\n---\nGone")},
+	"wiki/Home.md":       {Data: []byte("Wiki")},
+	"_skip/page.md":      {Data: []byte("Skipped")},
+	"robots.txt":         {Data: []byte("User-agent: *\nDisallow: /private/")},
+	"doc/testdata/x.md":  {Data: []byte("Skipped")},
+	"images/gopher.png":  {Data: []byte("PNG")},
+	"doc/gopher/help.md": {Data: []byte("Help")},
+	"blog/draft.md":      {Data: []byte("---\ndraft: true\n---\nDraft")},
+	"blog/future.md":     {Data: []byte("---\ndate: 2999-01-01T00:00:00Z\n---\nFuture")},
+}
+
+func TestURLs(t *testing.T) {
+	s := NewServer(testFS, "https://go.dev/", func() []string { return []string{"/pkg/", "/pkg/fmt/", "/blog/"} })
+	want := []URL{
+		{Loc: "https://go.dev/"},
+		{Loc: "https://go.dev/blog/"},
+		{Loc: "https://go.dev/blog/post", LastMod: "2023-08-14"},
+		{Loc: "https://go.dev/doc/go1.22", LastMod: "2024-02-06"},
+		{Loc: "https://go.dev/doc/gopher/help"},
+		{Loc: "https://go.dev/pkg/"},
+		{Loc: "https://go.dev/pkg/fmt/"},
+		{Loc: "https://go.dev/wiki/Home"},
+	}
+	if diff := cmp.Diff(want, s.URLs()); diff != "" {
+		t.Errorf("URLs (-want +got):\n%s", diff)
+	}
+}
+
+func get(t *testing.T, s *Server, path string) (int, string) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+	return w.Code, w.Body.String()
+}
+
+func TestServe(t *testing.T) {
+	s := NewServer(testFS, "https://go.dev", nil)
+
+	code, body := get(t, s, "/sitemap.xml")
+	if code != 200 || !strings.Contains(body, "<urlset xmlns=\"http://www.sitemaps.org/schemas/sitemap/0.9\">") ||
+		!strings.Contains(body, "<loc>https://go.dev/blog/post</loc>\n    <lastmod>2023-08-14</lastmod>") {
+		t.Errorf("GET /sitemap.xml: %d\n%s", code, body)
+	}
+	if code, _ := get(t, s, "/sitemap/1.xml"); code != 404 {
+		t.Errorf("GET /sitemap/1.xml with one sitemap: %d, want 404", code)
+	}
+
+	code, body = get(t, s, "/robots.txt")
+	if want := "User-agent: *\nDisallow: /private/\n\nSitemap: https://go.dev/sitemap.xml\n"; code != 200 || body != want {
+		t.Errorf("GET /robots.txt: %d %q, want 200 %q", code, body, want)
+	}
+
+	// Large sites get a sitemap index.
+	defer func(old int) { maxURLs = old }(maxURLs)
+	maxURLs = 2
+	code, body = get(t, s, "/sitemap.xml")
+	if code != 200 || !strings.Contains(body, "<sitemapindex") || !strings.Contains(body, "<loc>https://go.dev/sitemap/3.xml</loc>") ||
+		strings.Contains(body, "sitemap/4.xml") {
+		t.Errorf("GET /sitemap.xml with index: %d\n%s", code, body)
+	}
+	code, body = get(t, s, "/sitemap/3.xml")
+	if code != 200 || strings.Count(body, "<url>") != 2 || !strings.Contains(body, "https://go.dev/wiki/Home") {
+		t.Errorf("GET /sitemap/3.xml: %d\n%s", code, body)
+	}
+	for _, path := range []string{"/sitemap/0.xml", "/sitemap/4.xml", "/sitemap/01.xml", "/sitemap/x.xml", "/sitemap/1"} {
+		if code, _ := get(t, s, path); code != 404 {
+			t.Errorf("GET %s: %d, want 404", path, code)
+		}
+	}
+}
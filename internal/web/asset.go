@@ -0,0 +1,286 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package web
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+// assetCacheControl is the Cache-Control header for fingerprinted assets.
+// Their URLs change whenever their content does, so they never go stale.
+const assetCacheControl = "public, max-age=31536000, immutable"
+
+// An asset is a built style sheet or script.
+type asset struct {
+	url   string // fingerprinted URL path, like /js/site.0123456789.js
+	ctype string // Content-Type
+	data  []byte // bundled, minified content
+	hash  string // hex content hash, also used as the ETag
+}
+
+// An assetSet is the result of a call to BuildAssets.
+type assetSet struct {
+	byName map[string]*asset // by logical URL path, like /js/site.js
+	byURL  map[string]*asset // by fingerprinted URL path
+}
+
+// assetLoaders maps the file extensions of assets to the esbuild loaders.
+var assetLoaders = map[string]api.Loader{
+	".css": api.LoaderCSS,
+	".js":  api.LoaderJS,
+	".ts":  api.LoaderTS,
+}
+
+// BuildAssets bundles and minifies the style sheets (.css) and
+// scripts (.js and .ts) in the named directories of the site's file system,
+// replacing the assets from any previous call.
+// See “Assets” in the package doc comment for details.
+//
+// BuildAssets reports the errors from all the assets that fail to build,
+// in which case the site continues to use the assets from the previous call, if any.
+// It is safe to call BuildAssets while serving requests.
+func (s *Site) BuildAssets(dirs ...string) error {
+	var names []string
+	for _, dir := range dirs {
+		err := fs.WalkDir(s.fs, dir, func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && assetLoaders[path.Ext(name)] != api.LoaderNone {
+				names = append(names, "/"+name)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	sort.Strings(names)
+
+	set := &assetSet{
+		byName: make(map[string]*asset),
+		byURL:  make(map[string]*asset),
+	}
+	var errs []error
+	for _, name := range names {
+		a, err := s.buildAsset(name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		set.byName[name] = a
+		set.byURL[a.url] = a
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	s.assets.Store(set)
+	// Pages rendered from now on refer to the new assets.
+	s.gen.Add(1)
+	return nil
+}
+
+// UseAssets makes s serve the assets built by the most recent call
+// to from.BuildAssets, as if s had built them itself,
+// so that sites with the same style sheets and scripts need build them only once.
+// Later calls to from.BuildAssets do not affect s.
+// It is safe to call UseAssets while serving requests.
+func (s *Site) UseAssets(from *Site) {
+	set := from.assets.Load()
+	if set == nil {
+		return
+	}
+	s.assets.Store(set)
+	// Pages rendered from now on refer to the new assets.
+	s.gen.Add(1)
+}
+
+// buildAsset builds the asset with the given logical URL path.
+func (s *Site) buildAsset(name string) (*asset, error) {
+	ext := path.Ext(name)
+	outExt, ctype := ".js", "text/javascript; charset=utf-8"
+	if ext == ".css" {
+		outExt, ctype = ".css", "text/css; charset=utf-8"
+	}
+
+	// The site's scripts are classic scripts sharing global names,
+	// so the bundle keeps the top-level declarations of the file itself:
+	// the ESM output format does not wrap the code in a function,
+	// identifiers are left unminified, and unused code is not removed.
+	// Files it imports are ES modules, contributing only what it uses.
+	result := api.Build(api.BuildOptions{
+		EntryPoints:      []string{name},
+		Bundle:           true,
+		Format:           api.FormatESModule,
+		Target:           api.ES2018,
+		MinifyWhitespace: true,
+		MinifySyntax:     true,
+		Outfile:          "out" + outExt,
+		Write:            false,
+		LogLevel:         api.LogLevelSilent,
+		TreeShaking:      api.TreeShakingFalse,
+		Charset:          api.CharsetUTF8,
+		Metafile:         ext != ".css",
+		Plugins:          []api.Plugin{s.assetPlugin()},
+	})
+	if err := assetErrors(name, result.Errors); err != nil {
+		return nil, err
+	}
+	var data []byte
+	for _, f := range result.OutputFiles {
+		if path.Ext(f.Path) == outExt {
+			data = f.Contents
+		}
+	}
+
+	// A script that imports nothing is not bundled, only minified,
+	// which renames only local identifiers.
+	// Bundling would treat scripts that check for CommonJS,
+	// like jQuery, as CommonJS modules, hiding their globals.
+	if ext != ".css" && !assetImports(result.Metafile, name) {
+		src, err := fs.ReadFile(s.fs, strings.TrimPrefix(name, "/"))
+		if err != nil {
+			return nil, err
+		}
+		result := api.Transform(string(src), api.TransformOptions{
+			Loader:            assetLoaders[ext],
+			Target:            api.ES2018,
+			MinifyWhitespace:  true,
+			MinifySyntax:      true,
+			MinifyIdentifiers: true,
+			Charset:           api.CharsetUTF8,
+			Sourcefile:        name,
+			LogLevel:          api.LogLevelSilent,
+		})
+		if err := assetErrors(name, result.Errors); err != nil {
+			return nil, err
+		}
+		data = result.Code
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:5])
+	return &asset{
+		url:   strings.TrimSuffix(name, ext) + "." + hash + outExt,
+		ctype: ctype,
+		data:  data,
+		hash:  hash,
+	}, nil
+}
+
+// assetErrors returns an error listing the esbuild errors
+// from building the named asset, or nil if there are none.
+func assetErrors(name string, msgs []api.Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	for _, m := range msgs {
+		if m.Location != nil {
+			fmt.Fprintf(&buf, "\n\t%s:%d:%d: %s", m.Location.File, m.Location.Line, m.Location.Column, m.Text)
+		} else {
+			fmt.Fprintf(&buf, "\n\t%s", m.Text)
+		}
+	}
+	return fmt.Errorf("building %s:%s", name, buf.String())
+}
+
+// assetImports reports whether the esbuild metafile
+// lists any imports for the named asset.
+func assetImports(metafile, name string) bool {
+	var meta struct {
+		Inputs map[string]struct {
+			Imports []json.RawMessage
+		}
+	}
+	if err := json.Unmarshal([]byte(metafile), &meta); err != nil {
+		return true
+	}
+	return len(meta.Inputs["site:"+name].Imports) > 0
+}
+
+// assetPlugin returns an esbuild plugin that loads the
+// style sheets and scripts in a bundle from the site's file system.
+// References to other files, such as fonts and images in style sheets,
+// are left as they are.
+func (s *Site) assetPlugin() api.Plugin {
+	return api.Plugin{
+		Name: "site",
+		Setup: func(b api.PluginBuild) {
+			b.OnResolve(api.OnResolveOptions{Filter: ".*"}, func(args api.OnResolveArgs) (api.OnResolveResult, error) {
+				if strings.Contains(args.Path, ":") || assetLoaders[path.Ext(args.Path)] == api.LoaderNone {
+					return api.OnResolveResult{Path: args.Path, External: true}, nil
+				}
+				name := args.Path
+				if !strings.HasPrefix(name, "/") {
+					name = path.Join(path.Dir(args.Importer), name)
+				}
+				return api.OnResolveResult{Path: path.Clean(name), Namespace: "site"}, nil
+			})
+			b.OnLoad(api.OnLoadOptions{Filter: ".*", Namespace: "site"}, func(args api.OnLoadArgs) (api.OnLoadResult, error) {
+				data, err := fs.ReadFile(s.fs, strings.TrimPrefix(args.Path, "/"))
+				if err != nil {
+					return api.OnLoadResult{}, err
+				}
+				text := string(data)
+				return api.OnLoadResult{Contents: &text, Loader: assetLoaders[path.Ext(args.Path)]}, nil
+			})
+		},
+	}
+}
+
+// AssetURL returns the fingerprinted URL path for the asset with
+// the logical URL path name, like /js/site.js.
+// If name is not a built asset, AssetURL returns name unchanged.
+func (s *Site) AssetURL(name string) string {
+	if set := s.assets.Load(); set != nil {
+		if a := set.byName[name]; a != nil {
+			return a.url
+		}
+	}
+	return name
+}
+
+// serveAsset serves the built asset with the fingerprinted URL path
+// of the request, reporting whether there is one.
+func (s *Site) serveAsset(w http.ResponseWriter, r *http.Request) bool {
+	set := s.assets.Load()
+	if set == nil {
+		return false
+	}
+	a := set.byURL[r.URL.Path]
+	if a == nil {
+		return false
+	}
+	h := w.Header()
+	h.Set("Content-Type", a.ctype)
+	h.Set("Cache-Control", assetCacheControl)
+	h.Set("Etag", `"`+a.hash+`"`)
+	http.ServeContent(w, r, a.url, time.Time{}, bytes.NewReader(a.data))
+	return true
+}
+
+// asset returns the fingerprinted URL path for the asset with the
+// logical path name, interpreted relative to the directory of the
+// current page's URL if it does not begin with a slash.
+func (site *siteDir) asset(name string) string {
+	if !strings.HasPrefix(name, "/") {
+		name = "/" + path.Join(site.dir, name)
+	}
+	return site.AssetURL(name)
+}
@@ -0,0 +1,180 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Check loads every page in the file tree rooted at dir, as WalkPages does,
+// including the pages in the site's other languages,
+// and parses the templates needed to render each one, without executing them.
+// It returns the errors found, such as malformed page metadata,
+// missing layouts, and template syntax errors.
+// An error in a template shared by many pages is reported only once.
+func (site *Site) Check(dir string) []error {
+	var errs []error
+	seen := make(map[string]bool)
+	report := func(file string, err error) {
+		msg := err.Error()
+		if !seen[msg] {
+			seen[msg] = true
+			errs = append(errs, fmt.Errorf("%s: %v", file, err))
+		}
+	}
+	site.walkPages(dir, true, func(file string, p Page, err error) error {
+		if err != nil {
+			report(file, err)
+			return nil
+		}
+		if src, _ := p["FileData"].(string); strings.HasPrefix(src, "<!DOCTYPE ") {
+			// Served as is; see serveHTML.
+			return nil
+		}
+		u, _ := p["URL"].(string)
+		r := &http.Request{Method: "GET", URL: &url.URL{Path: u}}
+		if _, _, err := site.parseTemplates(p, "site.tmpl", r); err != nil {
+			report(file, err)
+		}
+		return nil
+	})
+	return errs
+}
+
+// A Diagnostic describes a problem found by Lint.
+type Diagnostic struct {
+	File    string `json:"file"`           // file name in the site's file system
+	Line    int    `json:"line,omitempty"` // line number in File; 0 if unknown
+	Message string `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	if d.Line == 0 {
+		return fmt.Sprintf("%s: %s", d.File, d.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s", d.File, d.Line, d.Message)
+}
+
+// Lint is a more thorough Check: it loads every page in the file tree
+// rooted at dir, in every language, and renders it, executing the templates involved,
+// which checks the code and play invocations in templated pages
+// as well as the page metadata, layouts, and template syntax.
+// It returns the problems found, located by file and line when possible.
+// A problem in a template shared by many pages is reported only once.
+//
+// Pages served without rendering (redirects and standalone HTML) are not rendered.
+// Neither are pages with "cache: false" in their metadata,
+// since they typically depend on data fetched over the network;
+// their templates are only parsed.
+func (site *Site) Lint(dir string) []Diagnostic {
+	var diags []Diagnostic
+	seen := make(map[Diagnostic]bool)
+	report := func(d Diagnostic) {
+		if !seen[d] {
+			seen[d] = true
+			diags = append(diags, d)
+		}
+	}
+	site.walkPages(dir, true, func(file string, p Page, err error) error {
+		if err != nil {
+			line := 0
+			if data, rerr := fs.ReadFile(site.fs, file); rerr == nil {
+				line = metaErrorLine(data, err)
+			}
+			report(Diagnostic{File: file, Line: line, Message: err.Error()})
+			return nil
+		}
+		src, _ := p["FileData"].(string)
+		if redir, _ := p["redirect"].(string); redir != "" || strings.HasPrefix(src, "<!DOCTYPE ") {
+			return nil
+		}
+		u, _ := p["URL"].(string)
+		r := &http.Request{Method: "GET", URL: &url.URL{Path: u}}
+		if p["cache"] == false {
+			_, _, err = site.parseTemplates(p, "site.tmpl", r)
+		} else {
+			_, err = site.renderHTML(p, "site.tmpl", r)
+		}
+		if err != nil {
+			report(site.diagnose(file, err))
+		}
+		return nil
+	})
+	return diags
+}
+
+// templateErrorRE matches the errors reported by text/template
+// for parsing and executing templates, which begin with
+// the template name, the line number, and sometimes the column.
+var templateErrorRE = regexp.MustCompile(`^template: ([^:]+):(\d+):(?:\d+:)? (.*)$`)
+
+// diagnose returns the Diagnostic for an error rendering the page in file.
+func (site *Site) diagnose(file string, err error) Diagnostic {
+	if errors.Is(err, errNoLayout) {
+		data, _ := fs.ReadFile(site.fs, file)
+		return Diagnostic{File: file, Line: metaKeyLine(data, "layout"), Message: err.Error()}
+	}
+	if m := templateErrorRE.FindStringSubmatch(err.Error()); m != nil {
+		// Templates loaded from files are named by the file.
+		// Others are defined by {{define}} or {{block}}
+		// somewhere in the file's templates.
+		if info, err := fs.Stat(site.fs, m[1]); err == nil && !info.IsDir() {
+			line, _ := strconv.Atoi(m[2])
+			return Diagnostic{File: m[1], Line: line, Message: m[3]}
+		}
+	}
+	return Diagnostic{File: file, Message: err.Error()}
+}
+
+// yamlErrorRE matches the line number in a YAML parse error.
+var yamlErrorRE = regexp.MustCompile(`^yaml: line (\d+):`)
+
+// metaErrorLine returns the line number in the page file data
+// of the problem in its metadata reported by err, or 0 if unknown.
+func metaErrorLine(data []byte, err error) int {
+	var syntax *json.SyntaxError
+	var typ *json.UnmarshalTypeError
+	off := -1
+	if errors.As(err, &syntax) {
+		off = int(syntax.Offset)
+	} else if errors.As(err, &typ) {
+		off = int(typ.Offset)
+	}
+	if off >= 0 {
+		// parseMeta unmarshals the JSON starting at the { of <!--{.
+		off += len(jsonStart) - 1
+		return 1 + bytes.Count(data[:min(off, len(data))], []byte("\n"))
+	}
+	if m := yamlErrorRE.FindStringSubmatch(err.Error()); m != nil {
+		// The YAML starts on the line after the opening ---.
+		n, _ := strconv.Atoi(m[1])
+		return n + 1
+	}
+	return 0
+}
+
+// metaKeyLine returns the line number in the page file data
+// of the metadata setting key, or 0 if there is none.
+func metaKeyLine(data []byte, key string) int {
+	for i, line := range strings.Split(string(data), "\n") {
+		if i > 0 && (line == "---" || strings.HasPrefix(line, "}-->")) {
+			break
+		}
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, key+":") || strings.HasPrefix(strings.ToLower(line), `"`+key+`"`) {
+			return i + 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,261 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package web
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SetCacheControl sets the Cache-Control header to send with successful
+// responses (status 200, 206, or 304) to requests whose URL path begins with prefix.
+// If more than one prefix matches, the longest one applies.
+// An empty value removes the policy for prefix.
+// A handler serving a response can override the policy by setting Cache-Control itself.
+// SetCacheControl must not be called concurrently with serving requests.
+func (s *Site) SetCacheControl(prefix, value string) {
+	if value == "" {
+		delete(s.cacheControl, prefix)
+		return
+	}
+	if s.cacheControl == nil {
+		s.cacheControl = make(map[string]string)
+	}
+	s.cacheControl[prefix] = value
+}
+
+// cachePolicy returns the Cache-Control value for the URL path.
+func (s *Site) cachePolicy(path string) string {
+	best, value := -1, ""
+	for prefix, v := range s.cacheControl {
+		if strings.HasPrefix(path, prefix) && len(prefix) > best {
+			best, value = len(prefix), v
+		}
+	}
+	return value
+}
+
+// cacheControlWriter returns a ResponseWriter that writes the response to r to w,
+// adding the Cache-Control header set by SetCacheControl for r's URL path
+// if the response is successful.
+// If there is no such header, or w is already such a ResponseWriter,
+// cacheControlWriter returns w itself.
+func (s *Site) cacheControlWriter(w http.ResponseWriter, r *http.Request) http.ResponseWriter {
+	if _, ok := w.(*ccWriter); ok {
+		return w
+	}
+	cc := s.cachePolicy(r.URL.Path)
+	if cc == "" {
+		return w
+	}
+	return &ccWriter{ResponseWriter: w, cc: cc}
+}
+
+// A ccWriter is an http.ResponseWriter that adds a Cache-Control header
+// to a successful response when its status is written.
+// It does not buffer the response. See Site.cacheControlWriter.
+type ccWriter struct {
+	http.ResponseWriter
+	cc    string // Cache-Control header to add
+	wrote bool   // status has been written
+}
+
+func (cw *ccWriter) WriteHeader(code int) {
+	if !cw.wrote && code >= 200 {
+		cw.wrote = true
+		h := cw.Header()
+		if (code == http.StatusOK || code == http.StatusPartialContent || code == http.StatusNotModified) && h.Get("Cache-Control") == "" {
+			h.Set("Cache-Control", cw.cc)
+		}
+	}
+	cw.ResponseWriter.WriteHeader(code)
+}
+
+func (cw *ccWriter) Write(b []byte) (int, error) {
+	if !cw.wrote {
+		cw.WriteHeader(http.StatusOK)
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+func (cw *ccWriter) Flush() {
+	if !cw.wrote {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying ResponseWriter, for http.ResponseController.
+func (cw *ccWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// pageETag returns the ETag for the page rendered in response to r
+// from the named file, whose content has the given hash.
+// Since the page is rendered using templates, assets, and other files as well
+// (like the pages listed by a blog index), the ETag also depends on
+// the digest of the site's content, along with the request's host and query,
+// which templates can consult.
+// A preview request (see SetPreviewToken) has no ETag,
+// so that pageETag returns the empty string.
+func (s *Site) pageETag(r *http.Request, file, hash string) string {
+	if s.isPreview(r) {
+		return ""
+	}
+	key := fmt.Sprintf("%s\x00%s\x00%s\x00%s\x00%s", s.digest(), file, hash, r.Host, r.URL.Query().Encode())
+	sum := sha256.Sum256([]byte(key))
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// A siteDigest is the digest of the site's content in one content generation.
+type siteDigest struct {
+	gen    int64
+	digest string
+}
+
+// digestExts lists the extensions of the files whose content
+// the site digest includes: the pages, templates, and data files
+// that templates read, and the sources of the assets.
+// For other files, like images, the digest includes only the name,
+// size, and modification time, to avoid reading them all.
+var digestExts = map[string]bool{
+	".md":   true,
+	".html": true,
+	".tmpl": true,
+	".yaml": true,
+	".json": true,
+	".css":  true,
+	".js":   true,
+	".ts":   true,
+}
+
+// digest returns a digest of the site's content: a hash of the name, size,
+// and modification time of every file in the file system, of the content
+// of the files listed in digestExts, and of the URLs of the built assets.
+// The digest is computed once per content generation (see Invalidate),
+// on first use.
+// Unlike the generation, which counts from zero in every process,
+// the digest is the same in every process serving the same content
+// and different in processes serving different content,
+// so that deploying new templates or pages changes the ETags of the pages
+// rendered using them, even when those pages' own files are unchanged.
+func (s *Site) digest() string {
+	gen := s.gen.Load()
+	if d := s.siteDigest.Load(); d != nil && d.gen == gen {
+		return d.digest
+	}
+	s.digestMu.Lock()
+	defer s.digestMu.Unlock()
+	if d := s.siteDigest.Load(); d != nil && d.gen == gen {
+		return d.digest
+	}
+
+	h := sha256.New()
+	fs.WalkDir(s.fs, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00%d\x00", name, info.Size(), info.ModTime().UnixNano())
+		if digestExts[path.Ext(name)] {
+			if data, err := fs.ReadFile(s.fs, name); err == nil {
+				sum := sha256.Sum256(data)
+				h.Write(sum[:])
+			}
+		}
+		return nil
+	})
+	if set := s.assets.Load(); set != nil {
+		var urls []string
+		for url := range set.byURL {
+			urls = append(urls, url)
+		}
+		sort.Strings(urls)
+		fmt.Fprintf(h, "assets\x00%s\x00", strings.Join(urls, "\x00"))
+	}
+	d := &siteDigest{gen: gen, digest: hex.EncodeToString(h.Sum(nil))}
+	s.siteDigest.Store(d)
+	return d.digest
+}
+
+// notModified sets the ETag header of the response to r to etag,
+// unless etag is empty, and reports whether r's If-None-Match header matches it.
+// If so, notModified has written a 304 Not Modified response,
+// and the caller must not write anything more.
+func (s *Site) notModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	w.Header().Set("Etag", etag)
+	if r.Method != "GET" && r.Method != "HEAD" || !etagMatch(r.Header.Get("If-None-Match"), etag) {
+		return false
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// A fileTag is a file's ETag, cached by Site.fileETag.
+type fileTag struct {
+	size    int64
+	modTime time.Time
+	gen     int64
+	etag    string
+}
+
+// fileETag returns the ETag for the named file in the site's file system,
+// which has the given info: a hash of the file's content.
+// The hash is computed once and then reused until the file's size
+// or modification time changes or Invalidate is called.
+// If the file cannot be read, fileETag returns the empty string.
+func (s *Site) fileETag(name string, info fs.FileInfo) string {
+	gen := s.gen.Load()
+	if v, ok := s.fileTags.Load(name); ok {
+		t := v.(*fileTag)
+		if t.size == info.Size() && t.modTime.Equal(info.ModTime()) && t.gen == gen {
+			return t.etag
+		}
+	}
+	f, err := s.fs.Open(name)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return ""
+	}
+	etag := `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+	s.fileTags.Store(name, &fileTag{info.Size(), info.ModTime(), gen, etag})
+	return etag
+}
+
+// etagMatch reports whether the If-None-Match header value list
+// matches etag, using the weak comparison required for If-None-Match.
+func etagMatch(list, etag string) bool {
+	if list == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, e := range strings.Split(list, ",") {
+		e = strings.TrimSpace(e)
+		if e == "*" || strings.TrimPrefix(e, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
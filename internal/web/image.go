@@ -0,0 +1,459 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package web
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"image"
+	"image/draw"
+	_ "image/gif" // for image.Decode
+	"image/jpeg"
+	"image/png"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// imageWidths lists the widths of the resized variants of an image.
+// Only variants narrower than the image itself are made.
+var imageWidths = []int{320, 640, 960, 1280, 1920}
+
+// imageSizes is the sizes attribute for responsive images:
+// the article column is at most 800 pixels wide.
+const imageSizes = "(min-width: 850px) 800px, 100vw"
+
+// imageMemCache is the size of the in-memory cache of derived images.
+const imageMemCache = 64 << 20
+
+// imageVariantRE matches the URL path of a derived image,
+// like /blog/x/fig.png.w640.0123456789.webp: the source image,
+// the width, a hash of the source content, and the format.
+var imageVariantRE = regexp.MustCompile(`^(/.+\.(?:png|jpe?g))\.w([0-9]+)\.([0-9a-f]{10})\.(png|jpg|webp)$`)
+
+// A WebPEncoder writes the image m to w in WebP format.
+type WebPEncoder func(w io.Writer, m image.Image) error
+
+// SetWebPEncoder sets the encoder to use for WebP versions of images,
+// which the “img” template function offers to browsers that accept them.
+// A nil encoder, the default, disables WebP versions.
+// If the encoder fails for an image, a request for the WebP version
+// is served the image in its own format instead.
+// SetWebPEncoder must not be called concurrently with serving requests.
+func (s *Site) SetWebPEncoder(enc WebPEncoder) {
+	s.imgs.webp = enc
+}
+
+// SetImageCache sets a directory in which to keep the derived images,
+// so that they need not be regenerated after a restart.
+// The file names are the hashes of the source images plus the width and format.
+// The empty string, the default, keeps derived images only in memory.
+// SetImageCache must not be called concurrently with serving requests.
+func (s *Site) SetImageCache(dir string) {
+	s.imgs.dir = dir
+}
+
+// An imageSet holds a Site's derived images.
+type imageSet struct {
+	webp WebPEncoder // from SetWebPEncoder
+	dir  string      // from SetImageCache
+
+	group singleflight.Group // generating derived images, by key
+
+	mu      sync.Mutex
+	sources map[string]*imageSource // by file, for the current content
+	size    int64                   // total size of cached derived images
+	lru     list.List               // *derivedImage, most recently used first
+	entries map[imageKey]*list.Element
+}
+
+// An imageSource is a source image in the site.
+type imageSource struct {
+	file   string    // file name in fsys
+	hash   string    // hex content hash
+	modt   time.Time // file modification time when hashed
+	format string    // "png", "jpeg", or "gif"
+	width  int
+	height int
+}
+
+// An imageKey identifies a derived image.
+type imageKey struct {
+	hash   string // source hash
+	width  int
+	format string // "png", "jpg", or "webp"
+}
+
+// A derivedImage is a derived image in the cache.
+type derivedImage struct {
+	key  imageKey
+	data []byte
+}
+
+// newImageSet returns a new, empty imageSet.
+func newImageSet() *imageSet {
+	return &imageSet{
+		sources: make(map[string]*imageSource),
+		entries: make(map[imageKey]*list.Element),
+	}
+}
+
+// invalidate discards the information about source images,
+// which may have changed. The derived images are kept:
+// they are identified by the source content hashes.
+func (set *imageSet) invalidate() {
+	set.mu.Lock()
+	defer set.mu.Unlock()
+	clear(set.sources)
+}
+
+// imageSource returns information about the source image file.
+// It caches the information for as long as the file's
+// modification time stays the same, or until the next Invalidate
+// for file systems without modification times.
+func (s *Site) imageSource(file string) (*imageSource, error) {
+	set := s.imgs
+	info, err := fs.Stat(s.fs, file)
+	if err != nil {
+		return nil, err
+	}
+	set.mu.Lock()
+	src := set.sources[file]
+	set.mu.Unlock()
+	if src != nil && src.modt.Equal(info.ModTime()) {
+		return src, nil
+	}
+
+	data, err := fs.ReadFile(s.fs, file)
+	if err != nil {
+		return nil, err
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	sum := sha256.Sum256(data)
+	src = &imageSource{
+		file:   file,
+		hash:   hex.EncodeToString(sum[:5]),
+		modt:   info.ModTime(),
+		format: format,
+		width:  cfg.Width,
+		height: cfg.Height,
+	}
+	set.mu.Lock()
+	set.sources[file] = src
+	set.mu.Unlock()
+	return src, nil
+}
+
+// variantURL returns the URL of the derived image of src with
+// the given width and format, given the URL of src itself.
+func (src *imageSource) variantURL(url string, width int, format string) string {
+	return fmt.Sprintf("%s.w%d.%s.%s", url, width, src.hash, format)
+}
+
+// variantFormat returns the format of the resized variants of src
+// other than WebP, or "" if src is not resized.
+func (src *imageSource) variantFormat() string {
+	switch src.format {
+	case "png":
+		return "png"
+	case "jpeg":
+		return "jpg"
+	}
+	// GIFs may be animated; leave them alone.
+	return ""
+}
+
+// img returns the HTML for displaying the image file name
+// with the given alternate text, as described in the package doc comment.
+// The URLs in the HTML are relative if name is, like an <img> tag
+// written by hand would be.
+func (site *siteDir) img(name, alt string) (template.HTML, error) {
+	file := name
+	if !strings.HasPrefix(file, "/") {
+		file = path.Join("/", site.dir, file)
+	}
+	file = strings.TrimPrefix(path.Clean(file), "/")
+	src, err := site.imageSource(file)
+	if err != nil {
+		return "", err
+	}
+
+	var widths []int
+	format := src.variantFormat()
+	if format != "" {
+		for _, w := range imageWidths {
+			if w < src.width {
+				widths = append(widths, w)
+			}
+		}
+	}
+	srcset := func(format string) string {
+		var list []string
+		for _, w := range widths {
+			list = append(list, fmt.Sprintf("%s %dw", src.variantURL(name, w, format), w))
+		}
+		if format == src.variantFormat() {
+			list = append(list, fmt.Sprintf("%s %dw", name, src.width))
+		} else {
+			list = append(list, fmt.Sprintf("%s %dw", src.variantURL(name, src.width, format), src.width))
+		}
+		return strings.Join(list, ", ")
+	}
+
+	var b strings.Builder
+	esc := template.HTMLEscapeString
+	webp := format != "" && site.imgs.webp != nil
+	if webp {
+		fmt.Fprintf(&b, `<picture><source type="image/webp" srcset="%s" sizes="%s">`, esc(srcset("webp")), imageSizes)
+	}
+	fmt.Fprintf(&b, `<img src="%s"`, esc(name))
+	if len(widths) > 0 {
+		fmt.Fprintf(&b, ` srcset="%s" sizes="%s"`, esc(srcset(format)), imageSizes)
+	}
+	fmt.Fprintf(&b, ` width="%d" height="%d" alt="%s" loading="lazy" decoding="async">`, src.width, src.height, esc(alt))
+	if webp {
+		b.WriteString(`</picture>`)
+	}
+	return template.HTML(b.String()), nil
+}
+
+// serveImage serves the derived image named by the request URL path,
+// reporting whether the path names one.
+func (s *Site) serveImage(w http.ResponseWriter, r *http.Request) bool {
+	m := imageVariantRE.FindStringSubmatch(r.URL.Path)
+	if m == nil {
+		return false
+	}
+	file, hash, format := strings.TrimPrefix(m[1], "/"), m[3], m[4]
+	width, err := strconv.Atoi(m[2])
+	if err != nil {
+		return false
+	}
+	src, err := s.imageSource(file)
+	if err != nil || src.variantFormat() == "" || format == "webp" && s.imgs.webp == nil || format != "webp" && format != src.variantFormat() {
+		return false
+	}
+	if width != src.width && !slices.Contains(imageWidths, width) || width > src.width {
+		return false
+	}
+	if hash != src.hash {
+		// The image has changed since the page linking to this URL was rendered.
+		http.Redirect(w, r, src.variantURL("/"+file, width, format), http.StatusFound)
+		return true
+	}
+
+	data, err := s.derivedImage(src, width, format)
+	if err != nil && format == "webp" {
+		// The WebP encoder is an external command (see SetWebPEncoder)
+		// that may fail; the browser can use the image in its own format.
+		log.Printf("image: %v; serving %s instead", err, src.variantFormat())
+		format = src.variantFormat()
+		data, err = s.derivedImage(src, width, format)
+	}
+	if err != nil {
+		s.ServeError(w, r, err)
+		return true
+	}
+	ctype := "image/" + format
+	if format == "jpg" {
+		ctype = "image/jpeg"
+	}
+	h := w.Header()
+	h.Set("Content-Type", ctype)
+	h.Set("Cache-Control", assetCacheControl)
+	h.Set("Etag", `"`+hash+"-"+strconv.Itoa(width)+"-"+format+`"`)
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+	return true
+}
+
+// derivedImage returns the image src resized to width and encoded in format,
+// from the cache if possible.
+func (s *Site) derivedImage(src *imageSource, width int, format string) ([]byte, error) {
+	set := s.imgs
+	key := imageKey{src.hash, width, format}
+	if data, ok := set.get(key); ok {
+		return data, nil
+	}
+	v, err, _ := set.group.Do(fmt.Sprint(key), func() (any, error) {
+		var file string
+		if set.dir != "" {
+			file = filepath.Join(set.dir, fmt.Sprintf("%s-w%d.%s", key.hash, key.width, key.format))
+			if data, err := os.ReadFile(file); err == nil {
+				return data, nil
+			}
+		}
+		data, err := s.makeImage(src, width, format)
+		if err != nil {
+			return nil, err
+		}
+		if file != "" {
+			if err := os.WriteFile(file, data, 0666); err != nil {
+				log.Printf("image cache: %v", err)
+			}
+		}
+		return data, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	data := v.([]byte)
+	set.add(key, data)
+	return data, nil
+}
+
+// makeImage returns the image src resized to width and encoded in format.
+func (s *Site) makeImage(src *imageSource, width int, format string) ([]byte, error) {
+	data, err := fs.ReadFile(s.fs, src.file)
+	if err != nil {
+		return nil, err
+	}
+	m, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", src.file, err)
+	}
+	if width != src.width {
+		height := max(1, (src.height*width+src.width/2)/src.width)
+		m = resize(m, width, height)
+	}
+	var buf bytes.Buffer
+	switch format {
+	case "png":
+		enc := png.Encoder{CompressionLevel: png.BestCompression}
+		err = enc.Encode(&buf, m)
+	case "jpg":
+		err = jpeg.Encode(&buf, m, &jpeg.Options{Quality: 85})
+	case "webp":
+		err = s.imgs.webp(&buf, m)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: encoding %s: %v", src.file, format, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// get returns the cached derived image for key, if any.
+func (set *imageSet) get(key imageKey) ([]byte, bool) {
+	set.mu.Lock()
+	defer set.mu.Unlock()
+	e, ok := set.entries[key]
+	if !ok {
+		return nil, false
+	}
+	set.lru.MoveToFront(e)
+	return e.Value.(*derivedImage).data, true
+}
+
+// add adds the derived image data for key to the cache,
+// evicting the least recently used images as needed to make room.
+func (set *imageSet) add(key imageKey, data []byte) {
+	set.mu.Lock()
+	defer set.mu.Unlock()
+	if _, ok := set.entries[key]; ok {
+		return
+	}
+	set.entries[key] = set.lru.PushFront(&derivedImage{key, data})
+	set.size += int64(len(data))
+	for set.size > imageMemCache {
+		e := set.lru.Back()
+		d := e.Value.(*derivedImage)
+		set.lru.Remove(e)
+		delete(set.entries, d.key)
+		set.size -= int64(len(d.data))
+	}
+}
+
+// resize returns m scaled to w×h pixels.
+// Each destination pixel is the average of the source pixels it covers,
+// weighted by the area of overlap, which gives good results
+// when shrinking images, the only use here.
+// This area averaging (a box filter) uses every source pixel,
+// so unlike point sampling or bilinear interpolation it does not alias,
+// however much the image shrinks.
+// The standard library has no scaler, and this short one
+// avoids depending on golang.org/x/image/draw for a single function.
+func resize(m image.Image, w, h int) *image.RGBA {
+	b := m.Bounds()
+	src, ok := m.(*image.RGBA)
+	if !ok || b.Min != (image.Point{}) {
+		src = image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+		draw.Draw(src, src.Bounds(), m, b.Min, draw.Src)
+	}
+	sw, sh := src.Rect.Dx(), src.Rect.Dy()
+
+	// Scale rows horizontally into tmp (w×sh), then columns vertically into dst.
+	// RGBA pixels are alpha-premultiplied, so averaging them directly is correct.
+	tmp := make([]float32, w*sh*4)
+	for x, ws := range resizeWeights(sw, w) {
+		for y := 0; y < sh; y++ {
+			row := src.Pix[y*src.Stride:]
+			var c [4]float32
+			for _, wt := range ws {
+				p := row[wt.i*4 : wt.i*4+4]
+				for k := range c {
+					c[k] += wt.w * float32(p[k])
+				}
+			}
+			copy(tmp[(y*w+x)*4:], c[:])
+		}
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y, ws := range resizeWeights(sh, h) {
+		for x := 0; x < w; x++ {
+			var c [4]float32
+			for _, wt := range ws {
+				p := tmp[(wt.i*w+x)*4:]
+				for k := range c {
+					c[k] += wt.w * p[k]
+				}
+			}
+			for k := range c {
+				dst.Pix[y*dst.Stride+x*4+k] = uint8(min(255, c[k]+0.5))
+			}
+		}
+	}
+	return dst
+}
+
+// A resizeWeight is the weight of source pixel i in a destination pixel.
+type resizeWeight struct {
+	i int
+	w float32
+}
+
+// resizeWeights returns, for each of the dst pixels in a row or column
+// resized from src pixels, the weights of the source pixels it covers.
+func resizeWeights(src, dst int) [][]resizeWeight {
+	scale := float64(src) / float64(dst)
+	weights := make([][]resizeWeight, dst)
+	for i := range weights {
+		lo, hi := float64(i)*scale, float64(i+1)*scale
+		for j := int(lo); j < src && float64(j) < hi; j++ {
+			a, b := max(lo, float64(j)), min(hi, float64(j+1))
+			if b > a {
+				weights[i] = append(weights[i], resizeWeight{j, float32((b - a) / scale)})
+			}
+		}
+	}
+	return weights
+}
@@ -0,0 +1,154 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package web
+
+import (
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// An Alternate is a version of a page in another language,
+// listed in the page's “Alternates” key.
+type Alternate struct {
+	Lang string // language tag, like "zh", or "x-default"
+	URL  string // URL of that version of the page, absolute if the site has a BaseURL (see SetSiteInfo)
+}
+
+// SetLanguages declares that the site's pages are written in the language def
+// and may also be available in each of the languages in others,
+// in files named with the language before the extension,
+// like p.zh.md or p/index.zh.html.
+// Languages are identified by BCP 47 tags, like "en", "zh", or "pt-BR".
+// See the “Languages” section in the package doc comment for details.
+// SetLanguages must not be called concurrently with serving requests.
+func (s *Site) SetLanguages(def string, others ...string) {
+	s.lang = def
+	s.langs = others
+}
+
+// splitLang reports whether file names a page in one of the site's
+// other languages, like p.zh.md, p.zh, or p/index.zh.md.
+// If so, it returns the name of the page in the default language
+// (p.md, p, or p/index.md) and the language.
+// Otherwise it returns file unchanged and an empty language.
+func (s *Site) splitLang(file string) (string, string) {
+	base, ext := file, ""
+	for _, e := range []string{".md", ".html"} {
+		if strings.HasSuffix(file, e) {
+			base, ext = strings.TrimSuffix(file, e), e
+			break
+		}
+	}
+	for _, l := range s.langs {
+		if b, ok := strings.CutSuffix(base, "."+l); ok && b != "" && !strings.HasSuffix(b, "/") {
+			return b + ext, l
+		}
+	}
+	return file, ""
+}
+
+// langFiles returns the files that may hold the page in language lang,
+// given its canonical file name (without extension), in order of preference.
+// If lang is the default language or empty, the files are the usual
+// file.md, file.html, file/index.md, file/index.html.
+func (s *Site) langFiles(file, lang string) []string {
+	suffix := ""
+	if lang != "" && lang != s.lang {
+		suffix = "." + lang
+	}
+	return []string{
+		file + suffix + ".md",
+		file + suffix + ".html",
+		path.Join(file, "index"+suffix+".md"),
+		path.Join(file, "index"+suffix+".html"),
+	}
+}
+
+// hasLangFile reports whether any of the files that may hold
+// the page with the given canonical file name in language lang exists.
+func (s *Site) hasLangFile(file, lang string) bool {
+	for _, f := range s.langFiles(file, lang) {
+		if _, err := fs.Stat(s.fs, f); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// alternates returns the Alternates page key for the page with the given
+// canonical file name and URL path: one entry for each language in which
+// the page exists, plus an x-default entry for the URL without a language.
+// If the page exists only in the default language, alternates returns nil.
+func (s *Site) alternates(file, u string) []Alternate {
+	var list []Alternate
+	for _, l := range s.langs {
+		if s.hasLangFile(file, l) {
+			list = append(list, Alternate{Lang: l, URL: s.info.BaseURL + u + "?hl=" + url.QueryEscape(l)})
+		}
+	}
+	if list == nil {
+		return nil
+	}
+	return append([]Alternate{
+		{Lang: "x-default", URL: s.info.BaseURL + u},
+		{Lang: s.lang, URL: s.info.BaseURL + u + "?hl=" + url.QueryEscape(s.lang)},
+	}, list...)
+}
+
+// language returns the language in which to serve the page requested by r:
+// the language named by the hl URL query parameter, if the site has it,
+// or else the best match for the request's Accept-Language header,
+// or else the site's default language.
+// If the site has no other languages, language returns the empty string.
+func (s *Site) language(r *http.Request) string {
+	if len(s.langs) == 0 {
+		return ""
+	}
+	if l, ok := s.matchLang(r.URL.Query().Get("hl")); ok {
+		return l
+	}
+	best, bestQ := s.lang, 0.0
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = f
+		}
+		if l, ok := s.matchLang(strings.TrimSpace(tag)); ok && q > bestQ {
+			best, bestQ = l, q
+		}
+	}
+	return best
+}
+
+// matchLang returns the site language matching the language tag.
+// An exact match (ignoring case) is best; otherwise a site language
+// with the same primary language subtag (zh for zh-TW) will do.
+func (s *Site) matchLang(tag string) (string, bool) {
+	if tag == "" {
+		return "", false
+	}
+	all := append([]string{s.lang}, s.langs...)
+	for _, l := range all {
+		if strings.EqualFold(l, tag) {
+			return l, true
+		}
+	}
+	prim, _, _ := strings.Cut(tag, "-")
+	for _, l := range all {
+		if lp, _, _ := strings.Cut(l, "-"); strings.EqualFold(lp, prim) {
+			return l, true
+		}
+	}
+	return "", false
+}
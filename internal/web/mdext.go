@@ -0,0 +1,253 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package web
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// This file defines goldmark extensions for callouts and tabbed code groups.
+// See “Markdown Extensions” in the package doc comment for the syntax.
+
+// A callout is a note, warning, or deprecation notice.
+type callout struct {
+	ast.BaseBlock
+	kind string // "note", "warning", or "deprecated"
+}
+
+var kindCallout = ast.NewNodeKind("Callout")
+
+func (n *callout) Kind() ast.NodeKind { return kindCallout }
+
+func (n *callout) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Kind": n.kind}, nil)
+}
+
+// calloutTitles maps the callout kinds to their titles.
+var calloutTitles = map[string]string{
+	"note":       "Note",
+	"warning":    "Warning",
+	"deprecated": "Deprecated",
+}
+
+// calloutRE matches the first line of a block quote that is a callout.
+var calloutRE = regexp.MustCompile(`(?i)^\[!(note|warning|deprecated)\]$`)
+
+// callouts is a goldmark extension turning block quotes that begin
+// with a line [!NOTE], [!WARNING], or [!DEPRECATED] into callouts.
+type callouts struct{}
+
+func (c callouts) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithASTTransformers(util.Prioritized(mdTransformFunc(c.transform), 3)))
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(util.Prioritized(c, 0)))
+}
+
+func (callouts) transform(doc *ast.Document, reader text.Reader, _ parser.Context) {
+	var quotes []*ast.Blockquote
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if q, ok := n.(*ast.Blockquote); ok && entering {
+			quotes = append(quotes, q)
+		}
+		return ast.WalkContinue, nil
+	})
+
+	src := reader.Source()
+	for _, q := range quotes {
+		p, ok := q.FirstChild().(*ast.Paragraph)
+		if !ok || p.Lines().Len() == 0 {
+			continue
+		}
+		first := p.Lines().At(0)
+		m := calloutRE.FindStringSubmatch(strings.TrimSpace(string(first.Value(src))))
+		if m == nil {
+			continue
+		}
+
+		// Remove the [!NOTE] line from the paragraph,
+		// and the paragraph too if nothing is left.
+		for n := p.FirstChild(); n != nil; n = p.FirstChild() {
+			t, ok := n.(*ast.Text)
+			if !ok || t.Segment.Start >= first.Stop {
+				break
+			}
+			p.RemoveChild(p, n)
+		}
+		if p.FirstChild() == nil {
+			q.RemoveChild(q, p)
+		}
+
+		c := &callout{kind: strings.ToLower(m[1])}
+		for n := q.FirstChild(); n != nil; n = q.FirstChild() {
+			c.AppendChild(c, n)
+		}
+		q.Parent().ReplaceChild(q.Parent(), q, c)
+	}
+}
+
+func (c callouts) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindCallout, c.render)
+}
+
+func (callouts) render(w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	c := n.(*callout)
+	if entering {
+		fmt.Fprintf(w, "<div class=\"Callout Callout--%s\" role=\"note\">\n<p class=\"Callout-title\">%s</p>\n", c.kind, calloutTitles[c.kind])
+	} else {
+		w.WriteString("</div>\n")
+	}
+	return ast.WalkContinue, nil
+}
+
+// A codeTabs is a group of code blocks shown one at a time, selected by tabs.
+// Its children are codeTab nodes.
+type codeTabs struct {
+	ast.BaseBlock
+	id string // HTML ID prefix for the tabs and panels
+}
+
+// A codeTab is a single tab in a codeTabs group.
+// Its child is the code block.
+type codeTab struct {
+	ast.BaseBlock
+	label string // tab label
+}
+
+var (
+	kindCodeTabs = ast.NewNodeKind("CodeTabs")
+	kindCodeTab  = ast.NewNodeKind("CodeTab")
+)
+
+func (n *codeTabs) Kind() ast.NodeKind { return kindCodeTabs }
+func (n *codeTab) Kind() ast.NodeKind  { return kindCodeTab }
+
+func (n *codeTabs) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"ID": n.id}, nil)
+}
+
+func (n *codeTab) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Label": n.label}, nil)
+}
+
+// codeTabRE matches the tab= setting in a fenced code block's info string.
+var codeTabRE = regexp.MustCompile(`(?:^|\s)tab=(?:"([^"]*)"|(\S+))`)
+
+// codeTabLabel returns the tab label set in the info string of the
+// fenced code block b, or the empty string if there is none.
+func codeTabLabel(b *ast.FencedCodeBlock, src []byte) string {
+	if b.Info == nil {
+		return ""
+	}
+	m := codeTabRE.FindSubmatch(b.Info.Segment.Value(src))
+	if m == nil {
+		return ""
+	}
+	return string(m[1]) + string(m[2])
+}
+
+// codeTabGroups is a goldmark extension grouping consecutive fenced code blocks
+// with tab=label in their info strings into tabbed code groups.
+type codeTabGroups struct{}
+
+func (g codeTabGroups) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithASTTransformers(util.Prioritized(mdTransformFunc(g.transform), 4)))
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(util.Prioritized(g, 0)))
+}
+
+func (codeTabGroups) transform(doc *ast.Document, reader text.Reader, _ parser.Context) {
+	src := reader.Source()
+	var blocks []*ast.FencedCodeBlock
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if b, ok := n.(*ast.FencedCodeBlock); ok && entering && codeTabLabel(b, src) != "" {
+			blocks = append(blocks, b)
+		}
+		return ast.WalkContinue, nil
+	})
+
+	grouped := make(map[ast.Node]bool)
+	ngroup := 0
+	for _, b := range blocks {
+		if grouped[b] {
+			continue
+		}
+		ngroup++
+		parent := b.Parent()
+		g := &codeTabs{id: fmt.Sprintf("codetabs-%d", ngroup)}
+		parent.InsertBefore(parent, b, g)
+		for n := ast.Node(b); n != nil; {
+			fb, ok := n.(*ast.FencedCodeBlock)
+			if !ok || codeTabLabel(fb, src) == "" {
+				break
+			}
+			next := n.NextSibling()
+			grouped[n] = true
+			tab := &codeTab{label: codeTabLabel(fb, src)}
+			tab.AppendChild(tab, n)
+			g.AppendChild(g, tab)
+			n = next
+		}
+	}
+}
+
+func (g codeTabGroups) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindCodeTabs, g.renderTabs)
+	reg.Register(kindCodeTab, g.renderTab)
+}
+
+// tabID returns the HTML IDs of the i'th tab (counting from 0) in g and of its panel.
+func (g *codeTabs) tabID(i int) (tab, panel string) {
+	return fmt.Sprintf("%s-tab-%d", g.id, i+1), fmt.Sprintf("%s-panel-%d", g.id, i+1)
+}
+
+func (codeTabGroups) renderTabs(w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	g := n.(*codeTabs)
+	if !entering {
+		w.WriteString("</div>\n")
+		return ast.WalkContinue, nil
+	}
+	w.WriteString("<div class=\"CodeTabs\">\n<div class=\"CodeTabs-list\" role=\"tablist\">\n")
+	i := 0
+	for c := g.FirstChild(); c != nil; c = c.NextSibling() {
+		tab, panel := g.tabID(i)
+		selected, tabindex := "true", "0"
+		if i > 0 {
+			selected, tabindex = "false", "-1"
+		}
+		fmt.Fprintf(w, "<button type=\"button\" class=\"CodeTabs-tab\" role=\"tab\" id=\"%s\" aria-controls=\"%s\" aria-selected=\"%s\" tabindex=\"%s\">",
+			tab, panel, selected, tabindex)
+		w.Write(util.EscapeHTML([]byte(c.(*codeTab).label)))
+		w.WriteString("</button>\n")
+		i++
+	}
+	w.WriteString("</div>\n")
+	return ast.WalkContinue, nil
+}
+
+func (codeTabGroups) renderTab(w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		w.WriteString("</div>\n")
+		return ast.WalkContinue, nil
+	}
+	g := n.Parent().(*codeTabs)
+	i := 0
+	for c := g.FirstChild(); c != n; c = c.NextSibling() {
+		i++
+	}
+	tab, panel := g.tabID(i)
+	hidden := ""
+	if i > 0 {
+		hidden = " hidden"
+	}
+	fmt.Fprintf(w, "<div class=\"CodeTabs-panel\" role=\"tabpanel\" id=\"%s\" aria-labelledby=\"%s\" tabindex=\"0\"%s>\n", panel, tab, hidden)
+	return ast.WalkContinue, nil
+}
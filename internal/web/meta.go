@@ -0,0 +1,251 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"path"
+	"strings"
+	"time"
+)
+
+// A SiteInfo describes a Site as a whole,
+// for the metadata that the “meta” template function adds to its pages.
+type SiteInfo struct {
+	BaseURL string // URL of the site root, without a trailing slash, like "https://go.dev"
+	Name    string // name of the site, like "The Go Programming Language"
+	Image   string // URL path of the preview image for pages that set none
+	Twitter string // the site's Twitter account, like "@golang"
+}
+
+// SetSiteInfo sets the information about the site
+// used in the metadata of its pages.
+// See “Page Metadata” in the package doc comment.
+// SetSiteInfo must not be called concurrently with serving requests.
+func (s *Site) SetSiteInfo(info SiteInfo) {
+	info.BaseURL = strings.TrimSuffix(info.BaseURL, "/")
+	s.info = info
+}
+
+// A pageMeta is the metadata for a page, for link previews and search engines.
+type pageMeta struct {
+	URL         string    // canonical URL
+	Title       string    // page title
+	Description string    // page description
+	Image       string    // URL of preview image
+	Type        string    // OpenGraph type: "article" or "website"
+	Published   time.Time // publication date, if any
+	Modified    time.Time // last modification date, if any
+	Authors     []string  // authors' names
+	Schema      string    // schema.org type for JSON-LD: "Article", "SoftwareSourceCode", or "" for none
+	Repository  string    // source code repository, for SoftwareSourceCode
+}
+
+// pageMeta returns the metadata for the page p.
+func (s *Site) pageMeta(p Page) *pageMeta {
+	// YAML decodes the nested map as a Page, JSON as a map[string]interface{}.
+	var over map[string]interface{}
+	switch m := p["meta"].(type) {
+	case Page:
+		over = m
+	case map[string]interface{}:
+		over = m
+	}
+	str := func(key, def string) string {
+		if v, ok := over[key].(string); ok {
+			return v
+		}
+		return def
+	}
+
+	url, _ := p["URL"].(string)
+	title, _ := p["title"].(string)
+	summary, _ := p["summary"].(string)
+	m := &pageMeta{
+		URL:         s.absURL(url, str("canonical", url)),
+		Title:       str("title", title),
+		Description: str("description", summary),
+		Image:       s.absURL(url, str("image", s.info.Image)),
+		Published:   metaTime(p["date"]),
+		Modified:    metaTime(over["modified"]),
+		Authors:     metaList(p["by"]),
+	}
+	if _, ok := over["authors"]; ok {
+		m.Authors = metaList(over["authors"])
+	}
+	if !m.Published.IsZero() {
+		m.Type, m.Schema = "article", "Article"
+	} else {
+		m.Type = "website"
+	}
+	m.Type = str("type", m.Type)
+	m.Schema = str("schema", m.Schema)
+	if m.Schema == "none" {
+		m.Schema = ""
+	}
+	m.Repository = str("repository", "")
+	return m
+}
+
+// absURL returns the absolute URL for the link u
+// on the page with the URL path page.
+func (s *Site) absURL(page, u string) string {
+	if u == "" || strings.Contains(u, "://") {
+		return u
+	}
+	if !strings.HasPrefix(u, "/") {
+		dir := page
+		if !strings.HasSuffix(dir, "/") {
+			dir = path.Dir(dir)
+		}
+		u = path.Join(dir, u)
+	}
+	return s.info.BaseURL + u
+}
+
+// metaTime returns the time in the page metadata value v:
+// a time.Time parsed from YAML, or a string holding a date
+// or an RFC 3339 time, as in JSON metadata.
+// It returns the zero time for any other value.
+func metaTime(v interface{}) time.Time {
+	switch v := v.(type) {
+	case time.Time:
+		return v
+	case string:
+		for _, layout := range []string{time.RFC3339, time.DateOnly} {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// metaList returns the strings in the page metadata value v,
+// which is either a single string or a list of strings.
+func metaList(v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var list []string
+		for _, x := range v {
+			if s, ok := x.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	case []string:
+		return v
+	}
+	return nil
+}
+
+// A jsonLD is the structured data describing a page,
+// in the schema.org vocabulary, encoded as JSON-LD.
+type jsonLD struct {
+	Context             string        `json:"@context"`
+	Type                string        `json:"@type"`
+	URL                 string        `json:"url,omitempty"`
+	Headline            string        `json:"headline,omitempty"`
+	Name                string        `json:"name,omitempty"`
+	Description         string        `json:"description,omitempty"`
+	Image               string        `json:"image,omitempty"`
+	DatePublished       string        `json:"datePublished,omitempty"`
+	DateModified        string        `json:"dateModified,omitempty"`
+	Author              []jsonLDThing `json:"author,omitempty"`
+	Publisher           *jsonLDThing  `json:"publisher,omitempty"`
+	CodeRepository      string        `json:"codeRepository,omitempty"`
+	ProgrammingLanguage string        `json:"programmingLanguage,omitempty"`
+}
+
+// A jsonLDThing is a person or organization in a jsonLD.
+type jsonLDThing struct {
+	Type string `json:"@type"`
+	Name string `json:"name"`
+}
+
+// jsonLD returns the JSON-LD structured data for m,
+// or nil if m has no schema.
+func (s *Site) jsonLD(m *pageMeta) *jsonLD {
+	if m.Schema == "" {
+		return nil
+	}
+	ld := &jsonLD{
+		Context:     "https://schema.org",
+		Type:        m.Schema,
+		URL:         m.URL,
+		Description: m.Description,
+		Image:       m.Image,
+	}
+	if !m.Published.IsZero() {
+		ld.DatePublished = m.Published.Format(time.RFC3339)
+	}
+	if !m.Modified.IsZero() {
+		ld.DateModified = m.Modified.Format(time.RFC3339)
+	}
+	for _, a := range m.Authors {
+		ld.Author = append(ld.Author, jsonLDThing{"Person", a})
+	}
+	switch m.Schema {
+	case "SoftwareSourceCode":
+		ld.Name = m.Title
+		ld.CodeRepository = m.Repository
+		ld.ProgrammingLanguage = "Go"
+	default:
+		ld.Headline = m.Title
+		if s.info.Name != "" {
+			ld.Publisher = &jsonLDThing{"Organization", s.info.Name}
+		}
+	}
+	return ld
+}
+
+// meta returns the HTML <head> tags giving the metadata for the page p,
+// as described in the package doc comment.
+func (s *Site) meta(p Page) (template.HTML, error) {
+	m := s.pageMeta(p)
+	var b strings.Builder
+	tag := func(attr, key, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "<meta %s=\"%s\" content=\"%s\">\n", attr, key, template.HTMLEscapeString(value))
+		}
+	}
+	if m.URL != "" {
+		fmt.Fprintf(&b, "<link rel=\"canonical\" href=\"%s\">\n", template.HTMLEscapeString(m.URL))
+	}
+	tag("name", "description", m.Description)
+	tag("property", "og:site_name", s.info.Name)
+	tag("property", "og:type", m.Type)
+	tag("property", "og:url", m.URL)
+	tag("property", "og:title", m.Title)
+	tag("property", "og:description", m.Description)
+	tag("property", "og:image", m.Image)
+	if m.Type == "article" {
+		if !m.Published.IsZero() {
+			tag("property", "article:published_time", m.Published.Format(time.RFC3339))
+		}
+		if !m.Modified.IsZero() {
+			tag("property", "article:modified_time", m.Modified.Format(time.RFC3339))
+		}
+		for _, a := range m.Authors {
+			tag("property", "article:author", a)
+		}
+	}
+	tag("name", "twitter:card", "summary")
+	tag("name", "twitter:site", s.info.Twitter)
+
+	if ld := s.jsonLD(m); ld != nil {
+		// json.Marshal escapes <, >, and &, so the JSON cannot end the script early.
+		js, err := json.Marshal(ld)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "<script type=\"application/ld+json\">%s</script>\n", js)
+	}
+	return template.HTML(b.String()), nil
+}
@@ -6,6 +6,8 @@ package web
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"io/fs"
 	"path"
@@ -22,7 +24,9 @@ type pageFile struct {
 	file string      // .md file for page
 	stat fs.FileInfo // stat for file when page was loaded
 	url  string      // url excluding site.BaseURL; always begins with slash
+	lang string      // language of page, if site has languages (see Site.SetLanguages)
 	data []byte      // page data (markdown)
+	hash string      // hex SHA-256 of file content, for Site.pageETag
 	page Page        // parameters passed to templates
 
 	checked int64 // unix nano, atomically updated
@@ -33,6 +37,18 @@ type pageFile struct {
 type Page map[string]interface{}
 
 func (site *Site) openPage(file string) (*pageFile, error) {
+	return site.openPageLang(file, "")
+}
+
+// openPageLang opens the page for file in the language lang,
+// falling back to the site's default language if there is
+// no version of the page in lang.
+// An empty lang means the default language.
+func (site *Site) openPageLang(file, lang string) (*pageFile, error) {
+	if lang == site.lang {
+		lang = ""
+	}
+
 	// Strip trailing .html or .md or /; it all names the same page.
 	if strings.HasSuffix(file, "/index.md") {
 		file = strings.TrimSuffix(file, "/index.md")
@@ -48,15 +64,22 @@ func (site *Site) openPage(file string) (*pageFile, error) {
 		file = strings.TrimSuffix(file, ".md")
 	}
 
+	key := file
+	if lang != "" {
+		key += "#" + lang
+	}
 	now := time.Now().UnixNano()
-	if cp, ok := site.cache.Load(file); ok {
+	if cp, ok := site.cache.Load(key); ok {
 		// Have cache entry; only use if the underlying file hasn't changed.
 		// To avoid continuous stats, only check it has been 3s since the last one.
 		// TODO(rsc): Move caching into a more general layer and cache templates.
+		// An entry for a language in which the page does not exist
+		// is the default-language page; use it only while that is still true.
 		p := cp.(*pageFile)
 		if now-atomic.LoadInt64(&p.checked) >= 3e9 {
 			info, err := fs.Stat(site.fs, p.file)
-			if err == nil && info.ModTime().Equal(p.stat.ModTime()) && info.Size() == p.stat.Size() {
+			if err == nil && info.ModTime().Equal(p.stat.ModTime()) && info.Size() == p.stat.Size() &&
+				(lang == "" || p.lang == lang || !site.hasLangFile(file, lang)) {
 				atomic.StoreInt64(&p.checked, now)
 				return p, nil
 			}
@@ -65,7 +88,7 @@ func (site *Site) openPage(file string) (*pageFile, error) {
 
 	// Check md before html to work correctly when x/website is layered atop Go 1.15 goroot during Go 1.15 tests.
 	// Want to find x/website's debugging_with_gdb.md not Go 1.15's debugging_with_gdb.html.
-	files := []string{file + ".md", file + ".html", path.Join(file, "index.md"), path.Join(file, "index.html")}
+	files := site.langFiles(file, lang)
 	var filePath string
 	var b []byte
 	var err error
@@ -80,12 +103,21 @@ func (site *Site) openPage(file string) (*pageFile, error) {
 		}
 	}
 	if err != nil {
+		if lang != "" {
+			// Serve the default language, caching the page for lang too,
+			// so that later requests for lang need not look for it again.
+			p, err := site.openPageLang(file, "")
+			if err == nil {
+				site.cache.Store(key, p)
+			}
+			return p, err
+		}
 		return nil, err
 	}
 
 	// If we read an index.md or index.html, the canonical relpath is without the index.md/index.html suffix.
 	url := path.Join("/", file)
-	if name := path.Base(filePath); name == "index.html" || name == "index.md" {
+	if name := path.Base(filePath); name == path.Base(files[2]) || name == path.Base(files[3]) {
 		url, _ = path.Split(path.Join("/", filePath))
 	}
 
@@ -94,26 +126,43 @@ func (site *Site) openPage(file string) (*pageFile, error) {
 		return nil, err
 	}
 
+	sum := sha256.Sum256(b)
 	p := &pageFile{
 		file:    filePath,
 		stat:    stat,
 		url:     url,
+		lang:    lang,
 		data:    body,
+		hash:    hex.EncodeToString(sum[:]),
 		page:    params,
 		checked: now,
 	}
+	if p.lang == "" {
+		p.lang = site.lang
+	}
 
-	// File, FileData, URL
+	// File, FileData, FileInfo, URL
 	p.page["File"] = filePath
 	p.page["FileData"] = string(body)
+	p.page["FileInfo"] = stat
 	p.page["URL"] = p.url
 
+	// lang, Alternates
+	if site.lang != "" {
+		if _, ok := p.page["lang"]; !ok {
+			p.page["lang"] = p.lang
+		}
+		if alt := site.alternates(file, p.url); alt != nil {
+			p.page["Alternates"] = alt
+		}
+	}
+
 	// User-specified redirect: overrides url but not URL.
 	if redir, _ := p.page["redirect"].(string); redir != "" {
 		p.url = redir
 	}
 
-	site.cache.Store(file, p)
+	site.cache.Store(key, p)
 
 	return p, nil
 }
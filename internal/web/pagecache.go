@@ -0,0 +1,168 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package web
+
+import (
+	"container/list"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// A pageCache is an LRU cache of rendered pages, limited by total size.
+// Entries are tagged with the content generation in which they were rendered;
+// entries from older generations are never returned.
+type pageCache struct {
+	gen *atomic.Int64 // current content generation (&Site.gen)
+
+	mu      sync.Mutex
+	max     int64                     // maximum total size of cached pages
+	size    int64                     // total size of cached pages
+	lru     list.List                 // *cachedPage, most recently used first
+	entries map[pageKey]*list.Element // key -> element in lru
+	stats   PageCacheStats
+}
+
+// A pageKey identifies a rendered page.
+type pageKey struct {
+	file  string // file in site's file system
+	query string // canonical form of request query
+	gen   int64  // content generation
+}
+
+// A cachedPage is a rendered page in the cache.
+type cachedPage struct {
+	key    pageKey
+	status int // status to send, or 0 for the default
+	html   []byte
+}
+
+// PageCacheStats holds statistics about a Site's rendered-page cache.
+type PageCacheStats struct {
+	Hits      int64 // requests served from the cache
+	Misses    int64 // requests that rendered a page
+	Evictions int64 // pages dropped to make room or after a content change
+	Pages     int64 // pages in the cache
+	Bytes     int64 // total size of pages in the cache
+}
+
+// HitRate returns the fraction of lookups that were hits.
+func (s PageCacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+func (s PageCacheStats) String() string {
+	return fmt.Sprintf("%d hits, %d misses (%.1f%% hit rate), %d evictions, %d pages, %d bytes",
+		s.Hits, s.Misses, 100*s.HitRate(), s.Evictions, s.Pages, s.Bytes)
+}
+
+// SetPageCache makes the Site keep up to maxBytes of rendered pages in memory,
+// evicting the least recently used pages when the limit is reached.
+// Only pages loaded from files are cached, keyed by the page file and
+// the request's URL query parameters.
+// A page with "cache: false" in its metadata is never cached;
+// that setting is appropriate for pages that use template functions
+// returning data that changes over time.
+// Nor are pages served to requests with a preview URL query parameter
+// (see SetPreviewToken), which may include unpublished pages.
+//
+// Because cached pages are not checked for changes to the underlying files,
+// the Site's owner must call Invalidate when the file system changes.
+// A maxBytes of zero or less disables the cache, which is the default.
+// SetPageCache must not be called concurrently with serving requests.
+func (s *Site) SetPageCache(maxBytes int64) {
+	if maxBytes <= 0 {
+		s.pages = nil
+		return
+	}
+	s.pages = &pageCache{gen: &s.gen, max: maxBytes, entries: make(map[pageKey]*list.Element)}
+}
+
+// Invalidate discards all cached rendered pages,
+// along with the cached information about images (see the “img” template function).
+// It starts a new content generation, so that pages rendered
+// from the old file system content are not served again
+// and the ETags of pages rendered from files change.
+func (s *Site) Invalidate() {
+	s.imgs.invalidate()
+	s.gen.Add(1)
+	if c := s.pages; c != nil {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		for c.lru.Len() > 0 {
+			c.evict(c.lru.Back())
+		}
+	}
+}
+
+// PageCacheStats returns statistics about the Site's rendered-page cache.
+func (s *Site) PageCacheStats() PageCacheStats {
+	c := s.pages
+	if c == nil {
+		return PageCacheStats{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st := c.stats
+	st.Pages = int64(c.lru.Len())
+	st.Bytes = c.size
+	return st
+}
+
+// key returns the cache key for the page file rendered for r.
+func (c *pageCache) key(file string, r *http.Request) pageKey {
+	// Encode sorts the query by key, so that equivalent
+	// requests share a cache entry.
+	return pageKey{file: file, query: r.URL.Query().Encode(), gen: c.gen.Load()}
+}
+
+// get returns the cached page with the given key.
+func (c *pageCache) get(key pageKey) (*cachedPage, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		c.stats.Misses++
+		return nil, false
+	}
+	c.stats.Hits++
+	c.lru.MoveToFront(e)
+	return e.Value.(*cachedPage), true
+}
+
+// add adds the page to the cache.
+func (c *pageCache) add(p *cachedPage) {
+	n := int64(len(p.html))
+	if n > c.max {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if p.key.gen != c.gen.Load() {
+		// Rendered from content that has since been replaced.
+		return
+	}
+	if e, ok := c.entries[p.key]; ok {
+		c.evict(e)
+	}
+	for c.size+n > c.max && c.lru.Len() > 0 {
+		c.evict(c.lru.Back())
+	}
+	c.entries[p.key] = c.lru.PushFront(p)
+	c.size += n
+}
+
+// evict removes the entry e from the cache.
+// c.mu must be held.
+func (c *pageCache) evict(e *list.Element) {
+	p := c.lru.Remove(e).(*cachedPage)
+	delete(c.entries, p.key)
+	c.size -= int64(len(p.html))
+	c.stats.Evictions++
+}
@@ -0,0 +1,77 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package web
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"time"
+)
+
+// timeNow is time.Now, replaced during tests.
+var timeNow = time.Now
+
+// Published reports whether the page p is published:
+// that is, p is not a draft (with “draft: true” in its metadata)
+// and p's date, if any, is not in the future.
+// Unpublished pages are served only to previews;
+// see “Drafts and Scheduled Pages” in the package doc comment.
+func Published(p Page) bool {
+	if draft, _ := p["draft"].(bool); draft {
+		return false
+	}
+	t, _ := p["date"].(time.Time)
+	return !t.After(timeNow())
+}
+
+// Scheduled returns the time at which the page p will be published,
+// if p has a future date and is not a draft.
+// Otherwise it returns the zero time.
+func Scheduled(p Page) time.Time {
+	if draft, _ := p["draft"].(bool); draft {
+		return time.Time{}
+	}
+	if t, _ := p["date"].(time.Time); t.After(timeNow()) {
+		return t
+	}
+	return time.Time{}
+}
+
+// SetPreviewToken sets the token that allows viewing unpublished pages:
+// a request with the URL query parameter preview=token is served
+// the page even if it is a draft or scheduled for the future.
+// The empty string, the default, disables previews.
+// SetPreviewToken must not be called concurrently with serving requests.
+func (s *Site) SetPreviewToken(token string) {
+	s.previewToken = token
+}
+
+// isPreview reports whether r carries the site's preview token.
+func (s *Site) isPreview(r *http.Request) bool {
+	tok := r.URL.Query().Get("preview")
+	return s.previewToken != "" && subtle.ConstantTimeCompare([]byte(tok), []byte(s.previewToken)) == 1
+}
+
+// schedule records that a page omitted from a page list
+// will be published at t, so that checkSchedule can discard
+// the cached pages rendered from the list at that time.
+func (s *Site) schedule(t time.Time) {
+	n := t.UnixNano()
+	for {
+		old := s.nextPublish.Load()
+		if old != 0 && old <= n || s.nextPublish.CompareAndSwap(old, n) {
+			return
+		}
+	}
+}
+
+// checkSchedule discards the cached rendered pages if a page omitted
+// from a page list when they were rendered has since been published.
+func (s *Site) checkSchedule() {
+	n := s.nextPublish.Load()
+	if n != 0 && timeNow().UnixNano() >= n && s.nextPublish.CompareAndSwap(n, 0) {
+		s.Invalidate()
+	}
+}
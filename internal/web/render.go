@@ -6,6 +6,7 @@ package web
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"html/template"
 	"io/fs"
@@ -47,18 +48,63 @@ func (site *Site) renderHTML(p Page, tmpl string, r *http.Request) ([]byte, erro
 	}
 	p = p2
 
-	url, ok := p["URL"].(string)
-	if !ok {
+	t, content, err := site.parseTemplates(p, tmpl, r)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := p["URL"].(string); !ok {
 		// Set URL - caller did not.
 		p["URL"] = r.URL.Path
 	}
 	file, _ := p["File"].(string)
 	data, _ := p["FileData"].(string)
 
+	var buf bytes.Buffer
+	if _, ok := p["Content"]; !ok && data != "" {
+		tdata := data
+		if content != nil {
+			if err := content.Execute(&buf, p); err != nil {
+				return nil, err
+			}
+			tdata = buf.String()
+			buf.Reset()
+		}
+
+		if strings.HasSuffix(file, ".md") {
+			html, toc, err := markdownTOC(tdata)
+			if err != nil {
+				return nil, err
+			}
+			p["Content"] = html
+			if _, ok := p["TOC"]; !ok && toc != nil && p["toc"] != false {
+				p["TOC"] = toc
+			}
+		} else {
+			p["Content"] = template.HTML(tdata)
+		}
+	}
+
+	if err := t.Execute(&buf, p); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// parseTemplates parses the templates needed to render the page p
+// using the named base template: the base template itself, the page's layout,
+// and, if the page's content is a template, the content.
+// It returns the base template and the content template,
+// which is nil if the content is not a template.
+func (site *Site) parseTemplates(p Page, tmpl string, r *http.Request) (t, content *template.Template, err error) {
+	url, _ := p["URL"].(string)
+	file, _ := p["File"].(string)
+	data, _ := p["FileData"].(string)
+
 	// Load base template.
 	base, err := site.readFile(".", tmpl)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	dir := strings.Trim(path.Dir(url), "/")
@@ -67,11 +113,12 @@ func (site *Site) renderHTML(p Page, tmpl string, r *http.Request) ([]byte, erro
 	}
 	sd := &siteDir{site, dir}
 
-	t := template.New("site.tmpl").Funcs(template.FuncMap{
+	t = template.New("site.tmpl").Funcs(template.FuncMap{
 		"add":          func(a, b int) int { return a + b },
 		"sub":          func(a, b int) int { return a - b },
 		"mul":          func(a, b int) int { return a * b },
 		"div":          func(a, b int) int { return a / b },
+		"asset":        sd.asset,
 		"code":         sd.code,
 		"data":         sd.data,
 		"page":         sd.page,
@@ -82,7 +129,9 @@ func (site *Site) renderHTML(p Page, tmpl string, r *http.Request) ([]byte, erro
 		"strings":      func() pkgStrings { return pkgStrings{} },
 		"file":         sd.file,
 		"first":        first,
+		"img":          sd.img,
 		"markdown":     markdown,
+		"meta":         sd.meta,
 		"raw":          raw,
 		"yaml":         yamlFn,
 		"presentStyle": presentStyle,
@@ -90,7 +139,7 @@ func (site *Site) renderHTML(p Page, tmpl string, r *http.Request) ([]byte, erro
 	t.Funcs(site.funcs)
 
 	if err := tmplfunc.Parse(t, string(base)); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// Load page-specific layout template.
@@ -109,7 +158,7 @@ func (site *Site) renderHTML(p Page, tmpl string, r *http.Request) ([]byte, erro
 	} else if layout != "none" {
 		l, ok := site.findLayout(dir, layout)
 		if !ok {
-			return nil, fmt.Errorf("cannot find layout %q", layout)
+			return nil, nil, fmt.Errorf("%w %q", errNoLayout, layout)
 		}
 		layout = l
 	}
@@ -117,51 +166,31 @@ func (site *Site) renderHTML(p Page, tmpl string, r *http.Request) ([]byte, erro
 	if layout != "none" {
 		ldata, err := site.readFile(".", layout)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		if err := tmplfunc.Parse(t.New(layout), string(ldata)); err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 	}
 
-	var buf bytes.Buffer
+	// The page must explicitly request templating with "template: true"
+	// in its metadata. Markdown files are no longer treated as
+	// templates by default.
 	if _, ok := p["Content"]; !ok && data != "" {
-		// The page must explicitly request templating with "template: true"
-		// in its metadata. Markdown files are no longer treated as
-		// templates by default.
-		isTemplate, _ := p["template"].(bool)
-
-		tdata := data
-		if isTemplate {
+		if isTemplate, _ := p["template"].(bool); isTemplate {
 			// Load content as a template.
-			tf := t.New(file)
-			if err := tmplfunc.Parse(tf, data); err != nil {
-				return nil, err
+			content = t.New(file)
+			if err := tmplfunc.Parse(content, data); err != nil {
+				return nil, nil, err
 			}
-			if err := tf.Execute(&buf, p); err != nil {
-				return nil, err
-			}
-			tdata = buf.String()
-			buf.Reset()
-		}
-
-		if strings.HasSuffix(file, ".md") {
-			html, err := markdownToHTML(tdata)
-			if err != nil {
-				return nil, err
-			}
-			p["Content"] = html
-		} else {
-			p["Content"] = template.HTML(tdata)
 		}
 	}
-
-	if err := t.Execute(&buf, p); err != nil {
-		return nil, err
-	}
-	return buf.Bytes(), nil
+	return t, content, nil
 }
 
+// errNoLayout is the error reported when a page names a layout that does not exist.
+var errNoLayout = errors.New("cannot find layout")
+
 // findLayout searches the start directory and parent directories for a template with the given base name.
 func (site *Site) findLayout(dir, name string) (string, bool) {
 	name += ".tmpl"
@@ -181,13 +210,24 @@ func (site *Site) findLayout(dir, name string) (string, bool) {
 // The Markdown source may contain raw HTML,
 // and may contain Go template syntax if templating was not enabled.
 func markdownToHTML(markdown string) (template.HTML, error) {
+	html, _, err := markdownTOC(markdown)
+	return html, err
+}
+
+// markdownTOC is like markdownToHTML but also returns the table of contents
+// listing the level 2 and 3 headings in the Markdown, or nil if there are
+// fewer than two such headings. Headings written in raw HTML are not listed.
+func markdownTOC(markdown string) (template.HTML, []*Heading, error) {
 	// parser.WithHeadingAttribute allows custom ids on headings.
 	// html.WithUnsafe allows use of raw HTML, which we need for tables.
 	md := goldmark.New(
 		goldmark.WithParserOptions(
 			parser.WithHeadingAttribute(),
 			parser.WithAutoHeadingID(),
-			parser.WithASTTransformers(util.Prioritized(mdTransformFunc(mdLink), 1)),
+			parser.WithASTTransformers(
+				util.Prioritized(mdTransformFunc(mdLink), 1),
+				util.Prioritized(mdTransformFunc(mdHeadings), 2),
+			),
 		),
 		goldmark.WithRendererOptions(html.WithUnsafe()),
 		goldmark.WithExtensions(
@@ -198,16 +238,21 @@ func markdownToHTML(markdown string) (template.HTML, error) {
 			),
 			extension.DefinitionList,
 			extension.NewTable(),
+			extension.Footnote,
 			tableWrapper{},
+			callouts{},
+			codeTabGroups{},
 		),
 	)
 	var buf bytes.Buffer
 	buf.WriteString("<div class='markdown'>\n")
-	if err := md.Convert(replaceTabs([]byte(markdown)), &buf); err != nil {
-		return "", err
+	pc := parser.NewContext()
+	if err := md.Convert(replaceTabs([]byte(markdown)), &buf, parser.WithContext(pc)); err != nil {
+		return "", nil, err
 	}
 	buf.WriteString("</div>\n")
-	return template.HTML(buf.Bytes()), nil
+	toc, _ := pc.Get(tocKey).([]*Heading)
+	return template.HTML(buf.Bytes()), toc, nil
 }
 
 // mdTransformFunc is a func implementing parser.ASTTransformer.
@@ -55,13 +55,26 @@
 // (see the next section, "Page Rendering"). The default is false.
 // Pages that use template functions (like {{code}}) must set "template: true".
 //
+// The key-value pair “toc: false” omits the table of contents
+// from a Markdown page (see the “TOC” key in “Page Rendering”).
+//
+// The key-value pair "cache: false" keeps the rendered page out of the
+// rendered-page cache (see “Caching” below), for pages whose templates
+// use data that changes over time.
+//
 // In addition to these explicit key-value pairs, pages loaded from the file system
 // have a few implicit key-value pairs added by the page loading process:
 //
 //   - File: the path in fsys to the file containing the page
 //   - FileData: the file body, with the key-value metadata stripped
+//   - FileInfo: the fs.FileInfo for File; for files loaded from Git history,
+//     its ModTime and Sys methods report the last commit to change the file
 //   - URL: this page's URL path (/x/y/z for x/y/z.md, /x/y/ for x/y/index.md)
 //
+// On sites with more than one language, pages also have the keys
+// “lang”, unless set in the metadata, and “Alternates”;
+// see “Languages” below.
+//
 // The key “Content” is added during the rendering process.
 // See “Page Rendering” for details.
 //
@@ -77,6 +90,14 @@
 // and converted to HTML. The result is stored in the page under the key "Content",
 // with type template.HTML.
 //
+// Converting Markdown also gives each heading an ID, derived from its text
+// unless set explicitly with {#id}, for linking to the heading. If two headings
+// have the same ID, the later one is renamed by adding -1, -2, and so on.
+// Unless the page has “toc: false” in its metadata, a Markdown page with
+// at least two level 2 and 3 headings gets a table of contents, stored under the key
+// "TOC" as a []*Heading listing the level 2 headings, each with
+// its level 3 headings nested in Sub.
+//
 // A page's conversion to content can be skipped entirely in dynamically-generated pages
 // by setting the “Content” key before passing the page to ServePage.
 //
@@ -112,6 +133,31 @@
 // if there is no layout-specific template,
 // the content will still be rendered.
 //
+// # Markdown Extensions
+//
+// Markdown pages can use a few extensions to CommonMark,
+// besides tables, definition lists, and {#id} heading attributes.
+//
+// Footnotes are written as [^name] in the text,
+// with the note itself in a paragraph beginning [^name]:,
+// and are listed at the end of the page.
+//
+// A block quote whose first line is [!NOTE], [!WARNING], or [!DEPRECATED],
+// as on GitHub, is rendered as a callout:
+//
+//	> [!WARNING]
+//	> This function is not safe for concurrent use.
+//
+// becomes a <div class="Callout Callout--warning" role="note">
+// beginning with a <p class="Callout-title">Warning</p>.
+//
+// Consecutive fenced code blocks with tab=label in their info strings,
+// like ```sh tab=Linux or ```sh tab="Windows PowerShell",
+// are grouped into a single block of code showing one at a time,
+// chosen by a list of tabs with the given labels, marked up with the
+// ARIA tablist, tab, and tabpanel roles. The site's JavaScript must
+// handle selecting the tabs; without it, only the first tab's code shows.
+//
 // # Page Template Functions
 //
 // In this web server, templates can themselves be invoked as functions.
@@ -128,6 +174,13 @@
 // The “{{add x y}}”, “{{sub x y}}”, “{{mul x y}}”, and “{{div x y}}” functions
 // provide basic math on arguments of type int.
 //
+// The “{{asset f}}” function returns the fingerprinted URL path for the style sheet
+// or script f, if the Site has built it (see the “Assets” section below),
+// or else the URL path for f itself.
+// For example:
+//
+//	<link rel="stylesheet" href="{{asset "/css/styles.css"}}">
+//
 // The “{{code f [start [end]]}}” function returns a template.HTML of a formatted display
 // of code lines from the file f.
 // If both start and end are omitted, then the display shows the entire file.
@@ -153,9 +206,30 @@
 // The “{{first n slice}}” function returns a slice of the first n elements of slice,
 // or else slice itself when slice has fewer than n elements.
 //
+// The “{{img f alt}}” function returns the HTML for displaying the image f
+// with the alternate text alt (both strings): an <img> tag with the image's
+// width and height and, for a PNG or JPEG image, a srcset attribute listing
+// resized versions of the image, so that browsers on smaller screens
+// can download less. If the Site has a WebP encoder (see Site.SetWebPEncoder),
+// the tag is wrapped in a <picture> offering WebP versions as well.
+// For example:
+//
+//	{{img "gopher.png" "A gopher"}}
+//
+// The resized images have URL paths formed by appending the width, a hash of
+// the image content, and the format to the image's own path, like
+// /blog/x/fig.png.w640.0123456789.webp. The Site makes them when first requested
+// and keeps them in memory and, if set by Site.SetImageCache, in a directory.
+// Browsers may cache them indefinitely.
+//
 // The “{{markdown text}}” function interprets text (a string) as Markdown
 // and returns the equivalent HTML as a template.HTML.
 //
+// The “{{meta p}}” function returns the HTML tags giving the metadata of
+// the page p (a Page, usually “.”) for link previews and search engines,
+// for use in the <head> of the site template.
+// See “Page Metadata” below.
+//
 // The “{{page f}}” function returns the page data (a Page)
 // for the static page contained in the file f.
 // The lookup ignores trailing slashes in f as well as the presence or absence
@@ -200,6 +274,40 @@
 // function in these packages (except path.Split, which has more than one non-error result
 // and would not be invokable). For example, “{{strings.ToUpper "abc"}}”.
 //
+// # Page Metadata
+//
+// The “meta” template function derives a page's metadata from its
+// “title”, “summary”, “date”, and “by” (author or list of authors) keys
+// and from the information about the whole site set by Site.SetSiteInfo.
+// It returns a canonical link, a description, OpenGraph tags (og:title,
+// og:description, og:url, og:image, and so on) and a Twitter card.
+// A page with a date is an article, with article:published_time
+// and article:author tags, and a JSON-LD block describing it
+// as a schema.org Article.
+//
+// A page can override the derived metadata with a “meta” key
+// holding a map with any of these keys:
+//
+//   - title, description: the title and description to use
+//   - canonical: the canonical URL or URL path of the page
+//   - image: the URL or URL path of the preview image,
+//     relative to the page's URL unless it begins with a slash
+//   - type: the OpenGraph type, usually “article” or “website”
+//   - modified: the date an article was last updated
+//   - authors: the author or list of authors
+//   - schema: the schema.org type of the JSON-LD block, such as Article
+//     or SoftwareSourceCode, or “none” for no block
+//   - repository: the source code repository, for SoftwareSourceCode
+//
+// For example:
+//
+//	---
+//	title: Getting started
+//	meta:
+//	  description: Write and run your first Go program.
+//	  image: /images/tutorial.png
+//	---
+//
 // # Serving Requests
 //
 // A Site is an http.Handler that serves requests by consulting the underlying
@@ -276,6 +384,24 @@
 // where err is the “not exist” error returned by fs.Stat(fsys, p).
 // (See also the “Serving Errors” section below.)
 //
+// # Assets
+//
+// The Site.BuildAssets method builds the style sheets (.css) and scripts
+// (.js and .ts) in the given directories of fsys, once, using esbuild:
+// it bundles each file with the style sheets and ES modules it imports,
+// compiles TypeScript to JavaScript, and minifies the result.
+// Scripts remain classic scripts: their top-level declarations stay global.
+// Each built asset is served at a URL path derived from the file's path
+// and a hash of the built content, like /js/site.0123456789.js for js/site.js,
+// with a Cache-Control header allowing browsers to cache it indefinitely.
+// Templates use the “asset” function to refer to these URL paths.
+// The files themselves are still served at their own URL paths,
+// as usual, except that a request for a built TypeScript file
+// is served the built script.
+//
+// A Site that has not built its assets serves the files as they are,
+// which is convenient when editing them.
+//
 // # Serving Dynamic Requests
 //
 // Of course, a web site may wish to serve more than static content.
@@ -284,6 +410,83 @@
 // called with a dynamically generated Page value, which will then
 // be rendered and served as the result of the request.
 //
+// # Languages
+//
+// By default, a Site serves each page from a single file.
+// The Site.SetLanguages method declares a default language and a list of
+// other languages, each of which may have its own version of any page,
+// in a file named with the language tag before the extension:
+// p.zh.md, p.zh.html, p/index.zh.md, or p/index.zh.html hold
+// the Chinese version of the page otherwise loaded from p.md,
+// p.html, p/index.md, or p/index.html.
+// A page in another language has the same URL as the default page.
+//
+// To serve a page, the Site chooses the language named by the hl URL
+// query parameter, if the site has that language, or else the language
+// in the request's Accept-Language header with the highest quality
+// that the site has, or else the default language.
+// A language tag matches a site language with the same tag (ignoring case)
+// or, failing that, the same primary language subtag: "zh-TW" matches "zh".
+// If the page does not exist in the chosen language,
+// the Site serves the page in the default language.
+// A request for a language-specific path, like /p.zh,
+// redirects to the page URL with an hl parameter, like /p?hl=zh.
+// Responses carry Content-Language and “Vary: Accept-Language” headers.
+//
+// Pages on a site with more than one language have two more keys:
+// “lang”, the language of the page, and, if the page exists in
+// more than one language, “Alternates”, a []Alternate listing
+// the URL of each version of the page, for use in
+// <link rel="alternate" hreflang=...> tags.
+// The URLs are absolute, using the base URL set by SetSiteInfo, if any.
+// The first entry has language “x-default” and the plain page URL,
+// which serves the language chosen by the request headers.
+//
+// The WalkPages and Pages methods skip the pages in other languages,
+// so that listings of pages list each page once.
+//
+// # Drafts and Scheduled Pages
+//
+// A page with “draft: true” in its metadata, or with a “date” in the future,
+// is unpublished: the Site responds to requests for it as if it did not exist,
+// and the Pages method and the pages template function omit it from their results.
+// A page scheduled for a future date appears automatically once that time passes,
+// without restarting the server. If the rendered-page cache is enabled,
+// the Site discards the cached pages that listed other pages once a page
+// omitted from those lists is published.
+//
+// WalkPages does not omit unpublished pages, so that tools checking every
+// page see them too; callers listing pages for readers should use the
+// Published function to skip them.
+//
+// The Site.SetPreviewToken method sets a secret token that allows viewing
+// unpublished pages: a request with the URL query parameter preview=token
+// is served the page, with an “X-Robots-Tag: noindex” header.
+//
+// # Caching
+//
+// Pages rendered from files and the files served as raw bytes
+// carry an ETag header, and a GET request with a matching If-None-Match header
+// gets a 304 Not Modified response instead of the body.
+// A page's ETag is computed before rendering, so a 304 response costs no rendering.
+// It is a hash of the page file, the request's host and query,
+// and a digest of the whole site: the pages, templates, and data files
+// that any page can use, and the built assets (see “Assets” below).
+// The digest is recomputed when Site.Invalidate is called.
+// A file's ETag is a hash of its content, computed when the file is first
+// served and again after it changes.
+// Files are served by http.ServeContent, so they also handle Range requests
+// and, when the file system reports a modification time,
+// carry a Last-Modified header and handle If-Modified-Since requests.
+// Pages served by ServePage from a dynamically generated Page have no ETag.
+//
+// The Site.SetCacheControl method sets the Cache-Control header
+// to send for URL paths beginning with a given prefix.
+//
+// The Site.SetPageCache method enables an in-memory cache of rendered pages,
+// which the Site's owner must clear, by calling Site.Invalidate,
+// whenever the file system content changes.
+//
 // # Serving Errors
 //
 // If an error occurs while serving a request r,
@@ -305,6 +508,8 @@ package web
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"html"
@@ -313,11 +518,14 @@ import (
 	"io/fs"
 	"log"
 	"net/http"
+	"net/url"
 	"path"
 	"regexp"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/evanw/esbuild/pkg/api"
 	"golang.org/x/website/internal/spec"
@@ -331,6 +539,24 @@ type Site struct {
 	fileServer http.Handler     // http.FileServer(http.FS(fs))
 	funcs      template.FuncMap // accumulated from s.Funcs
 	cache      sync.Map         // canonical file path -> *pageFile, for site.openPage
+
+	cacheControl map[string]string          // URL path prefix -> Cache-Control header; see SetCacheControl
+	pages        *pageCache                 // rendered pages; nil if disabled; see SetPageCache
+	gen          atomic.Int64               // content generation, advanced by Invalidate
+	fileTags     sync.Map                   // file path -> *fileTag, for s.fileETag
+	siteDigest   atomic.Pointer[siteDigest] // digest of the content; see s.digest
+	digestMu     sync.Mutex                 // serializes computing siteDigest
+
+	lang  string   // default language; see SetLanguages
+	langs []string // other languages; see SetLanguages
+
+	previewToken string       // see SetPreviewToken
+	nextPublish  atomic.Int64 // unix nano time when a listed page is next published; 0 if none
+
+	assets atomic.Pointer[assetSet] // built assets; see BuildAssets
+	imgs   *imageSet                // image information and derived images; see image.go
+
+	info SiteInfo // see SetSiteInfo
 }
 
 // NewSite returns a new Site for serving pages from the file system fsys.
@@ -338,6 +564,7 @@ func NewSite(fsys fs.FS) *Site {
 	return &Site{
 		fs:         fsys,
 		fileServer: http.FileServer(http.FS(fsys)),
+		imgs:       newImageSet(),
 	}
 }
 
@@ -389,6 +616,8 @@ func (s *Site) ServeErrorStatus(w http.ResponseWriter, r *http.Request, err erro
 
 func (s *Site) serveErrorStatus(w http.ResponseWriter, r *http.Request, err error, status int, renderingError bool) {
 
+	// Drop any ETag set for the page that failed to render.
+	w.Header().Del("Etag")
 	if renderingError {
 		log.Printf("error rendering error: %v", err)
 		w.WriteHeader(status)
@@ -412,6 +641,7 @@ func (s *Site) serveErrorStatus(w http.ResponseWriter, r *http.Request, err erro
 // if p["URL"] is unset or does not have type string, then ServePage
 // sets p["URL"] to r.URL.Path in a clone of p before rendering the page.
 func (s *Site) ServePage(w http.ResponseWriter, r *http.Request, p Page) {
+	w = s.cacheControlWriter(w, r)
 	s.servePage(w, r, p, false)
 }
 
@@ -430,9 +660,21 @@ func (s *Site) servePage(w http.ResponseWriter, r *http.Request, p Page, renderi
 // ServeHTTP implements http.Handler, serving from a file in the site.
 // See the Site type documentation for details about how requests are handled.
 func (s *Site) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w = s.cacheControlWriter(w, r)
+
 	abspath := r.URL.Path
 	relpath := path.Clean(strings.TrimPrefix(abspath, "/"))
 
+	// Is it a fingerprinted asset?
+	if s.serveAsset(w, r) {
+		return
+	}
+
+	// Is it a resized image?
+	if s.serveImage(w, r) {
+		return
+	}
+
 	// Is it a TypeScript file?
 	if strings.HasSuffix(relpath, ".ts") {
 		s.serveTypeScript(w, r)
@@ -440,16 +682,38 @@ func (s *Site) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Is it a page we can generate?
-	if p, err := s.openPage(relpath); err == nil {
-		if p.url != abspath {
+	// A request for a page in a specific language, like /p.zh,
+	// redirects to the canonical path with ?hl=zh.
+	pagepath, hl := s.splitLang(relpath)
+	lang := hl
+	if lang == "" {
+		lang = s.language(r)
+	}
+	if p, err := s.openPageLang(pagepath, lang); err == nil {
+		if !Published(p.page) {
+			if !s.isPreview(r) {
+				s.ServeErrorStatus(w, r, &fs.PathError{Op: "open", Path: relpath, Err: fs.ErrNotExist}, http.StatusNotFound)
+				return
+			}
+			w.Header().Set("X-Robots-Tag", "noindex")
+		}
+		if p.url != abspath || hl != "" {
 			// Redirect to canonical path.
 			status := http.StatusMovedPermanently
 			if i, ok := p.page["status"].(int); ok {
 				status = i
 			}
-			http.Redirect(w, r, p.url, status)
+			target := p.url
+			if hl != "" {
+				target += "?hl=" + url.QueryEscape(hl)
+			}
+			http.Redirect(w, r, target, status)
 			return
 		}
+		if s.lang != "" {
+			w.Header().Set("Content-Language", p.lang)
+			w.Header().Add("Vary", "Accept-Language")
+		}
 		// Serve from the actual filesystem path.
 		s.serveHTML(w, r, p)
 		return
@@ -487,6 +751,13 @@ func (s *Site) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Serve raw bytes.
+	// The file server uses http.ServeContent, which handles
+	// If-None-Match using the ETag set here.
+	if !info.IsDir() {
+		if etag := s.fileETag(relpath, info); etag != "" {
+			w.Header().Set("Etag", etag)
+		}
+	}
 	s.fileServer.ServeHTTP(w, r)
 }
 
@@ -521,6 +792,14 @@ func (s *Site) serveHTML(w http.ResponseWriter, r *http.Request, p *pageFile) {
 	filePath, _ := p.page["File"].(string)
 	isMarkdown := strings.HasSuffix(filePath, ".md")
 
+	// Only a page whose content depends on the files alone
+	// and that is served with status 200 has an ETag.
+	if _, ok := p.page["status"]; !ok && p.page["cache"] != false {
+		if s.notModified(w, r, s.pageETag(r, p.file, p.hash)) {
+			return
+		}
+	}
+
 	// if it begins with "<!DOCTYPE " assume it is standalone
 	// html that doesn't need the template wrapping.
 	if strings.HasPrefix(src, "<!DOCTYPE ") {
@@ -528,21 +807,60 @@ func (s *Site) serveHTML(w http.ResponseWriter, r *http.Request, p *pageFile) {
 		return
 	}
 
-	// if it's the language spec, add tags to EBNF productions
-	if strings.HasSuffix(filePath, "ref/spec.html") {
-		var buf bytes.Buffer
-		spec.Linkify(&buf, []byte(src))
-		src = buf.String()
-	}
+	s.serveCachedPage(w, r, p.file, func() Page {
+		// if it's the language spec, add tags to EBNF productions
+		if strings.HasSuffix(filePath, "ref/spec.html") {
+			var buf bytes.Buffer
+			spec.Linkify(&buf, []byte(src))
+			src = buf.String()
+		}
 
-	// For non-Markdown files without "template: true", set Content here to
-	// skip renderHTML processing. Markdown files must always go through
-	// renderHTML for Markdown-to-HTML conversion.
-	isTemplate, _ := p.page["template"].(bool)
-	if !isTemplate && !isMarkdown {
-		p.page["Content"] = template.HTML(src)
+		// For non-Markdown files without "template: true", set Content here to
+		// skip renderHTML processing. Markdown files must always go through
+		// renderHTML for Markdown-to-HTML conversion.
+		isTemplate, _ := p.page["template"].(bool)
+		if !isTemplate && !isMarkdown {
+			p.page["Content"] = template.HTML(src)
+		}
+		return p.page
+	})
+}
+
+// serveCachedPage is like ServePage(w, r, page()) but uses the
+// rendered-page cache, if enabled, to avoid preparing and rendering
+// the page loaded from file.
+func (s *Site) serveCachedPage(w http.ResponseWriter, r *http.Request, file string, page func() Page) {
+	c := s.pages
+	if c == nil || r.URL.Query().Has("preview") {
+		// Preview requests can see drafts and scheduled pages,
+		// so their pages must not be cached for other requests;
+		// and caching them would let each token guess add a cache entry.
+		s.ServePage(w, r, page())
+		return
 	}
-	s.ServePage(w, r, p.page)
+	s.checkSchedule()
+	key := c.key(file, r)
+	cp, ok := c.get(key)
+	if !ok {
+		p := page()
+		if p["cache"] == false {
+			s.ServePage(w, r, p)
+			return
+		}
+		html, err := s.renderHTML(p, "site.tmpl", r)
+		if err != nil {
+			// Let ServePage report the error.
+			s.ServePage(w, r, p)
+			return
+		}
+		status, _ := p["status"].(int)
+		cp = &cachedPage{key: key, status: status, html: html}
+		c.add(cp)
+	}
+	if cp.status != 0 {
+		w.WriteHeader(cp.status)
+	}
+	w.Write(cp.html)
 }
 
 func (s *Site) serveDir(w http.ResponseWriter, r *http.Request, relpath string) {
@@ -585,25 +903,32 @@ func (s *Site) serveText(w http.ResponseWriter, r *http.Request, relpath string)
 		return
 	}
 
-	cfg := texthtml.Config{
-		GoComments: path.Ext(relpath) == ".go",
-		Highlight:  r.FormValue("h"),
-		Selection:  rangeSelection(r.FormValue("s")),
-		Line:       1,
+	sum := sha256.Sum256(src)
+	if s.notModified(w, r, s.pageETag(r, relpath, hex.EncodeToString(sum[:]))) {
+		return
 	}
 
-	var buf bytes.Buffer
-	buf.WriteString("<pre>")
-	buf.Write(texthtml.Format(src, cfg))
-	buf.WriteString("</pre>")
+	s.serveCachedPage(w, r, relpath, func() Page {
+		cfg := texthtml.Config{
+			GoComments: path.Ext(relpath) == ".go",
+			Highlight:  r.FormValue("h"),
+			Selection:  rangeSelection(r.FormValue("s")),
+			Line:       1,
+		}
 
-	fmt.Fprintf(&buf, `<p><a href="/%s?m=text">View as plain text</a></p>`, html.EscapeString(relpath))
+		var buf bytes.Buffer
+		buf.WriteString("<pre>")
+		buf.Write(texthtml.Format(src, cfg))
+		buf.WriteString("</pre>")
 
-	s.ServePage(w, r, Page{
-		"URL":      r.URL.Path,
-		"File":     relpath,
-		"layout":   "texthtml",
-		"texthtml": template.HTML(buf.String()),
+		fmt.Fprintf(&buf, `<p><a href="/%s?m=text">View as plain text</a></p>`, html.EscapeString(relpath))
+
+		return Page{
+			"URL":      r.URL.Path,
+			"File":     relpath,
+			"layout":   "texthtml",
+			"texthtml": template.HTML(buf.String()),
+		}
 	})
 }
 
@@ -636,8 +961,19 @@ type jsout struct {
 	stat   fs.FileInfo // stat for file when page was loaded
 }
 
+// serveTypeScript serves the TypeScript file named by r's URL path
+// compiled to JavaScript: the built asset, if the Site has built it
+// (see BuildAssets), or else the file compiled as it is now.
 func (s *Site) serveTypeScript(w http.ResponseWriter, r *http.Request) {
 	filename := path.Clean(strings.TrimPrefix(r.URL.Path, "/"))
+	if set := s.assets.Load(); set != nil {
+		if a := set.byName["/"+filename]; a != nil {
+			w.Header().Set("Content-Type", a.ctype)
+			w.Header().Set("Etag", `"`+a.hash+`"`)
+			http.ServeContent(w, r, filename, time.Time{}, bytes.NewReader(a.data))
+			return
+		}
+	}
 	if cjs, ok := s.cache.Load(filename); ok {
 		js := cjs.(*jsout)
 		info, err := fs.Stat(s.fs, filename)
@@ -5,14 +5,26 @@
 package web
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"path"
+	"path/filepath"
 	"strings"
 	"syscall"
 	"testing"
 	"testing/fstest"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 )
@@ -66,6 +78,110 @@ func TestMarkdown(t *testing.T) {
 	testServeBody(t, site, "/doc/test4", `{{x}}`)
 }
 
+func TestTOC(t *testing.T) {
+	site := NewSite(fstest.MapFS{
+		"site.tmpl": {Data: []byte(`{{range .TOC}}[{{.ID}} {{.Text}}{{range .Sub}} ({{.ID}} {{.Text}}){{end}}]{{end}}|{{.Content}}`)},
+		"doc/long.md": {Data: []byte("# Title\n\n" +
+			"## Introduction\n\n" +
+			"### Why `go`?\n\n" +
+			"## Details {#why-go}\n\n" +
+			"### Why `go`?\n\n" +
+			"#### Deep\n\n" +
+			"## Introduction\n")},
+		"doc/short.md": {Data: []byte("## Only\n\nOne heading.\n")},
+		"doc/off.md":   {Data: []byte("---\ntoc: false\n---\n## One\n## Two\n")},
+		"doc/early.md": {Data: []byte("### First\n## Second\n")},
+	})
+
+	const toc = "[introduction Introduction (why-go Why go?)]" +
+		"[why-go-2 Details (why-go-1 Why go?)]" +
+		"[introduction-1 Introduction]|"
+	testServeBody(t, site, "/doc/long", toc)
+	testServeBody(t, site, "/doc/long", `<h2 id="why-go-2">Details</h2>`)
+	testServeBody(t, site, "/doc/long", `<h4 id="deep">Deep</h4>`)
+	testServeBody(t, site, "/doc/short", "|<div")
+	testServeBody(t, site, "/doc/off", "|<div class='markdown'>\n<h2 id=\"one\">")
+	testServeBody(t, site, "/doc/early", "[first First][second Second]|")
+}
+
+func TestMarkdownExtensions(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		in   string
+		want []string // substrings of output, in order
+		not  []string // substrings not in output
+	}{
+		{
+			name: "callout",
+			in:   "> [!WARNING]\n> Not *safe*.\n>\n> Really.\n",
+			want: []string{`<div class="Callout Callout--warning" role="note">`, `<p class="Callout-title">Warning</p>`, "<p>Not <em>safe</em>.</p>", "<p>Really.</p>", "</div>"},
+			not:  []string{"blockquote", "[!WARNING]"},
+		},
+		{
+			name: "callout same line",
+			in:   "> [!note]\n> Hi.\n",
+			want: []string{`<div class="Callout Callout--note" role="note">`, `<p class="Callout-title">Note</p>`, "<p>Hi.</p>"},
+		},
+		{
+			name: "callout alone",
+			in:   "> [!DEPRECATED]\n\n> Plain.\n",
+			want: []string{"Callout--deprecated", "<blockquote>\n<p>Plain.</p>"},
+		},
+		{
+			name: "not callout",
+			in:   "> [!TIP] Text.\n",
+			want: []string{"<blockquote>", "[!TIP] Text."},
+			not:  []string{"Callout"},
+		},
+		{
+			name: "footnote",
+			in:   "Text.[^a]\n\n[^a]: The note.\n",
+			want: []string{`<a href="#fn:1" class="footnote-ref" role="doc-noteref">1</a>`, `<div class="footnotes" role="doc-endnotes">`, "The note."},
+		},
+		{
+			name: "tabs",
+			in: "```sh tab=Linux\nls\n```\n\n```bat tab=\"Windows cmd\"\ndir\n```\n\n" +
+				"Between.\n\n```go tab=Go\nx\n```\n\n```sh\nplain\n```\n",
+			want: []string{
+				`<div class="CodeTabs">`,
+				`<div class="CodeTabs-list" role="tablist">`,
+				`<button type="button" class="CodeTabs-tab" role="tab" id="codetabs-1-tab-1" aria-controls="codetabs-1-panel-1" aria-selected="true" tabindex="0">Linux</button>`,
+				`<button type="button" class="CodeTabs-tab" role="tab" id="codetabs-1-tab-2" aria-controls="codetabs-1-panel-2" aria-selected="false" tabindex="-1">Windows cmd</button>`,
+				`<div class="CodeTabs-panel" role="tabpanel" id="codetabs-1-panel-1" aria-labelledby="codetabs-1-tab-1" tabindex="0">`,
+				`<pre><code class="language-sh">ls`,
+				`<div class="CodeTabs-panel" role="tabpanel" id="codetabs-1-panel-2" aria-labelledby="codetabs-1-tab-2" tabindex="0" hidden>`,
+				`<pre><code class="language-bat">dir`,
+				"<p>Between.</p>",
+				`id="codetabs-2-tab-1"`, ">Go</button>",
+				`</div>
+<pre><code class="language-sh">plain`,
+			},
+			not: []string{"codetabs-2-tab-2", "codetabs-3"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			html, err := markdownToHTML(tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out := string(html)
+			rest := out
+			for _, w := range tt.want {
+				i := strings.Index(rest, w)
+				if i < 0 {
+					t.Fatalf("output missing %q (in order):\n%s", w, out)
+				}
+				rest = rest[i+len(w):]
+			}
+			for _, n := range tt.not {
+				if strings.Contains(out, n) {
+					t.Errorf("output contains %q:\n%s", n, out)
+				}
+			}
+		})
+	}
+}
+
 func TestCode(t *testing.T) {
 	site := NewSite(fstest.MapFS{
 		"site.tmpl": {Data: []byte(`{{.Content}}`)},
@@ -217,3 +333,789 @@ func TestTypeScript(t *testing.T) {
 		})
 	}
 }
+
+func TestAssets(t *testing.T) {
+	site := NewSite(fstest.MapFS{
+		"site.tmpl":      {Data: []byte(`<link href="{{asset "/css/styles.css"}}"><script src="{{asset "../js/util.ts"}}"></script><img src="{{asset "/img.png"}}">`)},
+		"doc/page.md":    {Data: []byte("Hello.")},
+		"css/styles.css": {Data: []byte("@import \"fonts.css\";\nbody {\n  color: #ff0000;\n}\n")},
+		"css/fonts.css":  {Data: []byte("@font-face {\n  font-family: Go;\n  src: url('/fonts/Go.woff');\n}\n")},
+		"js/site.js":     {Data: []byte("import { double } from \"./util.ts\";\nfunction unusedHelper(argument) {\n  return double(argument);\n}\n")},
+		"js/util.ts":     {Data: []byte("export function double(x: number): number {\n  return x * 2;\n}\nexport function unused() {}\n")},
+		"js/umd.js":      {Data: []byte("(function (g, f) {\n  typeof module == \"object\" ? module.exports = f() : g.lib = f();\n})(this, function () { return 1; });\n")},
+		"js/notes.txt":   {Data: []byte("not an asset")},
+	})
+
+	// Before BuildAssets, templates refer to the files themselves.
+	testServeBody(t, site, "/doc/page", `<link href="/css/styles.css"><script src="/js/util.ts">`)
+
+	if err := site.BuildAssets("css", "js"); err != nil {
+		t.Fatal(err)
+	}
+	css := site.AssetURL("/css/styles.css")
+	ts := site.AssetURL("/js/util.ts")
+	if !strings.HasPrefix(css, "/css/styles.") || !strings.HasSuffix(css, ".css") || len(css) != len("/css/styles.0123456789.css") {
+		t.Fatalf("AssetURL(/css/styles.css) = %q", css)
+	}
+	if !strings.HasPrefix(ts, "/js/util.") || !strings.HasSuffix(ts, ".js") {
+		t.Fatalf("AssetURL(/js/util.ts) = %q", ts)
+	}
+	if u := site.AssetURL("/js/notes.txt"); u != "/js/notes.txt" {
+		t.Errorf("AssetURL(/js/notes.txt) = %q, want unchanged", u)
+	}
+	testServeBody(t, site, "/doc/page", `<link href="`+css+`"><script src="`+ts+`"></script><img src="/img.png">`)
+
+	get := func(path, inm string) *httptest.ResponseRecorder {
+		t.Helper()
+		r := httptest.NewRequest("GET", path, nil)
+		if inm != "" {
+			r.Header.Set("If-None-Match", inm)
+		}
+		w := httptest.NewRecorder()
+		site.ServeHTTP(w, r)
+		return w
+	}
+	for _, tt := range []struct {
+		url   string
+		ctype string
+		want  []string
+	}{
+		{css, "text/css; charset=utf-8", []string{"src:url(/fonts/Go.woff)", "body{color:red}"}},
+		{site.AssetURL("/js/site.js"), "text/javascript; charset=utf-8", []string{"function double(x){return x*2}", "function unusedHelper(argument){return double(argument)}"}},
+	} {
+		w := get(tt.url, "")
+		if w.Code != 200 {
+			t.Fatalf("GET %s: status %d", tt.url, w.Code)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != tt.ctype {
+			t.Errorf("GET %s: Content-Type %q, want %q", tt.url, ct, tt.ctype)
+		}
+		if cc := w.Header().Get("Cache-Control"); cc != assetCacheControl {
+			t.Errorf("GET %s: Cache-Control %q, want %q", tt.url, cc, assetCacheControl)
+		}
+		for _, s := range tt.want {
+			if !strings.Contains(w.Body.String(), s) {
+				t.Errorf("GET %s: body missing %q:\n%s", tt.url, s, w.Body)
+			}
+		}
+		// The ETag is the content hash that the URL's fingerprint abbreviates.
+		etag := w.Header().Get("Etag")
+		fingerprint := strings.TrimSuffix(tt.url[strings.LastIndex(tt.url, "/")+1:], path.Ext(tt.url))
+		fingerprint = fingerprint[strings.LastIndex(fingerprint, ".")+1:]
+		if !strings.HasPrefix(etag, `"`+fingerprint) || !strings.HasSuffix(etag, `"`) {
+			t.Errorf("GET %s: ETag %q, want quoted hash beginning with %s", tt.url, etag, fingerprint)
+		}
+		if w := get(tt.url, etag); w.Code != http.StatusNotModified || w.Header().Get("Cache-Control") != assetCacheControl {
+			t.Errorf("GET %s with If-None-Match %s: status %d, Cache-Control %q, want 304 with %q", tt.url, etag, w.Code, w.Header().Get("Cache-Control"), assetCacheControl)
+		}
+	}
+
+	// A script importing nothing is only minified, not wrapped as a module.
+	if w := get(site.AssetURL("/js/umd.js"), ""); w.Code != 200 || !strings.Contains(w.Body.String(), ".lib=") || strings.Contains(w.Body.String(), "__commonJS") {
+		t.Errorf("GET umd.js: status %d, body:\n%s", w.Code, w.Body)
+	}
+
+	// The files themselves are still served, without the long-term caching.
+	if w := get("/css/styles.css", ""); w.Code != 200 || !strings.Contains(w.Body.String(), "#ff0000") || w.Header().Get("Cache-Control") != "" {
+		t.Errorf("GET /css/styles.css: status %d, Cache-Control %q, body:\n%s", w.Code, w.Header().Get("Cache-Control"), w.Body)
+	}
+
+	// A TypeScript file is served as its built script.
+	if w := get("/js/util.ts", ""); w.Code != 200 || w.Body.String() != string(site.assets.Load().byName["/js/util.ts"].data) || w.Header().Get(cacheHeader) != "" {
+		t.Errorf("GET /js/util.ts: status %d, %s: %q, body:\n%s", w.Code, cacheHeader, w.Header().Get(cacheHeader), w.Body)
+	}
+
+	// Another site with the same files can use the same assets.
+	other := NewSite(site.fs)
+	other.UseAssets(site)
+	if u := other.AssetURL("/css/styles.css"); u != css {
+		t.Errorf("after UseAssets, AssetURL(/css/styles.css) = %q, want %q", u, css)
+	}
+	if w := httptest.NewRecorder(); !other.serveAsset(w, httptest.NewRequest("GET", css, nil)) || w.Code != 200 {
+		t.Errorf("after UseAssets, GET %s: status %d", css, w.Code)
+	}
+
+	// A failed build reports the error and keeps the previous assets.
+	site.fs.(fstest.MapFS)["js/bad.js"] = &fstest.MapFile{Data: []byte("function (")}
+	if err := site.BuildAssets("css", "js"); err == nil || !strings.Contains(err.Error(), "/js/bad.js") {
+		t.Errorf("BuildAssets with bad.js: err = %v, want error mentioning /js/bad.js", err)
+	}
+	if u := site.AssetURL("/css/styles.css"); u != css {
+		t.Errorf("after failed build, AssetURL(/css/styles.css) = %q, want %q", u, css)
+	}
+	if u := site.AssetURL("/js/bad.js"); u != "/js/bad.js" {
+		t.Errorf("after failed build, AssetURL(/js/bad.js) = %q, want unchanged", u)
+	}
+	testServeBody(t, site, "/doc/page", `<link href="`+css+`"><script src="`+ts+`"></script>`)
+	if w := get(css, ""); w.Code != 200 || w.Header().Get("Cache-Control") != assetCacheControl || w.Header().Get("Etag") == "" {
+		t.Errorf("after failed build, GET %s: status %d, Cache-Control %q, ETag %q, want 200 with %q and an ETag", css, w.Code, w.Header().Get("Cache-Control"), w.Header().Get("Etag"), assetCacheControl)
+	}
+
+	// A failed first build leaves the site using the files themselves.
+	site = NewSite(site.fs)
+	if err := site.BuildAssets("css", "js"); err == nil {
+		t.Errorf("BuildAssets with bad.js on new site: no error")
+	}
+	testServeBody(t, site, "/doc/page", `<link href="/css/styles.css"><script src="/js/util.ts">`)
+	if w := get(css, ""); w.Code == 200 {
+		t.Errorf("GET %s on site without assets: status 200, want error", css)
+	}
+}
+
+func TestImages(t *testing.T) {
+	// A 1000×500 PNG, red on the left and blue on the right.
+	m := image.NewRGBA(image.Rect(0, 0, 1000, 500))
+	for y := 0; y < 500; y++ {
+		for x := 0; x < 1000; x++ {
+			c := color.RGBA{255, 0, 0, 255}
+			if x >= 500 {
+				c = color.RGBA{0, 0, 255, 255}
+			}
+			m.SetRGBA(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, m); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	hash := hex.EncodeToString(sum[:5])
+	fsys := fstest.MapFS{
+		"site.tmpl":   {Data: []byte(`{{img "fig.png" "A <figure>"}}`)},
+		"error.tmpl":  {Data: []byte(`{{define "layout"}}{{.error}}{{end}}`)},
+		"doc/page.md": {Data: []byte("Hello.")},
+		"doc/fig.png": {Data: buf.Bytes()},
+	}
+	site := NewSite(fsys)
+
+	srcset := func(format string) string {
+		return fmt.Sprintf("fig.png.w320.%[1]s.%[2]s 320w, fig.png.w640.%[1]s.%[2]s 640w, fig.png.w960.%[1]s.%[2]s 960w, ", hash, format)
+	}
+	testServeBody(t, site, "/doc/page",
+		`<img src="fig.png" srcset="`+srcset("png")+`fig.png 1000w" sizes="`+imageSizes+`" width="1000" height="500" alt="A &lt;figure&gt;" loading="lazy" decoding="async">`)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		site.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+	variant := "/doc/fig.png.w640." + hash + ".png"
+	w := get(variant)
+	if w.Code != 200 || w.Header().Get("Content-Type") != "image/png" || w.Header().Get("Cache-Control") != assetCacheControl {
+		t.Fatalf("GET %s: status %d, Content-Type %q, Cache-Control %q", variant, w.Code, w.Header().Get("Content-Type"), w.Header().Get("Cache-Control"))
+	}
+	small, err := png.Decode(w.Body)
+	if err != nil {
+		t.Fatalf("GET %s: %v", variant, err)
+	}
+	if b := small.Bounds(); b.Dx() != 640 || b.Dy() != 320 {
+		t.Errorf("GET %s: image is %d×%d, want 640×320", variant, b.Dx(), b.Dy())
+	}
+	if r, _, b, _ := small.At(100, 100).RGBA(); r>>8 != 255 || b != 0 {
+		t.Errorf("GET %s: left pixel not red", variant)
+	}
+
+	// A stale hash redirects to the current version.
+	if w := get("/doc/fig.png.w640.0000000000.png"); w.Code != http.StatusFound || w.Header().Get("Location") != variant {
+		t.Errorf("GET stale variant: status %d, Location %q, want 302 to %s", w.Code, w.Header().Get("Location"), variant)
+	}
+	for _, path := range []string{
+		"/doc/fig.png.w500." + hash + ".png",  // not a variant width
+		"/doc/fig.png.w1280." + hash + ".png", // wider than the source
+		"/doc/fig.png.w640." + hash + ".jpg",  // wrong format
+		"/doc/fig.png.w640." + hash + ".webp", // no WebP encoder
+		"/doc/other.png.w640." + hash + ".png",
+	} {
+		if w := get(path); w.Code != http.StatusNotFound {
+			t.Errorf("GET %s: status %d, want 404", path, w.Code)
+		}
+	}
+
+	// With a WebP encoder, the image is offered as WebP too.
+	site = NewSite(fsys)
+	site.SetWebPEncoder(func(w io.Writer, m image.Image) error {
+		_, err := fmt.Fprintf(w, "WEBP %d×%d", m.Bounds().Dx(), m.Bounds().Dy())
+		return err
+	})
+	testServeBody(t, site, "/doc/page",
+		`<picture><source type="image/webp" srcset="`+srcset("webp")+`fig.png.w1000.`+hash+`.webp 1000w" sizes="`+imageSizes+`"><img src="fig.png" srcset="`+srcset("png"))
+	for path, want := range map[string]string{
+		"/doc/fig.png.w320." + hash + ".webp":  "WEBP 320×160",
+		"/doc/fig.png.w1000." + hash + ".webp": "WEBP 1000×500",
+	} {
+		if w := get(path); w.Code != 200 || w.Header().Get("Content-Type") != "image/webp" || w.Body.String() != want {
+			t.Errorf("GET %s: status %d, Content-Type %q, body %q, want %q", path, w.Code, w.Header().Get("Content-Type"), w.Body, want)
+		}
+	}
+
+	// If the WebP encoder fails, the WebP URL serves the image in its own format.
+	site = NewSite(fsys)
+	site.SetWebPEncoder(func(w io.Writer, m image.Image) error {
+		return fmt.Errorf("no cwebp")
+	})
+	w = get("/doc/fig.png.w320." + hash + ".webp")
+	if w.Code != 200 || w.Header().Get("Content-Type") != "image/png" || !strings.HasSuffix(w.Header().Get("Etag"), `-png"`) {
+		t.Errorf("GET WebP variant with failing encoder: status %d, Content-Type %q, ETag %s, want 200 image/png", w.Code, w.Header().Get("Content-Type"), w.Header().Get("Etag"))
+	} else if m, err := png.Decode(w.Body); err != nil || m.Bounds().Dx() != 320 {
+		t.Errorf("GET WebP variant with failing encoder: not a 320-pixel-wide PNG (%v)", err)
+	}
+
+	// The image cache directory keeps derived images across sites.
+	dir := t.TempDir()
+	site = NewSite(fsys)
+	site.SetImageCache(dir)
+	get(variant)
+	cached := filepath.Join(dir, hash+"-w640.png")
+	if err := os.WriteFile(cached, []byte("cached"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	site = NewSite(fsys)
+	site.SetImageCache(dir)
+	if w := get(variant); w.Body.String() != "cached" {
+		t.Errorf("GET %s with image cache: did not use cached file", variant)
+	}
+}
+
+func TestResize(t *testing.T) {
+	for _, tt := range []struct{ src, dst int }{{4, 2}, {1000, 640}, {7, 3}, {5, 5}} {
+		for i, ws := range resizeWeights(tt.src, tt.dst) {
+			var total float32
+			for _, w := range ws {
+				total += w.w
+			}
+			if total < 0.999 || total > 1.001 {
+				t.Errorf("resizeWeights(%d, %d)[%d] = %v, total %v, want 1", tt.src, tt.dst, i, ws, total)
+			}
+		}
+	}
+
+	m := image.NewGray(image.Rect(0, 0, 4, 1))
+	copy(m.Pix, []uint8{0, 100, 200, 255})
+	r := resize(m, 2, 1)
+	if got, want := r.Pix, []uint8{50, 50, 50, 255, 228, 228, 228, 255}; !bytes.Equal(got, want) {
+		t.Errorf("resize([0 100 200 255], 2, 1) = %v, want %v", got, want)
+	}
+
+	// Shrinking a one-pixel checkerboard by any factor gives uniform gray,
+	// where point sampling would alias to black, white, or stripes.
+	m = image.NewGray(image.Rect(0, 0, 100, 100))
+	for i := range m.Pix {
+		if (i%100+i/100)%2 == 0 {
+			m.Pix[i] = 255
+		}
+	}
+	for _, size := range []int{50, 30, 7} {
+		r := resize(m, size, size)
+		for i := 0; i < len(r.Pix); i += 4 {
+			if v := r.Pix[i]; v < 127-12 || v > 128+12 {
+				t.Errorf("resize(checkerboard, %d, %d): pixel %d = %d, want gray", size, size, i/4, v)
+				break
+			}
+		}
+	}
+}
+
+func TestMeta(t *testing.T) {
+	site := NewSite(fstest.MapFS{
+		"site.tmpl": {Data: []byte(`{{meta .}}`)},
+		"blog/post.md": {Data: []byte(`---
+title: A <Post>
+summary: All about it.
+date: 2024-05-06
+by:
+- Ann
+- Bob
+---
+Text.`)},
+		"doc/index.md": {Data: []byte(`---
+title: Docs
+summary: Documentation.
+meta:
+  image: card.png
+  schema: SoftwareSourceCode
+  repository: https://go.googlesource.com/go
+---
+Text.`)},
+		"doc/plain.html": {Data: []byte(`<!--{
+	"Title": "Plain",
+	"Date": "2020-01-02",
+	"Meta": {"schema": "none", "canonical": "/doc/", "modified": "2021-03-04T05:06:07Z"}
+}-->
+Text.`)},
+	})
+	site.SetSiteInfo(SiteInfo{
+		BaseURL: "https://go.dev/",
+		Name:    "The Go Programming Language",
+		Image:   "/images/gopher.png",
+		Twitter: "@golang",
+	})
+
+	testServeBody(t, site, "/blog/post", `<link rel="canonical" href="https://go.dev/blog/post">
+<meta name="description" content="All about it.">
+<meta property="og:site_name" content="The Go Programming Language">
+<meta property="og:type" content="article">
+<meta property="og:url" content="https://go.dev/blog/post">
+<meta property="og:title" content="A &lt;Post&gt;">
+<meta property="og:description" content="All about it.">
+<meta property="og:image" content="https://go.dev/images/gopher.png">
+<meta property="article:published_time" content="2024-05-06T00:00:00Z">
+<meta property="article:author" content="Ann">
+<meta property="article:author" content="Bob">
+<meta name="twitter:card" content="summary">
+<meta name="twitter:site" content="@golang">
+<script type="application/ld+json">{"@context":"https://schema.org","@type":"Article","url":"https://go.dev/blog/post","headline":"A \u003cPost\u003e","description":"All about it.","image":"https://go.dev/images/gopher.png","datePublished":"2024-05-06T00:00:00Z","author":[{"@type":"Person","name":"Ann"},{"@type":"Person","name":"Bob"}],"publisher":{"@type":"Organization","name":"The Go Programming Language"}}</script>
+`)
+
+	testServeBody(t, site, "/doc/", `<meta property="og:type" content="website">
+<meta property="og:url" content="https://go.dev/doc/">
+<meta property="og:title" content="Docs">
+<meta property="og:description" content="Documentation.">
+<meta property="og:image" content="https://go.dev/doc/card.png">
+<meta name="twitter:card" content="summary">
+<meta name="twitter:site" content="@golang">
+<script type="application/ld+json">{"@context":"https://schema.org","@type":"SoftwareSourceCode","url":"https://go.dev/doc/","name":"Docs","description":"Documentation.","image":"https://go.dev/doc/card.png","codeRepository":"https://go.googlesource.com/go","programmingLanguage":"Go"}</script>
+`)
+
+	// JSON metadata works too, and schema: none omits the JSON-LD.
+	for _, want := range []string{
+		`<link rel="canonical" href="https://go.dev/doc/">`,
+		`<meta property="og:title" content="Plain">`,
+		`<meta property="article:published_time" content="2020-01-02T00:00:00Z">`,
+		`<meta property="article:modified_time" content="2021-03-04T05:06:07Z">`,
+	} {
+		testServeBody(t, site, "/doc/plain", want)
+	}
+	w := httptest.NewRecorder()
+	site.ServeHTTP(w, httptest.NewRequest("GET", "/doc/plain", nil))
+	if strings.Contains(w.Body.String(), "ld+json") || strings.Contains(w.Body.String(), "og:description") {
+		t.Errorf("/doc/plain has JSON-LD or description:\n%s", w.Body)
+	}
+}
+
+func TestConditional(t *testing.T) {
+	fsys := fstest.MapFS{
+		"site.tmpl":         {Data: []byte(`{{.Content}}{{render}}`)},
+		"error.tmpl":        {Data: []byte(`{{define "layout"}}{{.error}}{{end}}`)},
+		"doc/page.md":       {Data: []byte("Hello, *page*.")},
+		"images/gopher.png": {Data: []byte("\x89PNG gopher"), ModTime: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)},
+	}
+	site := NewSite(fsys)
+	renders := 0
+	site.Funcs(template.FuncMap{"render": func() string { renders++; return "" }})
+	site.SetCacheControl("/", "no-cache")
+	site.SetCacheControl("/images/", "public, max-age=3600")
+
+	get := func(path string, hdr ...string) *httptest.ResponseRecorder {
+		t.Helper()
+		r := httptest.NewRequest("GET", path, nil)
+		for i := 0; i+1 < len(hdr); i += 2 {
+			r.Header.Set(hdr[i], hdr[i+1])
+		}
+		w := httptest.NewRecorder()
+		site.ServeHTTP(w, r)
+		return w
+	}
+
+	for _, tt := range []struct {
+		path string
+		cc   string
+	}{
+		{"/doc/page", "no-cache"},
+		{"/images/gopher.png", "public, max-age=3600"},
+	} {
+		w := get(tt.path)
+		etag := w.Header().Get("Etag")
+		if w.Code != 200 || etag == "" {
+			t.Fatalf("GET %s: %d, ETag %q, want 200 with ETag", tt.path, w.Code, etag)
+		}
+		if cc := w.Header().Get("Cache-Control"); cc != tt.cc {
+			t.Errorf("GET %s: Cache-Control = %q, want %q", tt.path, cc, tt.cc)
+		}
+		if w2 := get(tt.path); w2.Header().Get("Etag") != etag || w2.Body.String() != w.Body.String() {
+			t.Errorf("GET %s: ETag changed from %s to %s", tt.path, etag, w2.Header().Get("Etag"))
+		}
+
+		w = get(tt.path, "If-None-Match", `"xyzzy", W/`+etag)
+		if w.Code != 304 || w.Body.Len() != 0 {
+			t.Errorf("GET %s with If-None-Match: %d with %d-byte body, want 304 without body", tt.path, w.Code, w.Body.Len())
+		}
+		if cc := w.Header().Get("Cache-Control"); cc != tt.cc {
+			t.Errorf("GET %s with If-None-Match: Cache-Control = %q, want %q", tt.path, cc, tt.cc)
+		}
+		if w := get(tt.path, "If-None-Match", `"xyzzy"`); w.Code != 200 {
+			t.Errorf("GET %s with other If-None-Match: %d, want 200", tt.path, w.Code)
+		}
+	}
+
+	w := get("/images/gopher.png", "If-Modified-Since", "Sat, 03 Jan 2026 00:00:00 GMT")
+	if w.Code != 304 {
+		t.Errorf("GET /images/gopher.png with If-Modified-Since: %d, want 304", w.Code)
+	}
+	w = get("/images/gopher.png", "Range", "bytes=5-")
+	if w.Code != 206 || w.Body.String() != "gopher" || w.Header().Get("Cache-Control") != "public, max-age=3600" {
+		t.Errorf("GET /images/gopher.png with Range: %d, Cache-Control %q, body %q, want 206, public, max-age=3600, gopher", w.Code, w.Header().Get("Cache-Control"), w.Body)
+	}
+
+	// A matching If-None-Match skips rendering the page,
+	// until a change to any of the site's content changes the page's ETag.
+	etag := get("/doc/page").Header().Get("Etag")
+	n := renders
+	if w := get("/doc/page", "If-None-Match", etag); w.Code != 304 || renders != n {
+		t.Errorf("GET /doc/page with If-None-Match: %d after %d renders, want 304 after none", w.Code, renders-n)
+	}
+	site.Invalidate()
+	if w := get("/doc/page", "If-None-Match", etag); w.Code != 304 {
+		t.Errorf("GET /doc/page with If-None-Match after Invalidate without changes: %d, want 304", w.Code)
+	}
+
+	// Another Site serving the same content, as in another process, has the same ETags.
+	other := NewSite(fsys)
+	other.Funcs(template.FuncMap{"render": func() string { return "" }})
+	w = httptest.NewRecorder()
+	other.ServeHTTP(w, httptest.NewRequest("GET", "/doc/page", nil))
+	if w.Header().Get("Etag") != etag {
+		t.Errorf("GET /doc/page from another Site: ETag %s, want %s", w.Header().Get("Etag"), etag)
+	}
+
+	for _, change := range []struct {
+		name string
+		data string
+	}{
+		{"site.tmpl", `<title>New</title>{{.Content}}{{render}}`},
+		{"doc/other.md", "A new page, perhaps listed by the first."},
+	} {
+		fsys[change.name] = &fstest.MapFile{Data: []byte(change.data)}
+		site.Invalidate()
+		w := get("/doc/page", "If-None-Match", etag)
+		if w.Code != 200 || w.Header().Get("Etag") == etag {
+			t.Errorf("GET /doc/page with If-None-Match after changing %s: %d, ETag %s, want 200 with new ETag", change.name, w.Code, w.Header().Get("Etag"))
+		}
+		etag = w.Header().Get("Etag")
+	}
+
+	w = get("/doc/missing")
+	if w.Code != 404 || w.Header().Get("Etag") != "" || w.Header().Get("Cache-Control") != "" {
+		t.Errorf("GET /doc/missing: %d, ETag %q, Cache-Control %q, want 404 without either", w.Code, w.Header().Get("Etag"), w.Header().Get("Cache-Control"))
+	}
+}
+
+func TestPageCache(t *testing.T) {
+	fsys := fstest.MapFS{
+		"site.tmpl":      {Data: []byte(`{{.Content}}{{block "layout" .}}{{end}}`)},
+		"doc/page.md":    {Data: []byte("Hello, *page*.")},
+		"doc/nocache.md": {Data: []byte("---\ncache: false\n---\nHello, *nocache*.")},
+		"doc/draft.md":   {Data: []byte("---\ndraft: true\n---\nHello, *draft*.")},
+		"src/x.go":       {Data: []byte("package x\n")},
+		"texthtml.tmpl":  {Data: []byte(`{{define "layout"}}{{.texthtml}}{{end}}`)},
+	}
+	site := NewSite(fsys)
+	site.SetPageCache(1 << 20)
+	site.SetPreviewToken("secret")
+
+	get := func(path, body string) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		site.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != 200 || !strings.Contains(w.Body.String(), body) {
+			t.Fatalf("GET %s: expected 200 w/ %q: got %d w/ body:\n%s", path, body, w.Code, w.Body)
+		}
+	}
+	check := func(path, body string, hits, misses int64) {
+		t.Helper()
+		get(path, body)
+		st := site.PageCacheStats()
+		if st.Hits != hits || st.Misses != misses {
+			t.Fatalf("after GET %s: stats = %v, want %d hits, %d misses", path, st, hits, misses)
+		}
+	}
+	check("/doc/page", "<em>page</em>", 0, 1)
+	check("/doc/page", "<em>page</em>", 1, 1)
+	check("/doc/page?b=2&a=1", "<em>page</em>", 1, 2)
+	check("/doc/page?a=1&b=2", "<em>page</em>", 2, 2)
+	check("/src/x.go", "package x", 2, 3)
+	check("/src/x.go", "package x", 3, 3)
+	check("/doc/nocache", "<em>nocache</em>", 3, 4)
+	check("/doc/nocache", "<em>nocache</em>", 3, 5)
+
+	// Preview requests bypass the cache.
+	check("/doc/draft?preview=secret", "<em>draft</em>", 3, 5)
+	check("/doc/page?preview=guess", "<em>page</em>", 3, 5)
+
+	// Cached pages are served until the Site is told of the change.
+	fsys["doc/page.md"] = &fstest.MapFile{Data: []byte("Goodbye, *page*.")}
+	site.cache.Delete("doc/page") // skip openPage's 3-second stat delay
+	check("/doc/page", "<em>page</em>", 4, 5)
+	site.Invalidate()
+	check("/doc/page", "Goodbye", 4, 6)
+	if st := site.PageCacheStats(); st.Pages != 1 || st.Evictions != 3 {
+		t.Errorf("after Invalidate: stats = %v, want 1 page, 3 evictions", st)
+	}
+
+	// A cache too small for both pages evicts the least recently used one.
+	get("/src/x.go", "package x")
+	site.SetPageCache(site.PageCacheStats().Bytes - 1)
+	get("/doc/page", "Goodbye")
+	get("/src/x.go", "package x")
+	get("/doc/page", "Goodbye")
+	if st := site.PageCacheStats(); st.Hits != 0 || st.Pages != 1 || st.Evictions != 2 {
+		t.Errorf("small cache: stats = %v, want 0 hits, 1 page, 2 evictions", st)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	site := NewSite(fstest.MapFS{
+		"site.tmpl":         {Data: []byte(`{{.Content}}{{block "layout" .}}{{end}}`)},
+		"doc/ok.md":         {Data: []byte("---\ntemplate: true\n---\n{{\"ok\"}}")},
+		"doc/badmeta.md":    {Data: []byte("---\ntitle: [\n---\nText")},
+		"doc/badtmpl.md":    {Data: []byte("---\ntemplate: true\n---\n{{if}}")},
+		"doc/notmpl.md":     {Data: []byte("{{if}}")},
+		"doc/layout.md":     {Data: []byte("---\nlayout: missing\n---\nText")},
+		"doc/standalone.md": {Data: []byte("<!DOCTYPE html>{{if}}")},
+		"_skip/bad.md":      {Data: []byte("---\ntemplate: true\n---\n{{if}}")},
+	})
+	var got []string
+	for _, err := range site.Check(".") {
+		file, _, _ := strings.Cut(err.Error(), ":")
+		got = append(got, file)
+	}
+	want := []string{"doc/badmeta.md", "doc/badtmpl.md", "doc/layout.md"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Check errors (-want +got):\n%s", diff)
+	}
+
+	// An error in the base template is reported once.
+	site = NewSite(fstest.MapFS{
+		"site.tmpl": {Data: []byte(`{{.Content}`)},
+		"a.md":      {Data: []byte("A")},
+		"b.md":      {Data: []byte("B")},
+	})
+	if errs := site.Check("."); len(errs) != 1 {
+		t.Errorf("Check with bad site.tmpl = %v, want 1 error", errs)
+	}
+}
+
+func TestLint(t *testing.T) {
+	site := NewSite(fstest.MapFS{
+		"site.tmpl":        {Data: []byte("{{.Content}}\n{{block \"layout\" .}}{{end}}")},
+		"doc/ok.md":        {Data: []byte("---\ntemplate: true\n---\n{{code \"x.go\" `/func/` `/^}/`}}")},
+		"doc/x.go":         {Data: []byte("package x\n\nfunc f() {\n}\n")},
+		"doc/badyaml.md":   {Data: []byte("---\ntitle: x\nlayout: [\n---\nText")},
+		"doc/badjson.html": {Data: []byte("<!--{\n\t\"Title\": \"x\",\n}-->\nText")},
+		"doc/layout.md":    {Data: []byte("---\ntitle: x\nlayout: missing\n---\nText")},
+		"doc/nofile.md":    {Data: []byte("---\ntemplate: true\n---\n\n{{code \"y.go\"}}")},
+		"doc/badre.md":     {Data: []byte("---\ntemplate: true\n---\n{{play \"x.go\" `/(/`}}")},
+		"doc/nomatch.md":   {Data: []byte("---\ntemplate: true\n---\n{{code \"x.go\" `/nothing/`}}")},
+		"doc/exec.md":      {Data: []byte("---\ntemplate: true\n---\nText\n{{index .no 1}}")},
+		"doc/redirect.md":  {Data: []byte("---\nredirect: /doc/ok\ntemplate: true\n---\n{{if}}")},
+		"doc/live.md":      {Data: []byte("---\ntemplate: true\ncache: false\n---\n{{code \"y.go\"}}")},
+	})
+	want := []string{
+		"doc/badjson.html:3: invalid character '}'",
+		"doc/badre.md:4: executing \"doc/badre.md\"",
+		"doc/badyaml.md:3: yaml: line 2:",
+		"doc/exec.md:5: executing \"doc/exec.md\"",
+		"doc/layout.md:3: cannot find layout \"missing\"",
+		"doc/nofile.md:5: executing \"doc/nofile.md\"",
+		"doc/nomatch.md:4: executing \"doc/nomatch.md\"",
+	}
+	diags := site.Lint(".")
+	for i, d := range diags {
+		if i >= len(want) || !strings.HasPrefix(d.String(), want[i]) {
+			t.Errorf("Lint diagnostic #%d = %q, want prefix %q", i, d, want[min(i, len(want)-1)])
+		}
+	}
+	if len(diags) != len(want) {
+		t.Errorf("Lint returned %d diagnostics, want %d", len(diags), len(want))
+	}
+}
+
+func TestLanguages(t *testing.T) {
+	site := NewSite(fstest.MapFS{
+		"site.tmpl":           {Data: []byte(`[{{.lang}}]{{range .Alternates}}{{.Lang}}={{.URL}} {{end}}{{.Content}}`)},
+		"doc/page.md":         {Data: []byte("Hello")},
+		"doc/page.zh.md":      {Data: []byte("你好")},
+		"doc/page.pt-BR.md":   {Data: []byte("Olá")},
+		"doc/other.md":        {Data: []byte("Other")},
+		"blog/index.md":       {Data: []byte("Blog")},
+		"blog/index.zh.md":    {Data: []byte("博客")},
+		"blog/post.zh.md":     {Data: []byte("Only Chinese")},
+		"doc/version.2.0.md":  {Data: []byte("Dotted")},
+		"doc/page.zh.nope.md": {Data: []byte("Not a variant")},
+	})
+	site.SetLanguages("en", "zh", "pt-BR")
+
+	get := func(path, accept string) *httptest.ResponseRecorder {
+		t.Helper()
+		r := httptest.NewRequest("GET", path, nil)
+		if accept != "" {
+			r.Header.Set("Accept-Language", accept)
+		}
+		w := httptest.NewRecorder()
+		site.ServeHTTP(w, r)
+		return w
+	}
+
+	const alts = "x-default=/doc/page en=/doc/page?hl=en zh=/doc/page?hl=zh pt-BR=/doc/page?hl=pt-BR "
+	for _, tt := range []struct {
+		path, accept string
+		lang, body   string
+	}{
+		{"/doc/page", "", "en", "[en]" + alts + "<div class='markdown'>\n<p>Hello"},
+		{"/doc/page?hl=zh", "", "zh", "[zh]" + alts + "<div class='markdown'>\n<p>你好"},
+		{"/doc/page?hl=ZH", "", "zh", "<p>你好"},
+		{"/doc/page?hl=en", "zh", "en", "<p>Hello"},
+		{"/doc/page?hl=fr", "zh", "zh", "<p>你好"},
+		{"/doc/page", "zh-TW", "zh", "<p>你好"},
+		{"/doc/page", "fr, pt-br;q=0.8, zh;q=0.5", "pt-BR", "<p>Olá"},
+		{"/doc/page", "pt;q=0.3, en;q=0.7", "en", "<p>Hello"},
+		{"/doc/page", "fr", "en", "<p>Hello"},
+		{"/doc/other", "zh", "en", "[en]<div class='markdown'>\n<p>Other"},
+		{"/blog/", "zh", "zh", "[zh]x-default=/blog/ en=/blog/?hl=en zh=/blog/?hl=zh <div class='markdown'>\n<p>博客"},
+		{"/doc/version.2.0", "", "en", "<p>Dotted"},
+	} {
+		w := get(tt.path, tt.accept)
+		if w.Code != 200 || !strings.Contains(w.Body.String(), tt.body) {
+			t.Errorf("GET %s (Accept-Language: %s): %d %q, want 200 with %q", tt.path, tt.accept, w.Code, w.Body, tt.body)
+		}
+		if lang := w.Header().Get("Content-Language"); lang != tt.lang {
+			t.Errorf("GET %s (Accept-Language: %s): Content-Language = %q, want %q", tt.path, tt.accept, lang, tt.lang)
+		}
+		if vary := w.Header().Get("Vary"); vary != "Accept-Language" {
+			t.Errorf("GET %s: Vary = %q, want Accept-Language", tt.path, vary)
+		}
+	}
+
+	// A request for a missing translation caches the default-language page
+	// under the translation's key too.
+	if p, ok := site.cache.Load("doc/other#zh"); !ok || p.(*pageFile).file != "doc/other.md" {
+		t.Errorf("cache[doc/other#zh] = %v, %v, want doc/other.md", p, ok)
+	}
+
+	// With a base URL, the alternates are absolute.
+	site.SetSiteInfo(SiteInfo{BaseURL: "https://example.com/"})
+	site.cache.Range(func(k, _ any) bool { site.cache.Delete(k); return true })
+	if w := get("/blog/", "zh"); !strings.Contains(w.Body.String(), "x-default=https://example.com/blog/ en=https://example.com/blog/?hl=en ") {
+		t.Errorf("GET /blog/ with base URL: %q, want absolute alternates", w.Body)
+	}
+	site.SetSiteInfo(SiteInfo{})
+
+	for path, target := range map[string]string{
+		"/doc/page.zh":    "/doc/page?hl=zh",
+		"/doc/page.zh.md": "/doc/page?hl=zh",
+		"/blog/index.zh":  "/blog/?hl=zh",
+	} {
+		if w := get(path, ""); w.Code != 301 || w.Header().Get("Location") != target {
+			t.Errorf("GET %s: %d %q, want 301 to %q", path, w.Code, w.Header().Get("Location"), target)
+		}
+	}
+
+	var files []string
+	site.WalkPages(".", func(file string, p Page, err error) error {
+		files = append(files, file)
+		return nil
+	})
+	want := []string{"blog/index.md", "doc/other.md", "doc/page.md", "doc/page.zh.nope.md", "doc/version.2.0.md"}
+	if diff := cmp.Diff(want, files); diff != "" {
+		t.Errorf("WalkPages (-want +got):\n%s", diff)
+	}
+	pages, err := site.Pages("doc/*")
+	if err != nil || len(pages) != 4 {
+		t.Errorf("Pages(doc/*) = %d pages, %v, want 4", len(pages), err)
+	}
+
+	// Check and Lint look at the variants too.
+	site = NewSite(fstest.MapFS{
+		"site.tmpl":      {Data: []byte(`{{.Content}}`)},
+		"doc/page.md":    {Data: []byte("Hello")},
+		"doc/page.zh.md": {Data: []byte("---\nlayout: nope\n---\n你好")},
+	})
+	site.SetLanguages("en", "zh")
+	if errs := site.Check("."); len(errs) != 1 || !strings.HasPrefix(errs[0].Error(), "doc/page.zh.md: ") {
+		t.Errorf("Check = %v, want error in doc/page.zh.md", errs)
+	}
+	if diags := site.Lint("."); len(diags) != 1 || diags[0].File != "doc/page.zh.md" || diags[0].Line != 2 {
+		t.Errorf("Lint = %v, want doc/page.zh.md:2", diags)
+	}
+
+	// Without SetLanguages, variants are ordinary pages.
+	site = NewSite(fstest.MapFS{
+		"site.tmpl":      {Data: []byte(`{{.lang}}{{.Content}}`)},
+		"doc/page.zh.md": {Data: []byte("你好")},
+	})
+	w := get("/doc/page.zh", "zh")
+	if w.Code != 200 || !strings.Contains(w.Body.String(), "<p>你好</p>") || w.Header().Get("Content-Language") != "" || w.Header().Get("Vary") != "" {
+		t.Errorf("GET /doc/page.zh without languages: %d %q %v", w.Code, w.Body, w.Header())
+	}
+}
+
+func TestPublish(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	defer func(old func() time.Time) { timeNow = old }(timeNow)
+	timeNow = func() time.Time { return now }
+
+	site := NewSite(fstest.MapFS{
+		"site.tmpl":        {Data: []byte(`{{.Content}}{{block "layout" .}}{{end}}`)},
+		"error.tmpl":       {Data: []byte(`{{define "layout"}}{{.error}}{{end}}`)},
+		"index.md":         {Data: []byte("---\ntemplate: true\n---\n{{range pages \"/blog/*.md\"}}{{.title}};{{end}}")},
+		"blog/old.md":      {Data: []byte("---\ntitle: Old\ndate: 2026-04-01T00:00:00Z\n---\nOld")},
+		"blog/draft.md":    {Data: []byte("---\ntitle: Draft\ndraft: true\n---\nDraft")},
+		"blog/soon.md":     {Data: []byte("---\ntitle: Soon\ndate: 2026-05-02T00:00:00Z\n---\nSoon")},
+		"blog/olddraft.md": {Data: []byte("---\ntitle: Old Draft\ndate: 2026-04-01T00:00:00Z\ndraft: true\n---\nOld Draft")},
+	})
+	site.SetPageCache(1 << 20)
+	site.SetPreviewToken("secret")
+
+	get := func(path string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		site.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+	check := func(path string, code int, body string) {
+		t.Helper()
+		w := get(path)
+		if w.Code != code || !strings.Contains(w.Body.String(), body) {
+			t.Errorf("GET %s: %d %q, want %d with %q", path, w.Code, w.Body, code, body)
+		}
+	}
+
+	check("/blog/old", 200, "Old")
+	check("/blog/draft", 404, "not exist")
+	check("/blog/soon", 404, "not exist")
+	check("/blog/olddraft", 404, "not exist")
+	check("/", 200, "<p>Old;</p>")
+	check("/blog/soon?preview=wrong", 404, "not exist")
+	check("/blog/draft?preview=secret", 200, "Draft")
+	check("/blog/soon?preview=secret", 200, "Soon")
+	if w := get("/blog/soon?preview=secret"); w.Header().Get("X-Robots-Tag") != "noindex" {
+		t.Errorf("GET /blog/soon?preview=secret: X-Robots-Tag = %q, want noindex", w.Header().Get("X-Robots-Tag"))
+	}
+	if w := get("/blog/old"); w.Header().Get("X-Robots-Tag") != "" {
+		t.Errorf("GET /blog/old: X-Robots-Tag = %q, want none", w.Header().Get("X-Robots-Tag"))
+	}
+
+	pages, err := site.Pages("/blog/*.md")
+	if err != nil || len(pages) != 1 || pages[0]["title"] != "Old" {
+		t.Errorf("Pages = %v, %v, want only Old", pages, err)
+	}
+	var walked []string
+	site.WalkPages("blog", func(file string, p Page, err error) error {
+		walked = append(walked, file)
+		return nil
+	})
+	if len(walked) != 4 {
+		t.Errorf("WalkPages = %v, want all 4 pages", walked)
+	}
+
+	// Once its date passes, the scheduled page appears,
+	// even in the cached listing.
+	now = now.Add(24 * time.Hour)
+	check("/blog/soon", 200, "Soon")
+	check("/", 200, "<p>Old;Soon;</p>")
+	check("/blog/draft", 404, "not exist")
+
+	// Without a preview token, there are no previews.
+	site.SetPreviewToken("")
+	check("/blog/draft?preview=", 404, "not exist")
+}
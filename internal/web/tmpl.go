@@ -92,11 +92,60 @@ func (site *siteDir) page(u string) (Page, error) {
 	return p.page, nil
 }
 
-// Pages returns the pages found in files matching glob.
+// Pages returns the pages found in files matching glob,
+// omitting unpublished pages (see Published).
 func (site *Site) Pages(glob string) ([]Page, error) {
 	return (&siteDir{site, "."}).pages(glob)
 }
 
+// WalkPages calls fn for each page loaded from a .md or .html file
+// in the file tree rooted at dir (or for the page in dir itself, if dir is a file).
+// Directories named testdata or beginning with . or _ are skipped.
+//
+// Files holding a page in one of the site's other languages (see SetLanguages)
+// are not separate pages and are skipped.
+// Unlike Pages, WalkPages includes unpublished pages.
+//
+// If a page cannot be loaded, WalkPages calls fn with a nil Page and the error,
+// and fn decides whether to continue, as in fs.WalkDir.
+// Returning a non-nil error from fn stops the walk and returns that error.
+func (site *Site) WalkPages(dir string, fn func(file string, p Page, err error) error) error {
+	return site.walkPages(dir, false, fn)
+}
+
+// walkPages implements WalkPages.
+// If variants is true, it also calls fn for the pages in the site's other languages.
+func (site *Site) walkPages(dir string, variants bool, fn func(file string, p Page, err error) error) error {
+	return fs.WalkDir(site.fs, dir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fn(file, nil, err)
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if file != dir && (name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(file, ".md") && !strings.HasSuffix(file, ".html") {
+			return nil
+		}
+		base, lang := site.splitLang(file)
+		if lang != "" && !variants {
+			return nil
+		}
+		p, err := site.openPageLang(base, lang)
+		if err != nil {
+			return fn(file, nil, err)
+		}
+		if p.file != file {
+			// Another file (x.md instead of x.html) takes priority for this page.
+			return nil
+		}
+		return fn(file, p.page, nil)
+	})
+}
+
 // pages returns the page params for pages with urls matching glob.
 func (site *siteDir) pages(glob string) ([]Page, error) {
 	if !path.IsAbs(glob) {
@@ -127,10 +176,19 @@ func (site *siteDir) pages(glob string) ([]Page, error) {
 			}
 			file = f
 		}
+		if _, lang := site.splitLang(file); lang != "" {
+			continue
+		}
 		p, err := site.openPage(file)
 		if err != nil {
 			return nil, fmt.Errorf("%s: %v", file, err)
 		}
+		if !Published(p.page) {
+			if t := Scheduled(p.page); !t.IsZero() {
+				site.schedule(t)
+			}
+			continue
+		}
 		out = append(out, p.page)
 	}
 
@@ -0,0 +1,111 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package web
+
+import (
+	"strconv"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// A Heading is an entry in a page's table of contents,
+// stored in the page's “TOC” key as a []*Heading.
+type Heading struct {
+	Level int        // heading level: 2 for ## or <h2>
+	ID    string     // heading ID, for linking to #ID
+	Text  string     // heading text
+	Sub   []*Heading // nested headings of the next level
+}
+
+// tocKey is the parser.Context key under which mdHeadings
+// stores the table of contents.
+var tocKey = parser.NewContextKey()
+
+// minTOC is the minimum number of headings in a table of contents.
+// A page with fewer headings has no table of contents.
+const minTOC = 2
+
+// mdHeadings walks doc, making sure that every heading has a unique ID
+// and collecting the level 2 and 3 headings into a table of contents,
+// which it stores in pc under tocKey.
+//
+// Headings without an explicit {#id} get an ID derived from their text
+// during parsing (see parser.WithAutoHeadingID), which is unique
+// among those IDs. mdHeadings renames the later of two headings with
+// the same explicit ID, by adding -1, -2, and so on, as the automatic IDs do.
+func mdHeadings(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	var headings []*ast.Heading
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if h, ok := n.(*ast.Heading); ok && entering {
+			headings = append(headings, h)
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+
+	used := make(map[string]bool)
+	for _, h := range headings {
+		if id, ok := headingID(h); ok {
+			used[id] = true
+		}
+	}
+	seen := make(map[string]bool)
+	var toc []*Heading
+	var n int
+	for _, h := range headings {
+		id, ok := headingID(h)
+		if !ok {
+			continue
+		}
+		if seen[id] {
+			for i := 1; ; i++ {
+				if s := id + "-" + strconv.Itoa(i); !used[s] {
+					id = s
+					break
+				}
+			}
+			used[id] = true
+			h.SetAttributeString("id", []byte(id))
+		}
+		seen[id] = true
+
+		e := &Heading{Level: h.Level, ID: id, Text: string(h.Text(reader.Source()))}
+		switch h.Level {
+		default:
+			continue
+		case 2:
+			toc = append(toc, e)
+		case 3:
+			if len(toc) > 0 && toc[len(toc)-1].Level == 2 {
+				last := toc[len(toc)-1]
+				last.Sub = append(last.Sub, e)
+			} else {
+				// An h3 before any h2 is listed at the top level.
+				toc = append(toc, e)
+			}
+		}
+		n++
+	}
+	if n >= minTOC {
+		pc.Set(tocKey, toc)
+	}
+}
+
+// headingID returns the ID attribute of h.
+func headingID(h *ast.Heading) (string, bool) {
+	v, ok := h.AttributeString("id")
+	if !ok {
+		return "", false
+	}
+	switch v := v.(type) {
+	case []byte:
+		return string(v), true
+	case string:
+		return v, true
+	}
+	return "", false
+}